@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"io/fs"
 	"log"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pet-medical/api/internal/auth"
@@ -17,6 +19,9 @@ import (
 	"github.com/pet-medical/api/internal/handlers"
 	"github.com/pet-medical/api/internal/i18n"
 	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/notify"
+	"github.com/pet-medical/api/internal/oidc"
+	"github.com/pet-medical/api/internal/webhooks"
 )
 
 //go:embed static/*
@@ -47,6 +52,22 @@ func main() {
 		log.Fatalf("seed demo: %v", err)
 	}
 
+	mailer := notify.NewSMTPMailer(cfg)
+	if mailer != nil {
+		reminders := &notify.ReminderScheduler{
+			DB:              gormDB,
+			Mailer:          mailer,
+			Interval:        time.Duration(cfg.ReminderIntervalMin) * time.Minute,
+			PublicURL:       cfg.PublicURL,
+			DefaultLanguage: cfg.DefaultLanguage,
+		}
+		reminders.Start(context.Background())
+		log.Printf("reminder emails enabled via %s:%d (every %d min)", cfg.SMTPHost, cfg.SMTPPort, cfg.ReminderIntervalMin)
+	}
+
+	dispatcher := webhooks.NewDispatcher(gormDB, cfg.WebhookAllowPrivateTargets)
+	dispatcher.Start(context.Background())
+
 	jwt := auth.NewJWT(cfg.JWTSecret, cfg.JWTAccessTTL, cfg.JWTRefreshTTL)
 	refreshStore := auth.NewRefreshStore(gormDB)
 
@@ -59,16 +80,41 @@ func main() {
 		DB:                gormDB,
 		JWT:               jwt,
 		RefreshStore:      refreshStore,
+		ResetStore:        auth.NewResetStore(gormDB),
+		MFAStore:          auth.NewMFAStore(gormDB),
 		Config:            cfg,
 		DefaultWeightUnit: cfg.DefaultWeightUnit,
 		DefaultCurrency:   cfg.DefaultCurrency,
 		DefaultLanguage:   cfg.DefaultLanguage,
 		SameSiteCookie:    int(cfg.SameSiteCookie),
 	}
-	petsHandler := &handlers.PetsHandler{DB: gormDB, UploadDir: uploadDir}
-	vaccHandler := &handlers.VaccinationsHandler{DB: gormDB}
-	weightsHandler := &handlers.WeightsHandler{DB: gormDB}
-	docsHandler := &handlers.DocumentsHandler{DB: gormDB, UploadDir: uploadDir, MaxDocumentBytes: cfg.MaxUploadDocumentBytes}
+	if mailer != nil {
+		// Assigned only when set: a nil *SMTPMailer in the interface would not compare equal to nil.
+		authHandler.Mailer = mailer
+	}
+	if cfg.OIDCEnabled() {
+		authHandler.OIDC = oidc.New(cfg.OIDCIssuerURL, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCScopes)
+		log.Printf("OpenID Connect login enabled (issuer %s, auto-provision %v)", cfg.OIDCIssuerURL, cfg.OIDCAutoProvision)
+	}
+	petsHandler := &handlers.PetsHandler{DB: gormDB, UploadDir: uploadDir, Webhooks: dispatcher}
+	vaccHandler := &handlers.VaccinationsHandler{DB: gormDB, Webhooks: dispatcher}
+	weightsHandler := &handlers.WeightsHandler{DB: gormDB, Webhooks: dispatcher}
+	medsHandler := &handlers.MedicationsHandler{DB: gormDB}
+	visitsHandler := &handlers.VisitsHandler{DB: gormDB}
+	clinicsHandler := &handlers.ClinicsHandler{DB: gormDB}
+	allergiesHandler := &handlers.AllergiesHandler{DB: gormDB}
+	conditionsHandler := &handlers.ConditionsHandler{DB: gormDB}
+	labsHandler := &handlers.LabsHandler{DB: gormDB}
+	dueHandler := &handlers.DueHandler{DB: gormDB}
+	calendarHandler := &handlers.CalendarHandler{DB: gormDB, Config: cfg}
+	webhooksHandler := &handlers.WebhooksHandler{DB: gormDB, Webhooks: dispatcher}
+	membershipsHandler := &handlers.MembershipsHandler{DB: gormDB}
+	householdsHandler := &handlers.HouseholdsHandler{DB: gormDB}
+	shareLinksHandler := &handlers.ShareLinksHandler{DB: gormDB, Config: cfg, UploadDir: uploadDir}
+	certificatesHandler := &handlers.CertificatesHandler{DB: gormDB, Config: cfg}
+	exportHandler := &handlers.ExportHandler{DB: gormDB, UploadDir: uploadDir}
+	importHandler := &handlers.ImportHandler{DB: gormDB, UploadDir: uploadDir, MaxDocumentBytes: cfg.MaxUploadDocumentBytes, MaxPhotoBytes: cfg.MaxUploadPhotoBytes}
+	docsHandler := &handlers.DocumentsHandler{DB: gormDB, UploadDir: uploadDir, MaxDocumentBytes: cfg.MaxUploadDocumentBytes, Webhooks: dispatcher}
 	photosHandler := &handlers.PhotosHandler{DB: gormDB, UploadDir: uploadDir, MaxPhotoBytes: cfg.MaxUploadPhotoBytes}
 	usersHandler := &handlers.UsersHandler{
 		DB:                gormDB,
@@ -95,31 +141,138 @@ func main() {
 
 	// Public auth
 	router.HandleFunc("/api/auth/login", authHandler.Login).Methods(http.MethodPost)
+	router.HandleFunc("/api/auth/login/mfa", authHandler.LoginMFA).Methods(http.MethodPost)
+	router.HandleFunc("/api/auth/login/passkey/options", authHandler.PasskeyLoginOptions).Methods(http.MethodPost)
+	router.HandleFunc("/api/auth/login/passkey", authHandler.PasskeyLogin).Methods(http.MethodPost)
+	router.HandleFunc("/api/auth/oidc", authHandler.OIDCStatus).Methods(http.MethodGet)
+	router.HandleFunc("/api/auth/oidc/login", authHandler.OIDCLogin).Methods(http.MethodGet)
+	router.HandleFunc("/api/auth/oidc/callback", authHandler.OIDCCallback).Methods(http.MethodGet)
+	router.HandleFunc("/api/auth/forgot-password", authHandler.ForgotPassword).Methods(http.MethodPost)
+	router.HandleFunc("/api/auth/reset-password", authHandler.ResetPassword).Methods(http.MethodPost)
 	router.HandleFunc("/api/auth/refresh", authHandler.Refresh).Methods(http.MethodPost)
 	router.HandleFunc("/api/auth/logout", authHandler.Logout).Methods(http.MethodPost)
 
+	// Public ICS feed: authenticated by the secret token in the URL (calendar clients cannot send our cookies)
+	router.HandleFunc("/api/calendar/{token:[0-9a-f]{64}}.ics", calendarHandler.Feed).Methods(http.MethodGet)
+
+	// Public read-only share links for vets and sitters: authorized by the token, limited to the link's scopes
+	router.HandleFunc("/api/share/{token:[0-9a-f]{64}}", shareLinksHandler.View).Methods(http.MethodGet)
+	router.HandleFunc("/api/share/{token:[0-9a-f]{64}}/documents/{id}", shareLinksHandler.Document).Methods(http.MethodGet)
+	// Public verification of vaccination certificates (the QR code on the PDF): authorized by the signed token
+	router.HandleFunc("/api/verify/vaccinations/{token:[A-Za-z0-9_-]+\\.[A-Za-z0-9_-]+}", certificatesHandler.Verify).Methods(http.MethodGet)
+
 	// Protected API
 	api := router.PathPrefix("/api").Subrouter()
 	api.Use(middleware.AuthRequired(jwt))
 	api.HandleFunc("/auth/me", authHandler.Me).Methods(http.MethodGet)
 	api.HandleFunc("/auth/change-password", authHandler.ChangePassword).Methods(http.MethodPost, http.MethodPut)
+	api.HandleFunc("/auth/2fa", authHandler.TwoFactor).Methods(http.MethodGet)
+	api.HandleFunc("/auth/2fa/setup", authHandler.TwoFactorSetup).Methods(http.MethodPost)
+	api.HandleFunc("/auth/2fa/confirm", authHandler.TwoFactorConfirm).Methods(http.MethodPost)
+	api.HandleFunc("/auth/2fa/disable", authHandler.TwoFactorDisable).Methods(http.MethodPost)
+	api.HandleFunc("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes).Methods(http.MethodPost)
+	api.HandleFunc("/auth/passkeys", authHandler.ListPasskeys).Methods(http.MethodGet)
+	api.HandleFunc("/auth/passkeys/register/options", authHandler.PasskeyRegisterOptions).Methods(http.MethodPost)
+	api.HandleFunc("/auth/passkeys/register", authHandler.PasskeyRegister).Methods(http.MethodPost)
+	api.HandleFunc("/auth/passkeys/{id}", authHandler.RenamePasskey).Methods(http.MethodPatch)
+	api.HandleFunc("/auth/passkeys/{id}", authHandler.DeletePasskey).Methods(http.MethodDelete)
+	api.HandleFunc("/auth/sessions", authHandler.ListSessions).Methods(http.MethodGet)
+	api.HandleFunc("/auth/sessions/revoke-others", authHandler.RevokeOtherSessions).Methods(http.MethodPost)
+	api.HandleFunc("/auth/sessions/{id}", authHandler.RevokeSession).Methods(http.MethodDelete)
 	api.HandleFunc("/settings", settingsHandler.GetMine).Methods(http.MethodGet)
 	api.HandleFunc("/settings", settingsHandler.UpdateMine).Methods(http.MethodPut, http.MethodPatch)
+	api.HandleFunc("/export", exportHandler.Export).Methods(http.MethodGet)
+	api.HandleFunc("/import", importHandler.Import).Methods(http.MethodPost)
 	api.HandleFunc("/custom-options", customOptsHandler.Get).Methods(http.MethodGet)
 	api.HandleFunc("/custom-options", customOptsHandler.Add).Methods(http.MethodPost)
+	api.HandleFunc("/clinics", clinicsHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/clinics", clinicsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/clinics/{id}", clinicsHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/clinics/{id}", clinicsHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/clinics/{id}", clinicsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/due", dueHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/calendar/feed", calendarHandler.GetFeed).Methods(http.MethodGet)
+	api.HandleFunc("/calendar/feed", calendarHandler.RotateFeed).Methods(http.MethodPost)
+	api.HandleFunc("/calendar/feed", calendarHandler.RevokeFeed).Methods(http.MethodDelete)
+	api.HandleFunc("/households", householdsHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/households", householdsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/households/{id}", householdsHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/households/{id}", householdsHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/households/{id}", householdsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/households/{id}/members", householdsHandler.AddMember).Methods(http.MethodPost)
+	api.HandleFunc("/households/{id}/members/{userId}", householdsHandler.UpdateMember).Methods(http.MethodPut)
+	api.HandleFunc("/households/{id}/members/{userId}", householdsHandler.RemoveMember).Methods(http.MethodDelete)
+	api.HandleFunc("/invitations", membershipsHandler.Received).Methods(http.MethodGet)
+	api.HandleFunc("/invitations/{id}/accept", membershipsHandler.Accept).Methods(http.MethodPost)
+	api.HandleFunc("/invitations/{id}/decline", membershipsHandler.Decline).Methods(http.MethodPost)
+	api.HandleFunc("/webhooks", webhooksHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/webhooks", webhooksHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/webhooks/events", webhooksHandler.Events).Methods(http.MethodGet) // before {id}
+	api.HandleFunc("/webhooks/{id}", webhooksHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/webhooks/{id}", webhooksHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/webhooks/{id}/deliveries", webhooksHandler.Deliveries).Methods(http.MethodGet)
+	api.HandleFunc("/webhooks/{id}/test", webhooksHandler.Test).Methods(http.MethodPost)
 	api.HandleFunc("/pets", petsHandler.List).Methods(http.MethodGet)
 	api.HandleFunc("/pets", petsHandler.Create).Methods(http.MethodPost)
 	api.HandleFunc("/pets/{id}", petsHandler.Get).Methods(http.MethodGet)
 	api.HandleFunc("/pets/{id}", petsHandler.Update).Methods(http.MethodPut)
 	api.HandleFunc("/pets/{id}", petsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{id}/transfer", householdsHandler.TransferPet).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{id}/summary.pdf", petsHandler.Summary).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/share-links", shareLinksHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/share-links", shareLinksHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/share-links/{id}", shareLinksHandler.Revoke).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/share-links/{id}/access-log", shareLinksHandler.AccessLog).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/members", membershipsHandler.Members).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/members/{userId}", membershipsHandler.UpdateMember).Methods(http.MethodPut)
+	api.HandleFunc("/pets/{petId}/members/{userId}", membershipsHandler.RemoveMember).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/invitations", membershipsHandler.ListInvitations).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/invitations", membershipsHandler.Invite).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/invitations/{id}", membershipsHandler.RevokeInvitation).Methods(http.MethodDelete)
 	api.HandleFunc("/pets/{petId}/vaccinations", vaccHandler.List).Methods(http.MethodGet)
 	api.HandleFunc("/pets/{petId}/vaccinations", vaccHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/vaccinations.csv", vaccHandler.ExportCSV).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/vaccinations/import", vaccHandler.ImportCSV).Methods(http.MethodPost)
 	api.HandleFunc("/pets/{petId}/vaccinations/{id}", vaccHandler.Get).Methods(http.MethodGet)
 	api.HandleFunc("/pets/{petId}/vaccinations/{id}", vaccHandler.Update).Methods(http.MethodPut)
 	api.HandleFunc("/pets/{petId}/vaccinations/{id}", vaccHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/vaccinations/{id}/certificate.pdf", certificatesHandler.Certificate).Methods(http.MethodGet)
 	api.HandleFunc("/pets/{petId}/weights", weightsHandler.List).Methods(http.MethodGet)
 	api.HandleFunc("/pets/{petId}/weights", weightsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/weights.csv", weightsHandler.ExportCSV).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/weights/import", weightsHandler.ImportCSV).Methods(http.MethodPost)
 	api.HandleFunc("/pets/{petId}/weights/{id}", weightsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/medications", medsHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/medications", medsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/medications/adherence", medsHandler.Adherence).Methods(http.MethodGet) // before {id}
+	api.HandleFunc("/pets/{petId}/medications/{id}", medsHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/medications/{id}", medsHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/pets/{petId}/medications/{id}", medsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/medications/{id}/doses", medsHandler.ListDoses).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/medications/{id}/doses", medsHandler.LogDose).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/medications/{id}/doses/{doseId}", medsHandler.DeleteDose).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/visits", visitsHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/visits", visitsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/visits/{id}", visitsHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/visits/{id}", visitsHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/pets/{petId}/visits/{id}", visitsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/allergies", allergiesHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/allergies", allergiesHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/allergies/{id}", allergiesHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/allergies/{id}", allergiesHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/pets/{petId}/allergies/{id}", allergiesHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/conditions", conditionsHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/conditions", conditionsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/conditions/{id}", conditionsHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/conditions/{id}", conditionsHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/pets/{petId}/conditions/{id}", conditionsHandler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/pets/{petId}/labs", labsHandler.List).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/labs", labsHandler.Create).Methods(http.MethodPost)
+	api.HandleFunc("/pets/{petId}/labs/analytes", labsHandler.Analytes).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/labs/history", labsHandler.History).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/labs/{id}", labsHandler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/pets/{petId}/labs/{id}", labsHandler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/pets/{petId}/labs/{id}", labsHandler.Delete).Methods(http.MethodDelete)
 	api.HandleFunc("/pets/{petId}/documents", docsHandler.List).Methods(http.MethodGet)
 	api.HandleFunc("/pets/{petId}/documents", docsHandler.Create).Methods(http.MethodPost)
 	api.HandleFunc("/pets/{petId}/documents/{id}", docsHandler.Get).Methods(http.MethodGet)
@@ -139,10 +292,14 @@ func main() {
 	api.Handle("/admin/default-options/{id}", middleware.AdminRequired(http.HandlerFunc(defaultOptsHandler.Update))).Methods(http.MethodPut, http.MethodPatch)
 	api.Handle("/admin/default-options/{id}", middleware.AdminRequired(http.HandlerFunc(defaultOptsHandler.Delete))).Methods(http.MethodDelete)
 
+	// Admin-only: compute next_due for existing vaccinations from option durations
+	api.Handle("/admin/vaccinations/backfill-next-due", middleware.AdminRequired(http.HandlerFunc(vaccHandler.BackfillNextDue))).Methods(http.MethodPost)
+
 	// Admin-only: user management
 	api.Handle("/users", middleware.AdminRequired(http.HandlerFunc(usersHandler.List))).Methods(http.MethodGet)
 	api.Handle("/users", middleware.AdminRequired(http.HandlerFunc(usersHandler.Create))).Methods(http.MethodPost)
 	api.Handle("/users/{id}/role", middleware.AdminRequired(http.HandlerFunc(usersHandler.UpdateRole))).Methods(http.MethodPut, http.MethodPatch)
+	api.Handle("/users/{id}/2fa", middleware.AdminRequired(http.HandlerFunc(usersHandler.ResetTwoFactor))).Methods(http.MethodDelete)
 	api.Handle("/users/{id}/settings", middleware.AdminRequired(http.HandlerFunc(settingsHandler.GetForUser))).Methods(http.MethodGet)
 	api.Handle("/users/{id}/settings", middleware.AdminRequired(http.HandlerFunc(settingsHandler.UpdateForUser))).Methods(http.MethodPut, http.MethodPatch)
 
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"time"
 
@@ -68,3 +70,50 @@ func (j *JWT) ParseAccessToken(tokenString string) (*Claims, error) {
 }
 
 func (j *JWT) RefreshTokenDuration() time.Duration { return j.refreshTTL }
+
+// MFATokenTTL is how long a user has to enter their second factor after the password step.
+const MFATokenTTL = 5 * time.Minute
+
+const mfaAudience = "mfa"
+
+// mfaKey signs "mfa pending" tokens. It differs from the access token key so ParseAccessToken rejects them.
+func (j *JWT) mfaKey() []byte {
+	mac := hmac.New(sha256.New, []byte(j.secret))
+	mac.Write([]byte("pet-medical mfa pending token"))
+	return mac.Sum(nil)
+}
+
+// NewMFAToken returns a short-lived token proving the password step succeeded for userID. It grants no API access;
+// it is only exchanged, together with a second factor, for a real session.
+func (j *JWT) NewMFAToken(userID uuid.UUID) (string, error) {
+	now := time.Now()
+	claims := &jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(MFATokenTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        uuid.New().String(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.mfaKey())
+}
+
+// ParseMFAToken returns the user a token from NewMFAToken was issued to and the token's own ID (its jti), which
+// MFAStore uses to let each token be tried only once.
+func (j *JWT) ParseMFAToken(tokenString string) (userID, tokenID uuid.UUID, err error) {
+	claims := &jwt.RegisteredClaims{}
+	tok, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+		return j.mfaKey(), nil
+	}, jwt.WithAudience(mfaAudience), jwt.WithExpirationRequired())
+	if err != nil || !tok.Valid {
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
+	}
+	userID, err1 := uuid.Parse(claims.Subject)
+	tokenID, err2 := uuid.Parse(claims.ID)
+	if err1 != nil || err2 != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
+	}
+	return userID, tokenID, nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxMFAFailures is how many wrong second factors a user may enter within MFAFailureWindow, across all their MFA
+// tokens, before further attempts are refused until the window has passed.
+const (
+	MaxMFAFailures   = 5
+	MFAFailureWindow = 15 * time.Minute
+)
+
+var (
+	ErrMFATokenUsed       = errors.New("mfa token already used")
+	ErrTooManyMFAFailures = errors.New("too many failed second-factor attempts")
+)
+
+// MFAStore makes MFA tokens single-use and counts failed attempts per user, so a stolen password cannot be turned
+// into unlimited code guesses by reusing one token or spreading requests over many IPs.
+type MFAStore struct {
+	db *gorm.DB
+}
+
+func NewMFAStore(db *gorm.DB) *MFAStore {
+	return &MFAStore{db: db}
+}
+
+// Begin spends the token tokenID for userID. It fails with ErrMFATokenUsed when the token was already tried and with
+// ErrTooManyMFAFailures when the user has MaxMFAFailures recent failures. The attempt counts as failed until
+// Succeeded is called.
+func (s *MFAStore) Begin(tokenID, userID uuid.UUID) error {
+	since := time.Now().Add(-MFAFailureWindow)
+	// Tokens live for MFATokenTTL, shorter than the window, so older rows can no longer matter.
+	s.db.Where("created_at < ?", since).Delete(&models.MFAAttempt{})
+	var failures int64
+	if err := s.db.Model(&models.MFAAttempt{}).Where("user_id = ? AND succeeded = ? AND created_at >= ?", userID, false, since).
+		Count(&failures).Error; err != nil {
+		return err
+	}
+	if failures >= MaxMFAFailures {
+		return ErrTooManyMFAFailures
+	}
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.MFAAttempt{ID: tokenID, UserID: userID})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrMFATokenUsed
+	}
+	return nil
+}
+
+// Succeeded marks the attempt for tokenID as successful, so it does not count toward the user's failures.
+func (s *MFAStore) Succeeded(tokenID uuid.UUID) error {
+	return s.db.Model(&models.MFAAttempt{}).Where("id = ?", tokenID).Update("succeeded", true).Error
+}
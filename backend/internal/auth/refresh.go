@@ -13,6 +13,15 @@ import (
 
 const refreshTokenBytes = 32
 
+// maxUserAgentLen bounds the stored User-Agent; browsers send ~150 characters, anything far longer is noise.
+const maxUserAgentLen = 512
+
+// Device describes the client a refresh token was issued to, shown in the session list.
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
 type RefreshStore struct {
 	db *gorm.DB
 }
@@ -21,17 +30,21 @@ func NewRefreshStore(db *gorm.DB) *RefreshStore {
 	return &RefreshStore{db: db}
 }
 
-func (s *RefreshStore) Create(userID uuid.UUID, expiresAt time.Time) (token string, err error) {
-	b := make([]byte, refreshTokenBytes)
-	if _, err = rand.Read(b); err != nil {
+// Create starts a new session for userID and returns its refresh token. The user's expired sessions are removed.
+func (s *RefreshStore) Create(userID uuid.UUID, expiresAt time.Time, device Device) (token string, err error) {
+	token, err = newRefreshToken()
+	if err != nil {
 		return "", err
 	}
-	token = hex.EncodeToString(b)
-	hash := HashToken(token)
+	_ = s.db.Where("user_id = ? AND expires_at <= NOW()", userID).Delete(&models.RefreshToken{}).Error
+	now := time.Now()
 	rec := models.RefreshToken{
-		UserID:    userID,
-		TokenHash: hash,
-		ExpiresAt: expiresAt,
+		UserID:     userID,
+		TokenHash:  HashToken(token),
+		ExpiresAt:  expiresAt,
+		UserAgent:  truncateUserAgent(device.UserAgent),
+		IP:         device.IP,
+		LastUsedAt: &now,
 	}
 	if err = s.db.Create(&rec).Error; err != nil {
 		return "", err
@@ -39,16 +52,67 @@ func (s *RefreshStore) Create(userID uuid.UUID, expiresAt time.Time) (token stri
 	return token, nil
 }
 
-func (s *RefreshStore) Consume(token string) (userID uuid.UUID, err error) {
+// Rotate replaces a valid refresh token with a new one in the same session and records the device's latest use.
+// The update is conditional on the old hash, so when two refreshes race with one token only the first succeeds.
+func (s *RefreshStore) Rotate(token string, expiresAt time.Time, device Device) (userID uuid.UUID, newToken string, err error) {
 	hash := HashToken(token)
 	var rec models.RefreshToken
 	err = s.db.Where("token_hash = ? AND expires_at > NOW()", hash).First(&rec).Error
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
+	}
+	newToken, err = newRefreshToken()
+	if err != nil {
+		return uuid.Nil, "", err
 	}
-	userID = rec.UserID
-	_ = s.db.Where("token_hash = ?", hash).Delete(&models.RefreshToken{}).Error
-	return userID, nil
+	result := s.db.Model(&models.RefreshToken{}).Where("id = ? AND token_hash = ?", rec.ID, hash).Updates(map[string]interface{}{
+		"token_hash":   HashToken(newToken),
+		"expires_at":   expiresAt,
+		"user_agent":   truncateUserAgent(device.UserAgent),
+		"ip":           device.IP,
+		"last_used_at": time.Now(),
+	})
+	if result.Error != nil {
+		return uuid.Nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return uuid.Nil, "", gorm.ErrRecordNotFound
+	}
+	return rec.UserID, newToken, nil
+}
+
+// Lookup returns the unexpired session a refresh token belongs to.
+func (s *RefreshStore) Lookup(token string) (*models.RefreshToken, error) {
+	var rec models.RefreshToken
+	if err := s.db.Where("token_hash = ? AND expires_at > NOW()", HashToken(token)).First(&rec).Error; err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// List returns the user's unexpired sessions, most recently used first.
+func (s *RefreshStore) List(userID uuid.UUID) ([]models.RefreshToken, error) {
+	list := []models.RefreshToken{}
+	err := s.db.Where("user_id = ? AND expires_at > NOW()", userID).
+		Order("last_used_at DESC NULLS LAST").Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+// Revoke deletes the session a refresh token belongs to (logout).
+func (s *RefreshStore) Revoke(token string) error {
+	return s.db.Where("token_hash = ?", HashToken(token)).Delete(&models.RefreshToken{}).Error
+}
+
+// RevokeSession deletes one of the user's sessions by ID and reports whether it existed.
+func (s *RefreshStore) RevokeSession(userID, sessionID uuid.UUID) (bool, error) {
+	result := s.db.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.RefreshToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeOthers deletes every session of the user except keepID and returns how many were removed.
+func (s *RefreshStore) RevokeOthers(userID, keepID uuid.UUID) (int64, error) {
+	result := s.db.Where("user_id = ? AND id <> ?", userID, keepID).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
 }
 
 func (s *RefreshStore) RevokeAllForUser(userID uuid.UUID) error {
@@ -59,3 +123,23 @@ func HashToken(token string) string {
 	h := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(h[:])
 }
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func truncateUserAgent(ua string) string {
+	if len(ua) <= maxUserAgentLen {
+		return ua
+	}
+	// Cut at a rune boundary so the column holds valid UTF-8.
+	cut := maxUserAgentLen
+	for cut > 0 && ua[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return ua[:cut]
+}
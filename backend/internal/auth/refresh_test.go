@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateUserAgent(t *testing.T) {
+	short := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X)"
+	if got := truncateUserAgent(short); got != short {
+		t.Errorf("short user agent changed: %q", got)
+	}
+	// A multi-byte rune straddling the limit must not be split.
+	long := strings.Repeat("a", maxUserAgentLen-1) + "é" + strings.Repeat("b", 100)
+	got := truncateUserAgent(long)
+	if len(got) != maxUserAgentLen-1 || !utf8.ValidString(got) {
+		t.Errorf("truncated to %d bytes, valid=%v", len(got), utf8.ValidString(got))
+	}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// PasswordResetTTL is how long an emailed reset link stays valid.
+const PasswordResetTTL = time.Hour
+
+const resetTokenBytes = 32
+
+// ResetStore issues and redeems password reset tokens. Like refresh tokens, only their hash is stored.
+type ResetStore struct {
+	db *gorm.DB
+}
+
+func NewResetStore(db *gorm.DB) *ResetStore {
+	return &ResetStore{db: db}
+}
+
+// Create returns a new reset token for userID and invalidates any earlier unused ones, so only the latest link works.
+func (s *ResetStore) Create(userID uuid.UUID) (token string, err error) {
+	b := make([]byte, resetTokenBytes)
+	if _, err = rand.Read(b); err != nil {
+		return "", err
+	}
+	token = hex.EncodeToString(b)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			TokenHash: HashToken(token),
+			ExpiresAt: time.Now().Add(PasswordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RecentlyIssued reports whether a token for userID was created after since; used to avoid flooding an inbox.
+func (s *ResetStore) RecentlyIssued(userID uuid.UUID, since time.Time) bool {
+	var n int64
+	s.db.Model(&models.PasswordResetToken{}).Where("user_id = ? AND used_at IS NULL AND created_at > ?", userID, since).Count(&n)
+	return n > 0
+}
+
+// Consume marks an unexpired, unused token as used and returns its user. The update is conditional, so a token
+// redeemed by two requests at once succeeds only for one.
+func (s *ResetStore) Consume(token string) (userID uuid.UUID, err error) {
+	hash := HashToken(token)
+	var rec models.PasswordResetToken
+	err = s.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > NOW()", hash).First(&rec).Error
+	if err != nil {
+		return uuid.Nil, err
+	}
+	result := s.db.Model(&models.PasswordResetToken{}).Where("id = ? AND used_at IS NULL", rec.ID).Update("used_at", time.Now())
+	if result.Error != nil {
+		return uuid.Nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	return rec.UserID, nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, which every authenticator app supports).
+const (
+	totpDigits      = 6
+	totpModulo      = 1_000_000 // 10^totpDigits
+	totpPeriod      = 30 * time.Second
+	totpSecretBytes = 20
+	totpSkew        = 1 // steps accepted either side of now, for clock drift
+)
+
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // no 0/o, 1/l/i
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random secret, base32 encoded as authenticator apps expect.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI returns the otpauth:// URI an authenticator app scans from a QR code.
+func TOTPURI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// TOTPCode returns the code for the time step containing t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+	return totpCodeAt(key, t.Unix()/int64(totpPeriod.Seconds())), nil
+}
+
+func totpCodeAt(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	n := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, n%totpModulo)
+}
+
+// ValidateTOTP checks code against the steps around t and returns the step it matched. Steps at or before lastStep
+// are rejected so a code cannot be replayed; callers store the returned step as the new lastStep.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (step int64, ok bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return 0, false
+	}
+	now := t.Unix() / int64(totpPeriod.Seconds())
+	for s := now - totpSkew; s <= now+totpSkew; s++ {
+		if s <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCodeAt(key, s)), []byte(code)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes returns n one-time codes like "k7qm-x2hp-9tdw". Store only their HashRecoveryCode.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	const length = 12
+	// Bytes at or above limit are skipped so every character is equally likely.
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+	codes := make([]string, n)
+	buf := make([]byte, 32)
+	for i := range codes {
+		var sb strings.Builder
+		for chars := 0; chars < length; {
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			for _, c := range buf {
+				if int(c) >= limit || chars == length {
+					continue
+				}
+				if chars > 0 && chars%4 == 0 {
+					sb.WriteByte('-')
+				}
+				sb.WriteByte(recoveryCodeAlphabet[int(c)%len(recoveryCodeAlphabet)])
+				chars++
+			}
+		}
+		codes[i] = sb.String()
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode hashes a recovery code as typed, ignoring case, spaces, and dashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return HashToken(code)
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RFC 6238 appendix B (SHA-1), truncated to six digits.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		got, err := TOTPCode(rfcSecret, time.Unix(unix, 0))
+		if err != nil || got != want {
+			t.Errorf("TOTPCode(%d) = %s, %v; want %s", unix, got, err, want)
+		}
+	}
+}
+
+func TestValidateTOTP_SkewAndReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	prev, _ := TOTPCode(rfcSecret, now.Add(-30*time.Second))
+	step, ok := ValidateTOTP(rfcSecret, prev, now, 0)
+	if !ok || step != now.Unix()/30-1 {
+		t.Fatalf("previous step: ok=%v step=%d", ok, step)
+	}
+	if _, ok := ValidateTOTP(rfcSecret, prev, now, step); ok {
+		t.Error("a code must not be accepted twice")
+	}
+	old, _ := TOTPCode(rfcSecret, now.Add(-90*time.Second))
+	if _, ok := ValidateTOTP(rfcSecret, old, now, 0); ok {
+		t.Error("code three steps old accepted")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, "12345", now, 0); ok {
+		t.Error("short code accepted")
+	}
+	cur, _ := TOTPCode(rfcSecret, now)
+	if _, ok := ValidateTOTP(rfcSecret, cur[:3]+" "+cur[3:], now, 0); !ok {
+		t.Error("code typed with a space rejected")
+	}
+}
+
+func TestGenerateTOTPSecretAndURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil || len(secret) != 32 {
+		t.Fatalf("secret = %q, %v", secret, err)
+	}
+	u, err := url.Parse(TOTPURI("Pet Medical", "a@b.c", secret))
+	if err != nil || u.Scheme != "otpauth" || u.Host != "totp" || u.Query().Get("secret") != secret || u.Query().Get("issuer") != "Pet Medical" {
+		t.Errorf("uri = %v, %v", u, err)
+	}
+	if u.Path != "/Pet Medical:a@b.c" {
+		t.Errorf("label = %q", u.Path)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil || len(codes) != 10 {
+		t.Fatalf("codes = %v, %v", codes, err)
+	}
+	format := regexp.MustCompile(`^[a-z2-9]{4}-[a-z2-9]{4}-[a-z2-9]{4}$`)
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if !format.MatchString(c) || seen[c] {
+			t.Errorf("bad or repeated code %q", c)
+		}
+		seen[c] = true
+	}
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if HashRecoveryCode(typed) != HashRecoveryCode(codes[0]) {
+		t.Error("hash should ignore case, spaces, and dashes")
+	}
+}
+
+func TestMFAToken_NotAnAccessToken(t *testing.T) {
+	j := NewJWT("secret", 15, 7)
+	userID := uuid.New()
+	mfa, err := j.NewMFAToken(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, tokenID, err := j.ParseMFAToken(mfa)
+	if err != nil || got != userID || tokenID == uuid.Nil {
+		t.Errorf("ParseMFAToken = %v, %v, %v", got, tokenID, err)
+	}
+	if other, _ := j.NewMFAToken(userID); other == mfa {
+		t.Error("mfa tokens must have distinct IDs")
+	}
+	if _, err := j.ParseAccessToken(mfa); err == nil {
+		t.Error("mfa token accepted as access token")
+	}
+	access, _ := j.NewAccessToken(userID, "n", "e", "user")
+	if _, _, err := j.ParseMFAToken(access); err == nil {
+		t.Error("access token accepted as mfa token")
+	}
+	if _, _, err := NewJWT("other", 15, 7).ParseMFAToken(mfa); err == nil {
+		t.Error("mfa token accepted under another secret")
+	}
+}
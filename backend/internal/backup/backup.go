@@ -0,0 +1,132 @@
+// Package backup defines the account archive format shared by export and import: a ZIP with one JSON array per
+// entity under data/, uploaded files under files/ at their UploadDir-relative paths, and manifest.json listing every
+// entry with its size and SHA-256 checksum.
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is bumped whenever an entity file changes shape incompatibly.
+const SchemaVersion = 1
+
+const (
+	App          = "pet-medical"
+	ManifestName = "manifest.json"
+	DataDir      = "data/"
+	FilesDir     = "files/"
+)
+
+// Entity files, in the order they are written (parents before children).
+const (
+	FileSettings        = DataDir + "settings.json"
+	FileCustomOptions   = DataDir + "custom_options.json"
+	FileClinics         = DataDir + "clinics.json"
+	FilePets            = DataDir + "pets.json"
+	FileVisits          = DataDir + "visits.json"
+	FileVaccinations    = DataDir + "vaccinations.json"
+	FileWeights         = DataDir + "weights.json"
+	FileDocuments       = DataDir + "documents.json"
+	FilePhotos          = DataDir + "photos.json"
+	FileMedications     = DataDir + "medications.json"
+	FileMedicationDoses = DataDir + "medication_doses.json"
+	FileAllergies       = DataDir + "allergies.json"
+	FileConditions      = DataDir + "conditions.json"
+	FileLabPanels       = DataDir + "lab_panels.json"
+	FileLabAnalytes     = DataDir + "lab_analytes.json"
+)
+
+// Manifest describes an archive. It is written last, once every checksum is known.
+type Manifest struct {
+	App           string    `json:"app"`
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	UserID        uuid.UUID `json:"user_id"`
+	Entries       []Entry   `json:"entries"`
+	MissingFiles  []string  `json:"missing_files,omitempty"` // referenced by a record but absent from UploadDir
+}
+
+// Entry is one file in the archive. Records is set for entity files.
+type Entry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	Records *int   `json:"records,omitempty"`
+}
+
+// Writer streams an archive. Entries are written as they are added; Close appends the manifest.
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+}
+
+// NewWriter starts an archive for userID on w.
+func NewWriter(w io.Writer, userID uuid.UUID) *Writer {
+	return &Writer{
+		zw:       zip.NewWriter(w),
+		manifest: Manifest{App: App, SchemaVersion: SchemaVersion, ExportedAt: time.Now().UTC(), UserID: userID, Entries: []Entry{}},
+	}
+}
+
+// WriteJSON adds an entity file. v should be a slice (or the settings object); records is its length.
+func (w *Writer) WriteJSON(path string, v any, records int) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry, err := w.write(path, func(dst io.Writer) (int64, error) {
+		n, err := dst.Write(data)
+		return int64(n), err
+	})
+	if err != nil {
+		return err
+	}
+	entry.Records = &records
+	return nil
+}
+
+// WriteFile adds an uploaded file under FilesDir.
+func (w *Writer) WriteFile(relPath string, r io.Reader) error {
+	_, err := w.write(FilesDir+relPath, func(dst io.Writer) (int64, error) { return io.Copy(dst, r) })
+	return err
+}
+
+// Missing records a referenced upload that could not be read.
+func (w *Writer) Missing(relPath string) {
+	w.manifest.MissingFiles = append(w.manifest.MissingFiles, relPath)
+}
+
+func (w *Writer) write(path string, copyTo func(io.Writer) (int64, error)) (*Entry, error) {
+	dst, err := w.zw.CreateHeader(&zip.FileHeader{Name: path, Method: zip.Deflate, Modified: w.manifest.ExportedAt})
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	n, err := copyTo(io.MultiWriter(dst, h))
+	if err != nil {
+		return nil, err
+	}
+	w.manifest.Entries = append(w.manifest.Entries, Entry{Path: path, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))})
+	return &w.manifest.Entries[len(w.manifest.Entries)-1], nil
+}
+
+// Close writes the manifest and finishes the ZIP.
+func (w *Writer) Close() error {
+	dst, err := w.zw.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: w.manifest.ExportedAt})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(dst)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.manifest); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWriter_ManifestChecksums(t *testing.T) {
+	var buf bytes.Buffer
+	userID := uuid.New()
+	w := NewWriter(&buf, userID)
+	if err := w.WriteJSON(FilePets, []map[string]string{{"name": "Rex"}, {"name": "Tom"}}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFile("photos/p1/a.jpg", strings.NewReader("jpeg bytes")); err != nil {
+		t.Fatal(err)
+	}
+	w.Missing("documents/p1/gone.pdf")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		contents[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	var m Manifest
+	if err := json.Unmarshal(contents[ManifestName], &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if m.App != App || m.SchemaVersion != SchemaVersion || m.UserID != userID {
+		t.Errorf("manifest header = %+v", m)
+	}
+	if len(m.Entries) != 2 || len(m.MissingFiles) != 1 {
+		t.Fatalf("entries = %+v, missing = %v", m.Entries, m.MissingFiles)
+	}
+	if m.Entries[0].Records == nil || *m.Entries[0].Records != 2 || m.Entries[1].Records != nil {
+		t.Error("records should be set for entity files only")
+	}
+	for _, e := range m.Entries {
+		sum := sha256.Sum256(contents[e.Path])
+		if e.SHA256 != hex.EncodeToString(sum[:]) || e.Size != int64(len(contents[e.Path])) {
+			t.Errorf("%s: checksum or size mismatch", e.Path)
+		}
+	}
+	if m.Entries[1].Path != FilesDir+"photos/p1/a.jpg" {
+		t.Errorf("file path = %s", m.Entries[1].Path)
+	}
+}
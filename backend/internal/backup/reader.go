@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrInvalidArchive wraps every reason an archive is rejected by Open.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Default limits for Open; see Limits.
+const (
+	DefaultMaxFileBytes  = 25 << 20
+	DefaultMaxDataBytes  = 64 << 20
+	DefaultMaxTotalBytes = 2 << 30
+)
+
+// Limits bounds how much Open decompresses, so a small archive cannot expand into gigabytes (a ZIP bomb). The sizes
+// are checked against both the manifest and the ZIP headers before anything is read. Zero fields use the defaults.
+type Limits struct {
+	MaxFileBytes  int64 // per entry under files/
+	MaxDataBytes  int64 // per entry under data/
+	MaxTotalBytes int64 // all listed entries together
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxFileBytes <= 0 {
+		l.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if l.MaxDataBytes <= 0 {
+		l.MaxDataBytes = DefaultMaxDataBytes
+	}
+	if l.MaxTotalBytes <= 0 {
+		l.MaxTotalBytes = DefaultMaxTotalBytes
+	}
+	return l
+}
+
+// Archive is an opened, verified archive.
+type Archive struct {
+	Manifest Manifest
+	files    map[string]*zip.File
+	sizes    map[string]int64 // verified sizes of listed entries
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidArchive, fmt.Sprintf(format, args...))
+}
+
+// Open reads the manifest and verifies it against the ZIP: the app and schema version must be supported, every
+// listed entry must exist with the recorded size and checksum, and the ZIP may contain nothing that is not listed.
+// Entry paths must stay under data/ or files/, and entry sizes within limits.
+func Open(r io.ReaderAt, size int64, limits Limits) (*Archive, error) {
+	limits = limits.withDefaults()
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, invalid("not a ZIP file")
+	}
+	a := &Archive{files: make(map[string]*zip.File, len(zr.File)), sizes: make(map[string]int64)}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		if _, dup := a.files[f.Name]; dup {
+			return nil, invalid("duplicate entry %s", f.Name)
+		}
+		a.files[f.Name] = f
+	}
+	mf, ok := a.files[ManifestName]
+	if !ok {
+		return nil, invalid("%s missing", ManifestName)
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		return nil, invalid("%s unreadable", ManifestName)
+	}
+	err = json.NewDecoder(io.LimitReader(rc, 8<<20)).Decode(&a.Manifest)
+	rc.Close()
+	if err != nil {
+		return nil, invalid("%s is not valid JSON", ManifestName)
+	}
+	if a.Manifest.App != App {
+		return nil, invalid("not a %s archive", App)
+	}
+	if a.Manifest.SchemaVersion < 1 || a.Manifest.SchemaVersion > SchemaVersion {
+		return nil, invalid("unsupported schema version %d", a.Manifest.SchemaVersion)
+	}
+
+	listed := make(map[string]bool, len(a.Manifest.Entries))
+	var total int64
+	for _, e := range a.Manifest.Entries {
+		if !validEntryPath(e.Path) {
+			return nil, invalid("bad entry path %q", e.Path)
+		}
+		if listed[e.Path] {
+			return nil, invalid("%s listed twice", e.Path)
+		}
+		listed[e.Path] = true
+		f, ok := a.files[e.Path]
+		if !ok {
+			return nil, invalid("%s listed in manifest but missing", e.Path)
+		}
+		limit := limits.MaxFileBytes
+		if strings.HasPrefix(e.Path, DataDir) {
+			limit = limits.MaxDataBytes
+		}
+		if e.Size < 0 || e.Size > limit || f.UncompressedSize64 > uint64(limit) {
+			return nil, invalid("%s is larger than %d bytes", e.Path, limit)
+		}
+		if total += e.Size; total > limits.MaxTotalBytes {
+			return nil, invalid("archive expands to more than %d bytes", limits.MaxTotalBytes)
+		}
+		if err := verify(f, e); err != nil {
+			return nil, err
+		}
+		a.sizes[e.Path] = e.Size
+	}
+	for name := range a.files {
+		if name != ManifestName && !listed[name] {
+			return nil, invalid("%s not listed in manifest", name)
+		}
+	}
+	return a, nil
+}
+
+func validEntryPath(p string) bool {
+	if !strings.HasPrefix(p, DataDir) && !strings.HasPrefix(p, FilesDir) {
+		return false
+	}
+	return path.Clean(p) == p && !strings.Contains(p, "..") && !strings.Contains(p, "\\")
+}
+
+// verify reads the entry, stopping one byte past the recorded size so a mislabelled entry cannot inflate unbounded.
+func verify(f *zip.File, e Entry) error {
+	rc, err := f.Open()
+	if err != nil {
+		return invalid("%s unreadable", e.Path)
+	}
+	defer rc.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(rc, e.Size+1))
+	if err != nil {
+		return invalid("%s unreadable", e.Path)
+	}
+	if n != e.Size || hex.EncodeToString(h.Sum(nil)) != strings.ToLower(e.SHA256) {
+		return invalid("%s does not match its checksum", e.Path)
+	}
+	return nil
+}
+
+// ReadJSON decodes an entity file into v. It returns false, leaving v untouched, when the archive has no such file.
+func (a *Archive) ReadJSON(path string, v any) (bool, error) {
+	f, ok := a.files[path]
+	if !ok {
+		return false, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return true, err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(io.LimitReader(rc, a.sizes[path])).Decode(v); err != nil {
+		return true, invalid("%s: %v", path, err)
+	}
+	return true, nil
+}
+
+// File returns the uploaded file stored for relPath (an UploadDir-relative path from a record) and its size.
+func (a *Archive) File(relPath string) (io.ReadCloser, int64, error) {
+	f, ok := a.files[FilesDir+relPath]
+	if !ok {
+		return nil, 0, fmt.Errorf("file %s not in archive", relPath)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := a.sizes[FilesDir+relPath]
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, size), rc}, size, nil
+}
@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func exportFixture(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, uuid.New())
+	if err := w.WriteJSON(FilePets, []map[string]string{{"name": "Rex"}}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFile("photos/p1/a.jpg", strings.NewReader("jpeg bytes")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// rewrite copies an archive, letting edit change or drop (return nil) each entry and add extra ones.
+func rewrite(t *testing.T, data []byte, edit func(name string, body []byte) []byte, extra map[string]string) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		if body = edit(f.Name, body); body == nil {
+			continue
+		}
+		dst, _ := zw.Create(f.Name)
+		dst.Write(body)
+	}
+	for name, body := range extra {
+		dst, _ := zw.Create(name)
+		dst.Write([]byte(body))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestOpen_RoundTrip(t *testing.T) {
+	data := exportFixture(t)
+	a, err := Open(bytes.NewReader(data), int64(len(data)), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pets []map[string]string
+	if ok, err := a.ReadJSON(FilePets, &pets); !ok || err != nil || len(pets) != 1 || pets[0]["name"] != "Rex" {
+		t.Errorf("ReadJSON = %v, %v, %v", ok, err, pets)
+	}
+	if ok, err := a.ReadJSON(FileVisits, &pets); ok || err != nil {
+		t.Errorf("absent file: ok=%v err=%v", ok, err)
+	}
+	rc, size, err := a.File("photos/p1/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "jpeg bytes" || size != int64(len(body)) {
+		t.Errorf("File = %q (%d)", body, size)
+	}
+}
+
+func TestOpen_Rejects(t *testing.T) {
+	data := exportFixture(t)
+	keep := func(_ string, body []byte) []byte { return body }
+	editManifest := func(change func(*Manifest)) func(string, []byte) []byte {
+		return func(name string, body []byte) []byte {
+			if name != ManifestName {
+				return body
+			}
+			var m Manifest
+			json.Unmarshal(body, &m)
+			change(&m)
+			out, _ := json.Marshal(m)
+			return out
+		}
+	}
+	cases := map[string][]byte{
+		"not a zip": []byte("hello"),
+		"no manifest": rewrite(t, data, func(name string, body []byte) []byte {
+			if name == ManifestName {
+				return nil
+			}
+			return body
+		}, nil),
+		"tampered": rewrite(t, data, func(name string, body []byte) []byte {
+			if name == FilePets {
+				return bytes.Replace(body, []byte("Rex"), []byte("Max"), 1)
+			}
+			return body
+		}, nil),
+		"missing entry": rewrite(t, data, func(name string, body []byte) []byte {
+			if name == FilesDir+"photos/p1/a.jpg" {
+				return nil
+			}
+			return body
+		}, nil),
+		"unlisted entry": rewrite(t, data, keep, map[string]string{FilesDir + "photos/p1/extra.jpg": "x"}),
+		"other app":      rewrite(t, data, editManifest(func(m *Manifest) { m.App = "other" }), nil),
+		"newer schema":   rewrite(t, data, editManifest(func(m *Manifest) { m.SchemaVersion = SchemaVersion + 1 }), nil),
+		"path escape": rewrite(t, data, editManifest(func(m *Manifest) {
+			m.Entries = append(m.Entries, Entry{Path: FilesDir + "../../etc/passwd"})
+		}), nil),
+	}
+	for name, archive := range cases {
+		if _, err := Open(bytes.NewReader(archive), int64(len(archive)), Limits{}); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("%s: err = %v, want ErrInvalidArchive", name, err)
+		}
+	}
+}
+
+func TestOpen_Limits(t *testing.T) {
+	data := exportFixture(t)
+	for name, limits := range map[string]Limits{
+		"file too large":  {MaxFileBytes: 5},
+		"data too large":  {MaxDataBytes: 5},
+		"total too large": {MaxTotalBytes: 12},
+	} {
+		if _, err := Open(bytes.NewReader(data), int64(len(data)), limits); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("%s: err = %v, want ErrInvalidArchive", name, err)
+		}
+	}
+	// A manifest that claims a huge entry is rejected before the entry is read.
+	bomb := rewrite(t, data, func(name string, body []byte) []byte {
+		if name != ManifestName {
+			return body
+		}
+		var m Manifest
+		json.Unmarshal(body, &m)
+		for i := range m.Entries {
+			m.Entries[i].Size = 1 << 40
+		}
+		out, _ := json.Marshal(m)
+		return out
+	}, nil)
+	if _, err := Open(bytes.NewReader(bomb), int64(len(bomb)), Limits{}); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("declared size: err = %v", err)
+	}
+}
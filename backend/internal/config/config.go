@@ -43,6 +43,16 @@ type Config struct {
 	GoogleClientID     string // OAuth2 client ID
 	GoogleClientSecret string // OAuth2 client secret
 	GoogleRedirectURI  string // OAuth2 redirect URI (e.g. https://app.example.com/oauth2/callback)
+	// OpenID Connect login with any provider that supports discovery (Google, Keycloak, Authentik, ...). Enabled when
+	// OIDCIssuerURL and OIDCClientID are set. OIDCRedirectURI defaults to PUBLIC_URL (or the request origin) +
+	// /api/auth/oidc/callback. New accounts are only created when OIDCAutoProvision is true.
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string // empty for a public client (PKCE only)
+	OIDCRedirectURI   string
+	OIDCScopes        []string // default openid, email, profile
+	OIDCProviderName  string   // label for the login button; default "SSO"
+	OIDCAutoProvision bool
 	// Trusted proxies: comma-separated IPs or CIDRs (e.g. "10.0.0.1,172.16.0.0/12"). When request comes from one of these, X-Forwarded-* and forwarded auth headers are trusted.
 	// When TrustPrivateProxies is true (default), requests from loopback and private IP ranges are also trusted, so TRUSTED_PROXIES can be left unset when behind a proxy on the same host or in a private network.
 	TrustedProxies       []*net.IPNet
@@ -61,6 +71,21 @@ type Config struct {
 	// Max upload sizes in bytes. 0 = use default (10MB photos, 25MB documents).
 	MaxUploadPhotoBytes    int64
 	MaxUploadDocumentBytes int64
+	// PublicURL is the externally reachable base URL (e.g. https://pets.example.com), used for links in emails. Optional.
+	PublicURL string
+	// SMTP for reminder emails. Reminders are disabled when SMTPHost is empty.
+	SMTPHost     string
+	SMTPPort     int    // default 587
+	SMTPUsername string // optional; no AUTH when empty
+	SMTPPassword string
+	SMTPFrom     string // envelope and From address
+	SMTPTLS      string // "starttls" (default), "tls" (implicit, usually port 465), or "none" (e.g. a local capture server)
+	// ReminderIntervalMin is how often the reminder scheduler checks for due vaccinations (minutes). Default 60.
+	ReminderIntervalMin int
+	// WebhookAllowPrivateTargets lets webhooks be delivered to loopback and private addresses (e.g. a home-automation
+	// server on the LAN). Off by default so accounts cannot reach internal services; link-local and cloud metadata
+	// addresses are refused either way.
+	WebhookAllowPrivateTargets bool
 }
 
 func Load() *Config {
@@ -113,6 +138,10 @@ func Load() *Config {
 	googleClientID := strings.TrimSpace(os.Getenv("GOOGLE_CLIENT_ID"))
 	googleClientSecret := strings.TrimSpace(os.Getenv("GOOGLE_CLIENT_SECRET"))
 	googleRedirectURI := strings.TrimSpace(os.Getenv("GOOGLE_REDIRECT_URI"))
+	oidcName := strings.TrimSpace(os.Getenv("OIDC_PROVIDER_NAME"))
+	if oidcName == "" {
+		oidcName = "SSO"
+	}
 	forwardedEmail := strings.TrimSpace(os.Getenv("FORWARDED_EMAIL_HEADER"))
 	if forwardedEmail == "" {
 		forwardedEmail = "X-Forwarded-Email"
@@ -138,6 +167,14 @@ func Load() *Config {
 	if maxDocBytes <= 0 {
 		maxDocBytes = 25 * 1024 * 1024
 	}
+	smtpTLS := strings.TrimSpace(strings.ToLower(os.Getenv("SMTP_TLS")))
+	if smtpTLS != "tls" && smtpTLS != "none" {
+		smtpTLS = "starttls"
+	}
+	reminderInterval := parseIntEnv("REMINDER_INTERVAL_MIN", 60)
+	if reminderInterval <= 0 {
+		reminderInterval = 60
+	}
 	return &Config{
 		ServerPort:           port,
 		DBURL:                dbURL,
@@ -153,6 +190,13 @@ func Load() *Config {
 		GoogleClientID:       googleClientID,
 		GoogleClientSecret:   googleClientSecret,
 		GoogleRedirectURI:    googleRedirectURI,
+		OIDCIssuerURL:        strings.TrimRight(strings.TrimSpace(os.Getenv("OIDC_ISSUER_URL")), "/"),
+		OIDCClientID:         strings.TrimSpace(os.Getenv("OIDC_CLIENT_ID")),
+		OIDCClientSecret:     strings.TrimSpace(os.Getenv("OIDC_CLIENT_SECRET")),
+		OIDCRedirectURI:      strings.TrimSpace(os.Getenv("OIDC_REDIRECT_URI")),
+		OIDCScopes:           strings.Fields(strings.ReplaceAll(os.Getenv("OIDC_SCOPES"), ",", " ")),
+		OIDCProviderName:     oidcName,
+		OIDCAutoProvision:    parseBoolEnv("OIDC_AUTO_PROVISION", false),
 		TrustedProxies:       trustedProxies,
 		TrustPrivateProxies:  trustPrivate,
 		ForwardedEmailHeader: forwardedEmail,
@@ -164,6 +208,15 @@ func Load() *Config {
 		RateLimitAPIPerMin:          rateLimitAPI,
 		MaxUploadPhotoBytes:         maxPhotoBytes,
 		MaxUploadDocumentBytes:      maxDocBytes,
+		PublicURL:                   strings.TrimRight(strings.TrimSpace(os.Getenv("PUBLIC_URL")), "/"),
+		SMTPHost:                    strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		SMTPPort:                    parseIntEnv("SMTP_PORT", 587),
+		SMTPUsername:                strings.TrimSpace(os.Getenv("SMTP_USERNAME")),
+		SMTPPassword:                os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:                    strings.TrimSpace(os.Getenv("SMTP_FROM")),
+		SMTPTLS:                     smtpTLS,
+		ReminderIntervalMin:         reminderInterval,
+		WebhookAllowPrivateTargets:  parseBoolEnv("WEBHOOK_ALLOW_PRIVATE_TARGETS", false),
 	}
 }
 
@@ -270,6 +323,11 @@ func (c *Config) IsRequestHTTPS(r *http.Request) bool {
 	return false
 }
 
+// OIDCEnabled reports whether OpenID Connect login is configured.
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuerURL != "" && c.OIDCClientID != ""
+}
+
 // RequestOrigin returns the effective origin of this request (scheme://host), using X-Forwarded-Proto and X-Forwarded-Host when from a trusted proxy.
 // Used for CORS when CORS_ORIGINS is unset (allow same-origin only).
 func (c *Config) RequestOrigin(r *http.Request) string {
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// MigrateVeterinariansToClinics groups each user's distinct vaccinations.veterinarian strings into clinic
+// directory entries and links the vaccinations to them. Spellings that differ only in case, spacing, or
+// punctuation ("Dr. Smith", "dr smith") become one entry named after the most common spelling.
+// Only unlinked vaccinations are considered and existing entries with the same normalized name are reused.
+func MigrateVeterinariansToClinics(db *gorm.DB) error {
+	type row struct {
+		UserID       uuid.UUID
+		Veterinarian string
+		Count        int
+	}
+	var rows []row
+	err := db.Table("vaccinations").
+		Select("pets.user_id AS user_id, vaccinations.veterinarian AS veterinarian, COUNT(*) AS count").
+		Joins("JOIN pets ON pets.id = vaccinations.pet_id").
+		Where("vaccinations.clinic_id IS NULL AND vaccinations.veterinarian IS NOT NULL AND TRIM(vaccinations.veterinarian) <> ''").
+		Group("pets.user_id, vaccinations.veterinarian").
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("list veterinarians: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	type group struct {
+		userID    uuid.UUID
+		spellings map[string]int
+	}
+	groups := make(map[string]*group) // key: user id + normalized name
+	var keys []string
+	for _, r := range rows {
+		norm := normalizeClinicName(r.Veterinarian)
+		if norm == "" {
+			continue
+		}
+		key := r.UserID.String() + "|" + norm
+		g, ok := groups[key]
+		if !ok {
+			g = &group{userID: r.UserID, spellings: make(map[string]int)}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.spellings[r.Veterinarian] += r.Count
+	}
+	sort.Strings(keys)
+
+	created := 0
+	for _, key := range keys {
+		g := groups[key]
+		norm := key[strings.Index(key, "|")+1:]
+		clinicID, isNew, err := findOrCreateClinic(db, g.userID, norm, preferredSpelling(g.spellings))
+		if err != nil {
+			return err
+		}
+		if isNew {
+			created++
+		}
+		spellings := make([]string, 0, len(g.spellings))
+		for s := range g.spellings {
+			spellings = append(spellings, s)
+		}
+		err = db.Model(&models.Vaccination{}).
+			Where("clinic_id IS NULL AND veterinarian IN ? AND pet_id IN (?)", spellings, db.Model(&models.Pet{}).Select("id").Where("user_id = ?", g.userID)).
+			Update("clinic_id", clinicID).Error
+		if err != nil {
+			return fmt.Errorf("link vaccinations to clinic: %w", err)
+		}
+	}
+	log.Printf("migrated vaccination veterinarians into clinic directory (%d entries created)", created)
+	return nil
+}
+
+func findOrCreateClinic(db *gorm.DB, userID uuid.UUID, norm, name string) (uuid.UUID, bool, error) {
+	var existing []models.Clinic
+	if err := db.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return uuid.Nil, false, fmt.Errorf("list clinics: %w", err)
+	}
+	for _, c := range existing {
+		if normalizeClinicName(c.Name) == norm {
+			return c.ID, false, nil
+		}
+	}
+	c := models.Clinic{UserID: userID, Name: name}
+	if err := db.Create(&c).Error; err != nil {
+		return uuid.Nil, false, fmt.Errorf("create clinic: %w", err)
+	}
+	return c.ID, true, nil
+}
+
+// normalizeClinicName lowercases s and turns runs of punctuation and whitespace into single spaces so spelling variants compare equal.
+func normalizeClinicName(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// preferredSpelling returns the most frequent spelling, breaking ties alphabetically, trimmed.
+func preferredSpelling(spellings map[string]int) string {
+	best, bestCount := "", -1
+	for s, n := range spellings {
+		s = strings.TrimSpace(s)
+		if n > bestCount || (n == bestCount && s < best) {
+			best, bestCount = s, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,30 @@
+package db
+
+import "testing"
+
+func TestNormalizeClinicName(t *testing.T) {
+	same := []string{"Dr. Smith", "dr smith", "  DR  SMITH ", "Dr.Smith", "dr-smith"}
+	want := "dr smith"
+	for _, s := range same {
+		if got := normalizeClinicName(s); got != want {
+			t.Errorf("normalizeClinicName(%q) = %q, want %q", s, got, want)
+		}
+	}
+	if normalizeClinicName("Oak Vet Clinic") == normalizeClinicName("Elm Vet Clinic") {
+		t.Error("different clinics should not normalize to the same name")
+	}
+	if normalizeClinicName(" .. ") != "" {
+		t.Error("punctuation-only name should normalize to empty")
+	}
+}
+
+func TestPreferredSpelling(t *testing.T) {
+	got := preferredSpelling(map[string]int{"dr smith": 1, "Dr. Smith": 3, "DR SMITH": 2})
+	if got != "Dr. Smith" {
+		t.Errorf("expected most common spelling, got %q", got)
+	}
+	got = preferredSpelling(map[string]int{"b": 1, " a ": 1})
+	if got != "a" {
+		t.Errorf("expected alphabetical tie-break with trimming, got %q", got)
+	}
+}
@@ -75,20 +75,86 @@ func MigrateUsernameToDisplayName(db *gorm.DB) error {
 }
 
 // AutoMigrateAll runs GORM AutoMigrate for all models in FK-safe order.
-// Creates tables and adds missing columns; does not drop columns or run data migrations.
+// Creates tables and adds missing columns; does not drop columns. One-time data migrations then run through runOnce.
 func AutoMigrateAll(db *gorm.DB) error {
 	if err := MigrateUsernameToDisplayName(db); err != nil {
 		return err
 	}
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
+		&models.SchemaMigration{},
 		&models.User{},
 		&models.RefreshToken{},
+		&models.RecoveryCode{},
+		&models.PasswordResetToken{},
+		&models.MFAAttempt{},
+		&models.Passkey{},
+		&models.PasskeyChallenge{},
 		&models.Pet{},
 		&models.Vaccination{},
 		&models.WeightEntry{},
 		&models.Document{},
 		&models.PetPhoto{},
+		&models.Medication{},
+		&models.MedicationDose{},
+		&models.VetVisit{},
+		&models.Clinic{},
+		&models.Allergy{},
+		&models.ChronicCondition{},
+		&models.LabPanel{},
+		&models.LabAnalyte{},
+		&models.SentNotification{},
+		&models.CalendarFeed{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.PetMembership{},
+		&models.PetInvitation{},
+		&models.Household{},
+		&models.HouseholdMember{},
+		&models.ShareLink{},
+		&models.ShareLinkAccess{},
 		&models.UserCustomOption{},
 		&models.DefaultDropdownOption{},
 	)
+	if err != nil {
+		return err
+	}
+	// Group free-text veterinarians into the clinic directory once.
+	if err := runOnce(db, "vaccination_veterinarians_to_clinics", MigrateVeterinariansToClinics); err != nil {
+		return err
+	}
+	return runOnce(db, "sent_notifications_key_per_user", rebuildSentNotificationsKey)
+}
+
+// rebuildSentNotificationsKey recreates idx_sent_notifications_key with user_id added. AutoMigrate creates missing
+// indexes but does not change one that already exists under the same name.
+func rebuildSentNotificationsKey(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if m.HasIndex(&models.SentNotification{}, "idx_sent_notifications_key") {
+		if err := m.DropIndex(&models.SentNotification{}, "idx_sent_notifications_key"); err != nil {
+			return err
+		}
+	}
+	return m.CreateIndex(&models.SentNotification{}, "idx_sent_notifications_key")
+}
+
+// runOnce runs a one-time data migration in a transaction and records it in schema_migrations in the same
+// transaction. A migration that fails or is interrupted leaves no partial changes and runs again on the next start;
+// one that completed never runs again. An advisory lock keeps two instances starting together from both running it.
+func runOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "schema_migrations:"+name).Error; err != nil {
+			return fmt.Errorf("lock migration %s: %w", name, err)
+		}
+		var done int64
+		if err := tx.Model(&models.SchemaMigration{}).Where("name = ?", name).Count(&done).Error; err != nil {
+			return fmt.Errorf("check migration %s: %w", name, err)
+		}
+		if done > 0 {
+			return nil
+		}
+		if err := migrate(tx); err != nil {
+			return err
+		}
+		return tx.Create(&models.SchemaMigration{Name: name, AppliedAt: time.Now()}).Error
+	})
 }
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// petRole returns the user's role on the pet: owner for the pet's creator, otherwise the higher of the role from
+// pet_memberships and the role from the pet's household, or "" when the user has no access (or the pet does not exist).
+func petRole(db *gorm.DB, petID, userID uuid.UUID) string {
+	var pet models.Pet
+	if err := db.Select("id, user_id, household_id").Where("id = ?", petID).First(&pet).Error; err != nil {
+		return ""
+	}
+	if pet.UserID == userID {
+		return models.PetRoleOwner
+	}
+	role := ""
+	var m models.PetMembership
+	if err := db.Where("pet_id = ? AND user_id = ?", petID, userID).First(&m).Error; err == nil {
+		role = m.Role
+	}
+	if pet.HouseholdID != nil {
+		if hr := householdRole(db, *pet.HouseholdID, userID); models.PetRoleRank(models.HouseholdPetRole(hr)) > models.PetRoleRank(role) {
+			role = models.HouseholdPetRole(hr)
+		}
+	}
+	return role
+}
+
+// householdRole returns the user's role in the household, or "" when they are not a member.
+func householdRole(db *gorm.DB, householdID, userID uuid.UUID) string {
+	var m models.HouseholdMember
+	if err := db.Where("household_id = ? AND user_id = ?", householdID, userID).First(&m).Error; err != nil {
+		return ""
+	}
+	return m.Role
+}
+
+// requirePetRole is the shared authorization check for pet-scoped routes. It returns true when the caller holds at
+// least need on the pet; otherwise it writes 404 (no access, so pet ids are not disclosed) or 403 (role too low).
+func requirePetRole(w http.ResponseWriter, r *http.Request, db *gorm.DB, petID uuid.UUID, need string) bool {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return false
+	}
+	role := petRole(db, petID, u.ID)
+	if role == "" {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return false
+	}
+	if models.PetRoleRank(role) < models.PetRoleRank(need) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// petCreatorID returns pets.user_id. Clinic ids on a shared pet's records are validated against the creator's clinic
+// directory, since that is where the pet's existing clinic references live.
+func petCreatorID(db *gorm.DB, petID uuid.UUID) uuid.UUID {
+	var pet models.Pet
+	db.Select("user_id").Where("id = ?", petID).First(&pet)
+	return pet.UserID
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestPetRoleRank(t *testing.T) {
+	if !(models.PetRoleRank(models.PetRoleViewer) < models.PetRoleRank(models.PetRoleEditor) &&
+		models.PetRoleRank(models.PetRoleEditor) < models.PetRoleRank(models.PetRoleOwner)) {
+		t.Error("expected viewer < editor < owner")
+	}
+	if models.PetRoleRank("admin") != 0 || validPetRole("") {
+		t.Error("unknown roles must rank 0 and be invalid")
+	}
+	for _, role := range []string{models.PetRoleViewer, models.PetRoleEditor, models.PetRoleOwner} {
+		if !validPetRole(role) {
+			t.Errorf("%s should be valid", role)
+		}
+	}
+}
+
+func TestRequirePetRole_Unauthenticated(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	if requirePetRole(rec, req, nil, uuid.New(), models.PetRoleViewer) {
+		t.Fatal("expected false without a user")
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestWeights_Create_StoreDeniesAccess(t *testing.T) {
+	h := &WeightsHandler{WeightCreateStore: &mockWeightCreateStore{petOwner: false}}
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	if h.createOwnsPet(rec, req, uuid.New()) {
+		t.Fatal("expected false without a user")
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHouseholdPetRole(t *testing.T) {
+	tests := map[string]string{
+		models.HouseholdRoleAdmin:  models.PetRoleOwner,
+		models.HouseholdRoleMember: models.PetRoleEditor,
+		"":                         "",
+		"guest":                    "",
+	}
+	for in, want := range tests {
+		if got := models.HouseholdPetRole(in); got != want {
+			t.Errorf("HouseholdPetRole(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if !validHouseholdRole(models.HouseholdRoleAdmin) || validHouseholdRole(models.PetRoleOwner) {
+		t.Error("validHouseholdRole accepts only admin and member")
+	}
+}
+
+func TestPreviousCreatorMembership(t *testing.T) {
+	petID, creator, other := uuid.New(), uuid.New(), uuid.New()
+	if m := previousCreatorMembership(petID, creator, creator); m != nil {
+		t.Errorf("creator taking their own pet back: %+v", m)
+	}
+	m := previousCreatorMembership(petID, other, creator)
+	if m == nil || m.PetID != petID || m.UserID != creator || m.Role != models.PetRoleOwner {
+		t.Errorf("non-creator transfer must keep the creator as owner, got %+v", m)
+	}
+}
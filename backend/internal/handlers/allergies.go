@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+type AllergiesHandler struct {
+	DB *gorm.DB
+}
+
+// List returns the pet's allergies. Optional ?status=active|resolved.
+func (h *AllergiesHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	q := h.DB.Where("pet_id = ?", petID)
+	if status := strings.ToLower(r.URL.Query().Get("status")); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var list []models.Allergy
+	if err := q.Order("allergen").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.Allergy{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *AllergiesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var a models.Allergy
+	if err := h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&a).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *AllergiesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var a models.Allergy
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	a.PetID = petID
+	a.ID = uuid.Nil
+	if err := validateAllergyInput(&a); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.Create(&a).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *AllergiesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var a models.Allergy
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	a.ID = id
+	a.PetID = petID
+	if err := validateAllergyInput(&a); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.Allergy{}).Where("id = ? AND pet_id = ?", id, petID).Updates(map[string]interface{}{
+		"allergen": a.Allergen, "reaction": a.Reaction, "severity": a.Severity, "status": a.Status,
+		"identified_date": a.IdentifiedDate, "notes": a.Notes,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("id = ?", id).First(&a)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *AllergiesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.Allergy{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	maxHealthFieldLen = 200 // allergen, diagnosis, managing vet
+	maxHealthTextLen  = 5000
+)
+
+// validHealthStatus normalizes status (default active) and reports whether it is active or resolved.
+func validHealthStatus(status *string) bool {
+	*status = strings.TrimSpace(strings.ToLower(*status))
+	if *status == "" {
+		*status = models.HealthStatusActive
+	}
+	return *status == models.HealthStatusActive || *status == models.HealthStatusResolved
+}
+
+func validateAllergyInput(a *models.Allergy) error {
+	a.Allergen = strings.TrimSpace(a.Allergen)
+	if a.Allergen == "" {
+		return errors.New("allergen required")
+	}
+	if len(a.Allergen) > maxHealthFieldLen {
+		return errors.New("allergen too long")
+	}
+	a.Severity = strings.TrimSpace(strings.ToLower(a.Severity))
+	if a.Severity == "" {
+		a.Severity = models.AllergySeverityMild
+	}
+	switch a.Severity {
+	case models.AllergySeverityMild, models.AllergySeverityModerate, models.AllergySeveritySevere, models.AllergySeverityLifeThreatening:
+	default:
+		return errors.New("invalid severity")
+	}
+	if !validHealthStatus(&a.Status) {
+		return errors.New("invalid status")
+	}
+	if a.IdentifiedDate != nil && *a.IdentifiedDate != "" {
+		if _, err := time.Parse(dateLayout, *a.IdentifiedDate); err != nil {
+			return errors.New("invalid identified_date")
+		}
+	}
+	for _, s := range []*string{a.Reaction, a.Notes} {
+		if s != nil && len(*s) > maxHealthTextLen {
+			return errors.New("field too long")
+		}
+	}
+	return nil
+}
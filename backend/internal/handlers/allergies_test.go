@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestValidateAllergyInput(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name         string
+		in           models.Allergy
+		wantErr      string // "" means valid
+		wantSeverity string
+		wantStatus   string
+	}{
+		{"defaults", models.Allergy{Allergen: " Chicken "}, "", models.AllergySeverityMild, models.HealthStatusActive},
+		{"normalizes enums", models.Allergy{Allergen: "Pollen", Severity: " Life_Threatening ", Status: "RESOLVED"}, "", models.AllergySeverityLifeThreatening, models.HealthStatusResolved},
+		{"moderate", models.Allergy{Allergen: "Pollen", Severity: "moderate"}, "", models.AllergySeverityModerate, models.HealthStatusActive},
+		{"severe", models.Allergy{Allergen: "Pollen", Severity: "severe"}, "", models.AllergySeveritySevere, models.HealthStatusActive},
+		{"empty identified date", models.Allergy{Allergen: "Pollen", IdentifiedDate: str("")}, "", models.AllergySeverityMild, models.HealthStatusActive},
+		{"valid identified date", models.Allergy{Allergen: "Pollen", IdentifiedDate: str("2024-02-29")}, "", models.AllergySeverityMild, models.HealthStatusActive},
+		{"blank allergen", models.Allergy{Allergen: "  "}, "allergen required", "", ""},
+		{"long allergen", models.Allergy{Allergen: strings.Repeat("a", maxHealthFieldLen+1)}, "allergen too long", "", ""},
+		{"unknown severity", models.Allergy{Allergen: "Pollen", Severity: "critical"}, "invalid severity", "", ""},
+		{"unknown status", models.Allergy{Allergen: "Pollen", Status: "chronic"}, "invalid status", "", ""},
+		{"bad identified date", models.Allergy{Allergen: "Pollen", IdentifiedDate: str("2024-02-30")}, "invalid identified_date", "", ""},
+		{"timestamp identified date", models.Allergy{Allergen: "Pollen", IdentifiedDate: str("2024-02-01T10:00:00Z")}, "invalid identified_date", "", ""},
+		{"long reaction", models.Allergy{Allergen: "Pollen", Reaction: str(strings.Repeat("a", maxHealthTextLen+1))}, "field too long", "", ""},
+		{"long notes", models.Allergy{Allergen: "Pollen", Notes: str(strings.Repeat("a", maxHealthTextLen+1))}, "field too long", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.in
+			err := validateAllergyInput(&a)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateAllergyInput: %v", err)
+			}
+			if a.Allergen != strings.TrimSpace(tt.in.Allergen) {
+				t.Errorf("allergen = %q, want trimmed", a.Allergen)
+			}
+			if a.Severity != tt.wantSeverity || a.Status != tt.wantStatus {
+				t.Errorf("severity, status = %q, %q, want %q, %q", a.Severity, a.Status, tt.wantSeverity, tt.wantStatus)
+			}
+		})
+	}
+}
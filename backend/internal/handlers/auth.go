@@ -13,6 +13,8 @@ import (
 	"github.com/pet-medical/api/internal/i18n"
 	"github.com/pet-medical/api/internal/middleware"
 	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/notify"
+	"github.com/pet-medical/api/internal/oidc"
 	"gorm.io/gorm"
 )
 
@@ -30,6 +32,10 @@ type AuthHandler struct {
 	DefaultCurrency   string
 	DefaultLanguage   string
 	SameSiteCookie    int // http.SameSite value (Lax default; set SAME_SITE_COOKIE=none only if needed)
+	OIDC              *oidc.Provider // nil unless OpenID Connect login is configured
+	ResetStore        *auth.ResetStore
+	MFAStore          *auth.MFAStore
+	Mailer            notify.Mailer // nil when SMTP is not configured; password reset is then unavailable
 }
 
 type LoginRequest struct {
@@ -51,6 +57,7 @@ type UserDTO struct {
 	WeightUnit string `json:"weight_unit,omitempty"`
 	Currency   string `json:"currency,omitempty"`
 	Language   string `json:"language,omitempty"`
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
 }
 
 type RefreshResponse struct {
@@ -112,24 +119,33 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := h.JWT.NewAccessToken(u.ID, u.DisplayName, u.Email, u.Role)
-	if err != nil {
-		log.Print(i18n.Tf("log.auth.login_jwt_error", err))
-		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+	if u.TOTPEnabled {
+		mfaToken, err := h.JWT.NewMFAToken(u.ID)
+		if err != nil {
+			log.Print(i18n.Tf("log.auth.login_jwt_error", err))
+			http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+			return
+		}
+		log.Print(i18n.Tf("log.auth.login_mfa_required", u.ID))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(auth.MFATokenTTL.Seconds()),
+		})
 		return
 	}
+	h.startSession(w, r, u)
+}
 
-	expiresAt := time.Now().Add(h.JWT.RefreshTokenDuration())
-	refreshToken, err := h.RefreshStore.Create(u.ID, expiresAt)
+// startSession mints the access and refresh tokens for a fully authenticated user and writes the login response.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, u models.User) {
+	accessToken, err := h.issueTokens(w, r, u)
 	if err != nil {
-		log.Print(i18n.Tf("log.auth.login_refresh_error", err))
 		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
 		return
 	}
 
-	h.setRefreshCookie(w, r, refreshToken, int(h.JWT.RefreshTokenDuration().Seconds()))
-	h.setAccessCookie(w, r, accessToken, 15*60)
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(LoginResponse{
 		AccessToken: accessToken,
@@ -142,17 +158,39 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			WeightUnit: u.WeightUnit,
 			Currency:   u.Currency,
 			Language:   u.Language,
+			TwoFactorEnabled: u.TOTPEnabled,
 		},
 	})
 }
 
+// issueTokens creates the access and refresh tokens for u and sets both cookies. It returns the access token.
+func (h *AuthHandler) issueTokens(w http.ResponseWriter, r *http.Request, u models.User) (string, error) {
+	accessToken, err := h.JWT.NewAccessToken(u.ID, u.DisplayName, u.Email, u.Role)
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.login_jwt_error", err))
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(h.JWT.RefreshTokenDuration())
+	refreshToken, err := h.RefreshStore.Create(u.ID, expiresAt, h.device(r))
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.login_refresh_error", err))
+		return "", err
+	}
+
+	h.setRefreshCookie(w, r, refreshToken, int(h.JWT.RefreshTokenDuration().Seconds()))
+	h.setAccessCookie(w, r, accessToken, 15*60)
+	return accessToken, nil
+}
+
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(refreshCookieName)
 	if err != nil || cookie.Value == "" {
 		http.Error(w, `{"error":"refresh token required"}`, http.StatusUnauthorized)
 		return
 	}
-	userID, err := h.RefreshStore.Consume(cookie.Value)
+	expiresAt := time.Now().Add(h.JWT.RefreshTokenDuration())
+	userID, newRefresh, err := h.RefreshStore.Rotate(cookie.Value, expiresAt, h.device(r))
 	if err != nil {
 		h.clearRefreshCookie(w, r)
 		http.Error(w, `{"error":"invalid or expired refresh token"}`, http.StatusUnauthorized)
@@ -173,12 +211,6 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	expiresAt := time.Now().Add(h.JWT.RefreshTokenDuration())
-	newRefresh, err := h.RefreshStore.Create(u.ID, expiresAt)
-	if err != nil {
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
-		return
-	}
 	h.setRefreshCookie(w, r, newRefresh, int(h.JWT.RefreshTokenDuration().Seconds()))
 	h.setAccessCookie(w, r, accessToken, 15*60)
 
@@ -194,11 +226,18 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 			WeightUnit: u.WeightUnit,
 			Currency:   u.Currency,
 			Language:   u.Language,
+			TwoFactorEnabled: u.TOTPEnabled,
 		},
 	})
 }
 
+// Logout ends the current session: its refresh token is deleted server-side, then both cookies are cleared.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(refreshCookieName); err == nil && c.Value != "" {
+		if err := h.RefreshStore.Revoke(c.Value); err != nil {
+			log.Print(i18n.Tf("log.auth.logout_revoke_error", err))
+		}
+	}
 	h.clearRefreshCookie(w, r)
 	h.clearAccessCookie(w, r)
 	w.Header().Set("Content-Type", "application/json")
@@ -290,6 +329,7 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 		WeightUnit:  dbUser.WeightUnit,
 		Currency:    dbUser.Currency,
 		Language:    dbUser.Language,
+		TwoFactorEnabled: dbUser.TOTPEnabled,
 	})
 }
 
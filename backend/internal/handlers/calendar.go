@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/config"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// CalendarHandler manages the per-user ICS subscription feed. The feed itself is public and authenticated only by the
+// secret token in the URL, because calendar clients cannot send our JWT cookies.
+type CalendarHandler struct {
+	DB     *gorm.DB
+	Config *config.Config
+}
+
+// CalendarFeedStatus is the GET /calendar/feed response. Token and URL are only set right after create/rotate.
+type CalendarFeedStatus struct {
+	Active         bool       `json:"active"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	Token          string     `json:"token,omitempty"`
+	URL            string     `json:"url,omitempty"`
+}
+
+const (
+	calendarTokenBytes       = 32
+	defaultCalendarAlarmDays = 7
+)
+
+// GetFeed reports whether the caller has an active feed. The token cannot be shown again; rotate to get a new URL.
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	out := CalendarFeedStatus{}
+	var feed models.CalendarFeed
+	if err := h.DB.Where("user_id = ?", u.ID).First(&feed).Error; err == nil {
+		out.Active = true
+		out.CreatedAt = &feed.CreatedAt
+		out.LastAccessedAt = feed.LastAccessedAt
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// RotateFeed creates the caller's feed or replaces its token, invalidating the previous URL. Returns the new URL once.
+func (h *CalendarHandler) RotateFeed(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	b := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(b)
+	feed := models.CalendarFeed{UserID: u.ID, TokenHash: auth.HashToken(token)}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", u.ID).Delete(&models.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&feed).Error
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	base := h.Config.PublicURL
+	if base == "" {
+		base = h.Config.RequestOrigin(r)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CalendarFeedStatus{
+		Active:    true,
+		CreatedAt: &feed.CreatedAt,
+		Token:     token,
+		URL:       base + "/api/calendar/" + token + ".ics",
+	})
+}
+
+// RevokeFeed deletes the caller's feed so the URL stops working.
+func (h *CalendarHandler) RevokeFeed(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	result := h.DB.Where("user_id = ?", u.ID).Delete(&models.CalendarFeed{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Feed serves GET /api/calendar/{token}.ics (public). Unknown tokens get 404 so revoked feeds look like missing ones.
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	var feed models.CalendarFeed
+	if err := h.DB.Where("token_hash = ?", auth.HashToken(token)).First(&feed).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var user models.User
+	if err := h.DB.Where("id = ?", feed.UserID).First(&user).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var pets []models.Pet
+	if err := h.DB.Select("id, name").Where("id IN (?)", models.AccessiblePetIDs(h.DB, user.ID)).Find(&pets).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	var vaccs []models.Vaccination
+	if len(pets) > 0 {
+		petIDs := make([]uuid.UUID, len(pets))
+		for i, p := range pets {
+			petIDs[i] = p.ID
+		}
+		err := h.DB.Where("pet_id IN ?", petIDs).Order("administered_at DESC, created_at DESC").Find(&vaccs).Error
+		if err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	now := time.Now()
+	h.DB.Model(&models.CalendarFeed{}).Where("id = ?", feed.ID).Update("last_accessed_at", now)
+
+	alarmDays := defaultCalendarAlarmDays
+	if user.ReminderLeadDays > 0 {
+		alarmDays = user.ReminderLeadDays
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write([]byte(renderICS(now, alarmDays, pets, latestDoses(vaccs))))
+}
+
+// renderICS builds a VCALENDAR with one all-day VEVENT per vaccination next_due, each with an alarm alarmDays before
+// and one on the morning of the due date. UIDs are stable so clients update events in place.
+func renderICS(now time.Time, alarmDays int, pets []models.Pet, vaccs []models.Vaccination) string {
+	petNames := make(map[uuid.UUID]string, len(pets))
+	for _, p := range pets {
+		petNames[p.ID] = p.Name
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+	var b strings.Builder
+	line := func(s string) { b.WriteString(foldICSLine(s) + "\r\n") }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Pet Medical//Due dates//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:Pet care due dates")
+	for _, v := range vaccs {
+		if v.NextDue == nil {
+			continue
+		}
+		due, err := time.Parse(dateLayout, *v.NextDue)
+		if err != nil {
+			continue
+		}
+		pet := petNames[v.PetID]
+		summary := fmt.Sprintf("%s: %s due", pet, v.Name)
+		line("BEGIN:VEVENT")
+		line("UID:vaccination-" + v.ID.String() + "@pet-medical")
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + due.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + due.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICSText(summary))
+		line("DESCRIPTION:" + escapeICSText(fmt.Sprintf("%s vaccination for %s, last given %s.", v.Name, pet, v.AdministeredAt)))
+		line("TRANSP:TRANSPARENT")
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line(fmt.Sprintf("TRIGGER:-P%dD", alarmDays))
+		line("DESCRIPTION:" + escapeICSText(summary))
+		line("END:VALARM")
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("TRIGGER:PT9H")
+		line("DESCRIPTION:" + escapeICSText(summary))
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICSText escapes a TEXT value per RFC 5545 3.3.11.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// foldICSLine splits content lines longer than 75 octets, continuing with a leading space, without splitting UTF-8 runes.
+func foldICSLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestRenderICS(t *testing.T) {
+	pet := models.Pet{ID: uuid.New(), Name: "Rex"}
+	vacc := models.Vaccination{ID: uuid.New(), PetID: pet.ID, Name: "DHPP, booster; 3-year", AdministeredAt: "2024-06-01", NextDue: strPtr("2027-06-01")}
+	noDue := models.Vaccination{ID: uuid.New(), PetID: pet.ID, Name: "Lyme", AdministeredAt: "2024-06-01"}
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ics := renderICS(now, 14, []models.Pet{pet}, []models.Vaccination{vacc, noDue})
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:vaccination-" + vacc.ID.String() + "@pet-medical\r\n",
+		"DTSTAMP:20250102T030405Z\r\n",
+		"DTSTART;VALUE=DATE:20270601\r\n",
+		"DTEND;VALUE=DATE:20270602\r\n",
+		`SUMMARY:Rex: DHPP\, booster\; 3-year due` + "\r\n",
+		"TRIGGER:-P14D\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ICS missing %q:\n%s", want, ics)
+		}
+	}
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("got %d events, want 1 (vaccination without next_due skipped)", n)
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := foldICSLine(long)
+	for i, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("line %d is %d octets", i, len(part))
+		}
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("continuation line %d must start with a space", i)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != long {
+		t.Error("unfolding should restore the original line")
+	}
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/config"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/pdf"
+	"github.com/pet-medical/api/internal/qr"
+	"gorm.io/gorm"
+)
+
+// CertificatesHandler renders one-page vaccination certificates and verifies them publicly. A certificate's QR code
+// links to Verify with a signed token; nothing is stored, so any certificate stays verifiable until the record or
+// JWT_SECRET changes.
+type CertificatesHandler struct {
+	DB     *gorm.DB
+	Config *config.Config
+}
+
+// Certificate verification statuses.
+const (
+	CertificateValid    = "valid"
+	CertificateModified = "modified" // the record changed after the certificate was issued
+)
+
+// CertificateVerification is the public Verify response. Pet and Vaccination are only set when the status is valid.
+type CertificateVerification struct {
+	Status      string                  `json:"status"`
+	IssuedAt    time.Time               `json:"issued_at"`
+	Expired     bool                    `json:"expired"` // next_due is in the past
+	Pet         *CertificatePet         `json:"pet,omitempty"`
+	Vaccination *CertificateVaccination `json:"vaccination,omitempty"`
+}
+
+// CertificatePet is the pet as printed on the certificate.
+type CertificatePet struct {
+	Name        string  `json:"name"`
+	Species     *string `json:"species,omitempty"`
+	Breed       *string `json:"breed,omitempty"`
+	DateOfBirth *string `json:"date_of_birth,omitempty"`
+	MicrochipID *string `json:"microchip_id,omitempty"`
+}
+
+// CertificateVaccination is the vaccination as printed on the certificate.
+type CertificateVaccination struct {
+	Name           string  `json:"name"`
+	BatchNumber    *string `json:"batch_number,omitempty"`
+	AdministeredAt string  `json:"administered_at"`
+	NextDue        *string `json:"next_due,omitempty"`
+	Veterinarian   *string `json:"veterinarian,omitempty"`
+}
+
+var errInvalidCertificateToken = errors.New("invalid certificate token")
+
+// certificateKey derives the signing key from the JWT secret so certificates cannot be forged with, or used as, JWTs.
+func certificateKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("pet-medical vaccination certificate v1"))
+	return mac.Sum(nil)
+}
+
+// certificateFingerprint hashes every field printed on the certificate, so any later edit fails verification. The
+// clinic, when there is one, adds its ID and name: the printed veterinarian includes the clinic name, so changing or
+// renaming the clinic changes the certificate.
+func certificateFingerprint(p models.Pet, v models.Vaccination, clinic *models.Clinic) []byte {
+	str := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	fields := []string{
+		p.ID.String(), p.Name, str(p.Species), str(p.Breed), str(p.DateOfBirth), str(p.MicrochipID),
+		v.ID.String(), v.Name, str(v.BatchNumber), v.AdministeredAt, str(v.NextDue), str(v.Veterinarian),
+	}
+	if clinic != nil {
+		fields = append(fields, clinic.ID.String(), clinic.Name)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return sum[:16]
+}
+
+// signCertificate returns "<payload>.<signature>" (base64url), where the payload is the vaccination ID, the issue
+// time, and the record fingerprint.
+func signCertificate(key []byte, vaccinationID uuid.UUID, issuedAt time.Time, fingerprint []byte) string {
+	payload := make([]byte, 0, 40)
+	payload = append(payload, vaccinationID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(issuedAt.Unix()))
+	payload = append(payload, fingerprint...)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseCertificate checks the token's signature and returns its contents.
+func parseCertificate(key []byte, token string) (vaccinationID uuid.UUID, issuedAt time.Time, fingerprint []byte, err error) {
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, time.Time{}, nil, errInvalidCertificateToken
+	}
+	payload, err1 := base64.RawURLEncoding.DecodeString(payloadPart)
+	sig, err2 := base64.RawURLEncoding.DecodeString(sigPart)
+	if err1 != nil || err2 != nil || len(payload) != 40 {
+		return uuid.Nil, time.Time{}, nil, errInvalidCertificateToken
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return uuid.Nil, time.Time{}, nil, errInvalidCertificateToken
+	}
+	copy(vaccinationID[:], payload[:16])
+	issuedAt = time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0).UTC()
+	return vaccinationID, issuedAt, payload[24:], nil
+}
+
+// Certificate handles GET /pets/{petId}/vaccinations/{id}/certificate.pdf.
+func (h *CertificatesHandler) Certificate(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var pet models.Pet
+	var vacc models.Vaccination
+	if h.DB.Where("id = ?", petID).First(&pet).Error != nil || h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&vacc).Error != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var user models.User
+	if err := h.DB.Select("language").Where("id = ?", u.ID).First(&user).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	clinic := h.vaccinationClinic(vacc)
+
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	token := signCertificate(certificateKey(h.Config.JWTSecret), vacc.ID, issuedAt, certificateFingerprint(pet, vacc, clinic))
+	base := h.Config.PublicURL
+	if base == "" {
+		base = h.Config.RequestOrigin(r)
+	}
+	doc, err := renderCertificate(user.Language, pet, vacc, clinic, issuedAt, base+"/api/verify/vaccinations/"+token)
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	filename := downloadFilename(pet.Name+" "+vacc.Name, "certificate", "pdf")
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+// Verify handles GET /api/verify/vaccinations/{token} (public): whether the certificate is genuine and the record
+// still matches it. Forged tokens and deleted records are both 404.
+func (h *CertificatesHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	id, issuedAt, fingerprint, err := parseCertificate(certificateKey(h.Config.JWTSecret), mux.Vars(r)["token"])
+	var vacc models.Vaccination
+	var pet models.Pet
+	if err != nil || h.DB.Where("id = ?", id).First(&vacc).Error != nil || h.DB.Where("id = ?", vacc.PetID).First(&pet).Error != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	out := CertificateVerification{Status: CertificateModified, IssuedAt: issuedAt}
+	clinic := h.vaccinationClinic(vacc)
+	if hmac.Equal(fingerprint, certificateFingerprint(pet, vacc, clinic)) {
+		out.Status = CertificateValid
+		out.Expired = vacc.NextDue != nil && *vacc.NextDue != "" && *vacc.NextDue < time.Now().Format(dateLayout)
+		out.Pet = &CertificatePet{Name: pet.Name, Species: pet.Species, Breed: pet.Breed, DateOfBirth: pet.DateOfBirth, MicrochipID: pet.MicrochipID}
+		out.Vaccination = &CertificateVaccination{
+			Name:           vacc.Name,
+			BatchNumber:    vacc.BatchNumber,
+			AdministeredAt: vacc.AdministeredAt,
+			NextDue:        vacc.NextDue,
+		}
+		if vet := certificateVeterinarian(vacc, clinic); vet != "" {
+			out.Vaccination.Veterinarian = &vet
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// vaccinationClinic loads the clinic linked to v; nil when there is none or it was deleted.
+func (h *CertificatesHandler) vaccinationClinic(v models.Vaccination) *models.Clinic {
+	if v.ClinicID == nil {
+		return nil
+	}
+	var c models.Clinic
+	if h.DB.Where("id = ?", *v.ClinicID).First(&c).Error != nil {
+		return nil
+	}
+	return &c
+}
+
+// certificateVeterinarian is the veterinarian line as printed: the vaccination's veterinarian and the clinic name,
+// either of which may be missing; "" when both are.
+func certificateVeterinarian(v models.Vaccination, clinic *models.Clinic) string {
+	var parts []string
+	if vet := derefOr(v.Veterinarian, ""); vet != "" {
+		parts = append(parts, vet)
+	}
+	if clinic != nil {
+		parts = append(parts, clinic.Name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderCertificate lays out the one-page certificate with the verification QR code in the bottom-right corner.
+func renderCertificate(lang string, pet models.Pet, v models.Vaccination, clinic *models.Clinic, issuedAt time.Time, verifyURL string) (*pdf.Document, error) {
+	code, err := qr.Encode([]byte(verifyURL))
+	if err != nil {
+		return nil, err
+	}
+	t := func(key string) string { return i18n.TLang(lang, "pdf.certificate."+key) }
+	s := func(key string) string { return i18n.TLang(lang, "pdf.summary."+key) }
+	doc := pdf.New()
+	doc.Title = pet.Name + " - " + v.Name + " - " + t("title")
+	p := &summaryPage{doc: doc, y: summaryMargin + 20, lang: lang}
+	right := pdf.PageWidth - summaryMargin
+
+	doc.FillRect(0, 0, pdf.PageWidth, 8, summaryGreen)
+	doc.Text(summaryMargin, p.y+10, pdf.Bold, 26, summaryBlack, t("title"))
+	doc.Text(summaryMargin, p.y+30, pdf.Regular, 9, summaryGray, i18n.TfLang(lang, "pdf.certificate.id", v.ID.String()))
+	p.y += 40
+
+	dash := "—"
+	p.heading(t("pet"))
+	p.labelRows([][2]string{
+		{t("name"), pet.Name},
+		{s("species"), derefOr(pet.Species, dash)},
+		{s("breed"), derefOr(pet.Breed, dash)},
+		{s("date_of_birth"), derefOr(pet.DateOfBirth, dash)},
+		{s("microchip"), derefOr(pet.MicrochipID, dash)},
+	}, right-summaryMargin-110)
+
+	p.heading(t("vaccination"))
+	vet := certificateVeterinarian(v, clinic)
+	if vet == "" {
+		vet = dash
+	}
+	p.labelRows([][2]string{
+		{s("vaccine"), v.Name},
+		{t("batch_number"), derefOr(v.BatchNumber, dash)},
+		{t("administered"), v.AdministeredAt},
+		{s("next_due"), derefOr(v.NextDue, dash)},
+		{t("veterinarian"), vet},
+	}, right-summaryMargin-110)
+
+	// Verification block: QR code on the right, explanation on the left.
+	const qrSize = 132.0
+	top := pdf.PageHeight - summaryMargin - qrSize - 24
+	p.y = top - 16
+	doc.Line(summaryMargin, p.y, right, p.y, 0.75, summaryRule)
+	drawQR(doc, code, right-qrSize, top, qrSize)
+	textWidth := right - qrSize - 24 - summaryMargin
+	y := top + 14
+	doc.Text(summaryMargin, y, pdf.Bold, 12, summaryBlack, t("verify_heading"))
+	for _, line := range pdf.Wrap(pdf.Regular, 9, textWidth, t("verify_note")) {
+		y += 13
+		doc.Text(summaryMargin, y, pdf.Regular, 9, summaryGray, line)
+	}
+	y += 8
+	for _, line := range pdf.Wrap(pdf.Regular, 7, textWidth, verifyURL) {
+		y += 10
+		doc.Text(summaryMargin, y, pdf.Regular, 7, summaryGray, line)
+	}
+	doc.Text(summaryMargin, top+qrSize, pdf.Regular, 9, summaryBlack,
+		i18n.TfLang(lang, "pdf.certificate.issued", issuedAt.Format("2006-01-02 15:04")+" UTC"))
+	return doc, nil
+}
+
+// drawQR draws code in a size x size box at (x, y); the box includes the four-module quiet zone.
+func drawQR(doc *pdf.Document, code *qr.Code, x, y, size float64) {
+	module := size / float64(code.Size+8)
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; {
+			if !code.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && code.Dark(col, row) {
+				col++
+			}
+			doc.FillRect(x+module*float64(4+start), y+module*float64(4+row), module*float64(col-start), module, color.Black)
+		}
+	}
+}
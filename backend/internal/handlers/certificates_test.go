@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestCertificateToken_RoundTrip(t *testing.T) {
+	key := certificateKey("test-secret")
+	id := uuid.New()
+	issued := time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+	fp := bytes.Repeat([]byte{7}, 16)
+	token := signCertificate(key, id, issued, fp)
+
+	gotID, gotIssued, gotFP, err := parseCertificate(key, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotID != id || !gotIssued.Equal(issued) || !bytes.Equal(gotFP, fp) {
+		t.Errorf("parsed %s %s %x", gotID, gotIssued, gotFP)
+	}
+
+	payload, sig, _ := strings.Cut(token, ".")
+	flip := func(s string) string {
+		if s[0] == 'A' {
+			return "B" + s[1:]
+		}
+		return "A" + s[1:]
+	}
+	tampered := []string{
+		"",
+		payload,
+		payload + "." + flip(sig),
+		flip(payload) + "." + sig,
+	}
+	for _, tok := range tampered {
+		if _, _, _, err := parseCertificate(key, tok); err == nil {
+			t.Errorf("accepted tampered token %q", tok)
+		}
+	}
+	if _, _, _, err := parseCertificate(certificateKey("other-secret"), token); err == nil {
+		t.Error("accepted token signed with another secret")
+	}
+}
+
+func TestCertificateFingerprint_ChangesWithPrintedFields(t *testing.T) {
+	batch := "A1"
+	pet := models.Pet{ID: uuid.New(), Name: "Rex"}
+	vacc := models.Vaccination{ID: uuid.New(), Name: "Rabies", AdministeredAt: "2025-01-01", BatchNumber: &batch}
+	base := certificateFingerprint(pet, vacc, nil)
+	if !bytes.Equal(base, certificateFingerprint(pet, vacc, nil)) {
+		t.Fatal("fingerprint is not deterministic")
+	}
+	edited := vacc
+	other := "A2"
+	edited.BatchNumber = &other
+	if bytes.Equal(base, certificateFingerprint(pet, edited, nil)) {
+		t.Error("batch number change not detected")
+	}
+	renamed := pet
+	renamed.Name = "Max"
+	if bytes.Equal(base, certificateFingerprint(renamed, vacc, nil)) {
+		t.Error("pet name change not detected")
+	}
+	clinic := &models.Clinic{ID: uuid.New(), Name: "Main Street Vets"}
+	withClinic := certificateFingerprint(pet, vacc, clinic)
+	if bytes.Equal(base, withClinic) {
+		t.Error("clinic not part of the fingerprint")
+	}
+	renamedClinic := *clinic
+	renamedClinic.Name = "Harbour Vets"
+	if bytes.Equal(withClinic, certificateFingerprint(pet, vacc, &renamedClinic)) {
+		t.Error("clinic rename not detected")
+	}
+	otherClinic := *clinic
+	otherClinic.ID = uuid.New()
+	if bytes.Equal(withClinic, certificateFingerprint(pet, vacc, &otherClinic)) {
+		t.Error("clinic change not detected")
+	}
+	notes := "unrelated"
+	vacc.Notes = &notes
+	if !bytes.Equal(base, certificateFingerprint(pet, vacc, nil)) {
+		t.Error("fields not on the certificate must not affect the fingerprint")
+	}
+}
+
+func TestCertificateVeterinarian(t *testing.T) {
+	str := func(s string) *string { return &s }
+	clinic := &models.Clinic{Name: "Main Street Vets"}
+	tests := []struct {
+		name   string
+		vet    *string
+		clinic *models.Clinic
+		want   string
+	}{
+		{"vet and clinic", str("Dr. Oak"), clinic, "Dr. Oak, Main Street Vets"},
+		{"vet only", str(" Dr. Oak "), nil, "Dr. Oak"},
+		{"clinic only", str(" "), clinic, "Main Street Vets"},
+		{"neither", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certificateVeterinarian(models.Vaccination{Veterinarian: tt.vet}, tt.clinic); got != tt.want {
+				t.Errorf("certificateVeterinarian = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderCertificate(t *testing.T) {
+	pet := models.Pet{ID: uuid.New(), Name: "Rex"}
+	vacc := models.Vaccination{ID: uuid.New(), Name: "Rabies", AdministeredAt: "2025-01-01"}
+	clinic := &models.Clinic{Name: "Main Street Vets"}
+	url := "https://pets.example.com/api/verify/vaccinations/" + signCertificate(certificateKey("s"), vacc.ID, time.Now(), make([]byte, 16))
+	doc, err := renderCertificate("fr", pet, vacc, clinic, time.Now(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.PageCount() != 1 {
+		t.Errorf("pages = %d, want 1", doc.PageCount())
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil || !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("write: %v", err)
+	}
+}
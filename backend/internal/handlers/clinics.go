@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// ClinicsHandler provides CRUD for the current user's clinic/veterinarian directory.
+type ClinicsHandler struct {
+	DB *gorm.DB
+}
+
+// clinicBelongsToUser reports whether clinicID is in userID's directory. Used to validate clinic_id on pets, vaccinations, and conditions.
+func clinicBelongsToUser(db *gorm.DB, clinicID, userID uuid.UUID) bool {
+	var count int64
+	db.Model(&models.Clinic{}).Where("id = ? AND user_id = ?", clinicID, userID).Count(&count)
+	return count > 0
+}
+
+func (h *ClinicsHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	q := h.DB.Where("user_id = ?", u.ID)
+	if strings.ToLower(r.URL.Query().Get("emergency")) == "true" {
+		q = q.Where("emergency = ?", true)
+	}
+	var list []models.Clinic
+	if err := q.Order("name").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.Clinic{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *ClinicsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	var c models.Clinic
+	if err := h.DB.Where("id = ? AND user_id = ?", id, u.ID).First(&c).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *ClinicsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var c models.Clinic
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	c.ID = uuid.Nil
+	c.UserID = u.ID
+	if err := validateClinicInput(&c); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.Create(&c).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *ClinicsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	var c models.Clinic
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	c.ID = id
+	c.UserID = u.ID
+	if err := validateClinicInput(&c); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.Clinic{}).Where("id = ? AND user_id = ?", id, u.ID).Updates(map[string]interface{}{
+		"name": c.Name, "veterinarian": c.Veterinarian, "address": c.Address, "phone": c.Phone,
+		"email": c.Email, "emergency": c.Emergency, "notes": c.Notes,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("id = ?", id).First(&c)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// Delete removes the directory entry and clears references from the user's pets, vaccinations, and conditions.
+func (h *ClinicsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !clinicBelongsToUser(h.DB, id, u.ID) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Model(&models.Pet{}).Where("clinic_id = ? AND user_id = ?", id, u.ID).Update("clinic_id", nil)
+	h.DB.Model(&models.Vaccination{}).Where("clinic_id = ?", id).Update("clinic_id", nil)
+	h.DB.Model(&models.ChronicCondition{}).Where("clinic_id = ?", id).Update("clinic_id", nil)
+	result := h.DB.Where("id = ? AND user_id = ?", id, u.ID).Delete(&models.Clinic{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	maxClinicFieldLen = 200 // name, veterinarian, phone, email
+	maxClinicTextLen  = 2000
+)
+
+func validateClinicInput(c *models.Clinic) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("name required")
+	}
+	if len(c.Name) > maxClinicFieldLen {
+		return errors.New("name too long")
+	}
+	for _, s := range []*string{c.Veterinarian, c.Phone, c.Email} {
+		if s != nil && len(*s) > maxClinicFieldLen {
+			return errors.New("field too long")
+		}
+	}
+	for _, s := range []*string{c.Address, c.Notes} {
+		if s != nil && len(*s) > maxClinicTextLen {
+			return errors.New("field too long")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+type ConditionsHandler struct {
+	DB *gorm.DB
+}
+
+// List returns the pet's chronic conditions. Optional ?status=active|resolved.
+func (h *ConditionsHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	q := h.DB.Where("pet_id = ?", petID)
+	if status := strings.ToLower(r.URL.Query().Get("status")); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var list []models.ChronicCondition
+	if err := q.Order("diagnosed_date DESC NULLS LAST, diagnosis").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.ChronicCondition{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *ConditionsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var c models.ChronicCondition
+	if err := h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&c).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *ConditionsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var c models.ChronicCondition
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	c.PetID = petID
+	c.ID = uuid.Nil
+	if err := validateConditionInput(&c); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if c.ClinicID != nil && !clinicBelongsToUser(h.DB, *c.ClinicID, petCreatorID(h.DB, petID)) {
+		http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.Create(&c).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *ConditionsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var c models.ChronicCondition
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	c.ID = id
+	c.PetID = petID
+	if err := validateConditionInput(&c); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if c.ClinicID != nil && !clinicBelongsToUser(h.DB, *c.ClinicID, petCreatorID(h.DB, petID)) {
+		http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.ChronicCondition{}).Where("id = ? AND pet_id = ?", id, petID).Updates(map[string]interface{}{
+		"diagnosis": c.Diagnosis, "diagnosed_date": c.DiagnosedDate, "status": c.Status,
+		"managing_vet": c.ManagingVet, "clinic_id": c.ClinicID, "notes": c.Notes,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("id = ?", id).First(&c)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+func (h *ConditionsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.ChronicCondition{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateConditionInput(c *models.ChronicCondition) error {
+	c.Diagnosis = strings.TrimSpace(c.Diagnosis)
+	if c.Diagnosis == "" {
+		return errors.New("diagnosis required")
+	}
+	if len(c.Diagnosis) > maxHealthFieldLen {
+		return errors.New("diagnosis too long")
+	}
+	if !validHealthStatus(&c.Status) {
+		return errors.New("invalid status")
+	}
+	if c.DiagnosedDate != nil && *c.DiagnosedDate != "" {
+		if _, err := time.Parse(dateLayout, *c.DiagnosedDate); err != nil {
+			return errors.New("invalid diagnosed_date")
+		}
+	}
+	if c.ManagingVet != nil && len(*c.ManagingVet) > maxHealthFieldLen {
+		return errors.New("managing_vet too long")
+	}
+	if c.Notes != nil && len(*c.Notes) > maxHealthTextLen {
+		return errors.New("notes too long")
+	}
+	return nil
+}
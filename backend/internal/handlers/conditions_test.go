@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestValidateConditionInput(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name       string
+		in         models.ChronicCondition
+		wantErr    string // "" means valid
+		wantStatus string
+	}{
+		{"defaults to active", models.ChronicCondition{Diagnosis: " Hypothyroidism "}, "", models.HealthStatusActive},
+		{"normalizes status", models.ChronicCondition{Diagnosis: "Otitis", Status: " Resolved "}, "", models.HealthStatusResolved},
+		{"empty diagnosed date", models.ChronicCondition{Diagnosis: "Otitis", DiagnosedDate: str("")}, "", models.HealthStatusActive},
+		{"valid diagnosed date", models.ChronicCondition{Diagnosis: "Otitis", DiagnosedDate: str("2023-11-05")}, "", models.HealthStatusActive},
+		{"blank diagnosis", models.ChronicCondition{Diagnosis: " "}, "diagnosis required", ""},
+		{"long diagnosis", models.ChronicCondition{Diagnosis: strings.Repeat("a", maxHealthFieldLen+1)}, "diagnosis too long", ""},
+		{"unknown status", models.ChronicCondition{Diagnosis: "Otitis", Status: "managed"}, "invalid status", ""},
+		{"bad diagnosed date", models.ChronicCondition{Diagnosis: "Otitis", DiagnosedDate: str("05/11/2023")}, "invalid diagnosed_date", ""},
+		{"long managing vet", models.ChronicCondition{Diagnosis: "Otitis", ManagingVet: str(strings.Repeat("a", maxHealthFieldLen+1))}, "managing_vet too long", ""},
+		{"long notes", models.ChronicCondition{Diagnosis: "Otitis", Notes: str(strings.Repeat("a", maxHealthTextLen+1))}, "notes too long", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.in
+			err := validateConditionInput(&c)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateConditionInput: %v", err)
+			}
+			if c.Diagnosis != strings.TrimSpace(tt.in.Diagnosis) {
+				t.Errorf("diagnosis = %q, want trimmed", c.Diagnosis)
+			}
+			if c.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", c.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxCSVBytes = 5 << 20 // 5 MB
+	maxCSVRows  = 10000
+)
+
+// CSVRowIssue is a problem with one data row. Row is the line the row starts on (the header is line 1).
+type CSVRowIssue struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// CSVImportReport is the response of a CSV import. Columns maps each imported field to the header it was read from.
+// Duplicates are rows matching an existing record (or an earlier row) and are skipped, not errors.
+type CSVImportReport struct {
+	DryRun     bool              `json:"dry_run"`
+	DateFormat string            `json:"date_format"`
+	Columns    map[string]string `json:"columns"`
+	Rows       int               `json:"rows"`
+	Created    int               `json:"created"`
+	Duplicates []int             `json:"duplicates"`
+	Errors     []CSVRowIssue     `json:"errors"`
+	Warnings   []string          `json:"warnings"`
+}
+
+func newCSVImportReport() CSVImportReport {
+	return CSVImportReport{Columns: map[string]string{}, Duplicates: []int{}, Errors: []CSVRowIssue{}, Warnings: []string{}}
+}
+
+// csvTable is a parsed upload: the header row and the data rows, with the line each row starts on so errors point at
+// the row a spreadsheet shows.
+type csvTable struct {
+	header []string
+	rows   [][]string
+	lines  []int
+}
+
+// readCSV parses a CSV exported by a spreadsheet: an optional UTF-8 BOM is skipped and the delimiter (comma,
+// semicolon, or tab) is taken from the header line. Blank rows are dropped.
+func readCSV(r io.Reader) (*csvTable, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxCSVBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCSVBytes {
+		return nil, errors.New("file too large")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.Comma = ','
+	for _, d := range []rune{';', '\t'} {
+		if bytes.Count(firstLine, []byte(string(d))) > bytes.Count(firstLine, []byte(string(cr.Comma))) {
+			cr.Comma = d
+		}
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+	t := &csvTable{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid CSV: " + err.Error())
+		}
+		if t.header == nil {
+			t.header = rec
+			continue
+		}
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		t.rows = append(t.rows, rec)
+		t.lines = append(t.lines, line)
+		if len(t.rows) > maxCSVRows {
+			return nil, fmt.Errorf("too many rows (max %d)", maxCSVRows)
+		}
+	}
+	if t.header == nil {
+		return nil, errors.New("CSV is empty")
+	}
+	return t, nil
+}
+
+// cell returns the trimmed value of column col in row, or "" when the row is short or col is -1.
+func (t *csvTable) cell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(unescapeCSVCell(row[col]))
+}
+
+// column returns the values of col across all rows, for date format detection.
+func (t *csvTable) column(col int) []string {
+	out := make([]string, len(t.rows))
+	for i, row := range t.rows {
+		out[i] = t.cell(row, col)
+	}
+	return out
+}
+
+// normalizeHeader turns "Weight (kg)" into "weight_kg" so headers and aliases compare loosely.
+func normalizeHeader(s string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
+
+// mapColumns resolves each field to a column index (-1 when absent). mapping, from the request, names a header or a
+// 1-based column number per field and wins over aliases; otherwise a header matches a field if it equals one of the
+// field's aliases or its first word does ("date_measured" for "date"). Fields are resolved in the order of fields.
+func mapColumns(header []string, fields []string, aliases map[string][]string, mapping map[string]string) (map[string]int, error) {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f] = true
+	}
+	for f := range mapping {
+		if !known[f] {
+			return nil, fmt.Errorf("unknown field %q in mapping", f)
+		}
+	}
+	norm := make([]string, len(header))
+	for i, h := range header {
+		norm[i] = normalizeHeader(h)
+	}
+	used := make(map[int]bool)
+	out := make(map[string]int, len(fields))
+	for _, f := range fields {
+		out[f] = -1
+		if m, ok := mapping[f]; ok && strings.TrimSpace(m) != "" {
+			col := -1
+			if n, err := strconv.Atoi(strings.TrimSpace(m)); err == nil && n >= 1 && n <= len(header) {
+				col = n - 1
+			} else {
+				for i, h := range norm {
+					if h == normalizeHeader(m) {
+						col = i
+						break
+					}
+				}
+			}
+			if col < 0 {
+				return nil, fmt.Errorf("column %q for %s not found", m, f)
+			}
+			out[f], used[col] = col, true
+		}
+	}
+	for _, f := range fields {
+		if out[f] >= 0 {
+			continue
+		}
+		for pass := 0; pass < 2 && out[f] < 0; pass++ {
+			for _, alias := range aliases[f] {
+				for i, h := range norm {
+					first, _, _ := strings.Cut(h, "_")
+					if !used[i] && ((pass == 0 && h == alias) || (pass == 1 && first == alias)) {
+						out[f], used[i] = i, true
+						break
+					}
+				}
+				if out[f] >= 0 {
+					break
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// parseCSVMapping decodes the optional "mapping" form field: a JSON object of field to header or column number.
+func parseCSVMapping(r *http.Request) (map[string]string, error) {
+	raw := strings.TrimSpace(r.FormValue("mapping"))
+	if raw == "" {
+		return nil, nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return nil, errors.New("mapping must be a JSON object of field to column")
+	}
+	return m, nil
+}
+
+// csvDateFormat is a date format a spreadsheet may use; Name is what the API reports and accepts as date_format.
+type csvDateFormat struct {
+	Name   string
+	Layout string
+}
+
+// Go layouts accept one- or two-digit days and months, so "1/2/2006" also reads "01/02/2006".
+var (
+	csvISODateFormats = []csvDateFormat{
+		{"YYYY-MM-DD", dateLayout},
+		{"YYYY-MM-DDTHH:MM:SS", time.RFC3339},
+		{"YYYY-MM-DD HH:MM:SS", "2006-01-02 15:04:05"},
+		{"YYYY-MM-DD HH:MM", "2006-01-02 15:04"},
+		{"YYYY/MM/DD", "2006/1/2"},
+	}
+	csvMonthFirstFormats = []csvDateFormat{
+		{"MM/DD/YYYY", "1/2/2006"},
+		{"MM-DD-YYYY", "1-2-2006"},
+		{"MM/DD/YY", "1/2/06"},
+	}
+	csvDayFirstFormats = []csvDateFormat{
+		{"DD/MM/YYYY", "2/1/2006"},
+		{"DD.MM.YYYY", "2.1.2006"},
+		{"DD-MM-YYYY", "2-1-2006"},
+		{"DD/MM/YY", "2/1/06"},
+		{"DD.MM.YY", "2.1.06"},
+	}
+	csvTextDateFormats = []csvDateFormat{
+		{"MMM D, YYYY", "Jan 2, 2006"},
+		{"MMM D YYYY", "Jan 2 2006"},
+		{"MMMM D, YYYY", "January 2, 2006"},
+		{"D MMM YYYY", "2 Jan 2006"},
+		{"D MMMM YYYY", "2 January 2006"},
+	}
+)
+
+// csvDateFormats lists the formats in detection order. Month-first and day-first dates are indistinguishable until a
+// day passes 12, so the caller picks which family wins a tie (day-first for every language but English).
+func csvDateFormats(dayFirst bool) []csvDateFormat {
+	out := append([]csvDateFormat{}, csvISODateFormats...)
+	if dayFirst {
+		out = append(append(out, csvDayFirstFormats...), csvMonthFirstFormats...)
+	} else {
+		out = append(append(out, csvMonthFirstFormats...), csvDayFirstFormats...)
+	}
+	return append(out, csvTextDateFormats...)
+}
+
+// detectDateFormat picks the format that parses the most values, earliest in csvDateFormats(dayFirst) on a tie.
+// named, when set, must be one of the format names and is used as is. ambiguous reports that the chosen format tied
+// with one of the other month/day order, so the caller can warn that the guess came from the language.
+func detectDateFormat(values []string, dayFirst bool, named string) (f csvDateFormat, ambiguous bool, err error) {
+	formats := csvDateFormats(dayFirst)
+	if named = strings.TrimSpace(named); named != "" {
+		for _, f := range formats {
+			if strings.EqualFold(f.Name, named) {
+				return f, false, nil
+			}
+		}
+		return csvDateFormat{}, false, fmt.Errorf("unknown date_format %q", named)
+	}
+	counts := make([]int, len(formats))
+	best := 0
+	for i, f := range formats {
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			if _, err := time.Parse(f.Layout, v); err == nil {
+				counts[i]++
+			}
+		}
+		if counts[i] > counts[best] {
+			best = i
+		}
+	}
+	if counts[best] == 0 {
+		return formats[0], false, nil
+	}
+	for i, f := range formats {
+		if i != best && counts[i] == counts[best] && dayOrder(f) != 0 && dayOrder(f) != dayOrder(formats[best]) {
+			ambiguous = true
+		}
+	}
+	return formats[best], ambiguous, nil
+}
+
+// dayOrder is 1 for month-first formats, 2 for day-first, 0 when the order cannot be confused.
+func dayOrder(f csvDateFormat) int {
+	for _, m := range csvMonthFirstFormats {
+		if m == f {
+			return 1
+		}
+	}
+	for _, d := range csvDayFirstFormats {
+		if d == f {
+			return 2
+		}
+	}
+	return 0
+}
+
+// parseCSVDate converts a cell to the stored "2006-01-02" form.
+func parseCSVDate(f csvDateFormat, s string) (string, error) {
+	t, err := time.Parse(f.Layout, s)
+	if err != nil {
+		return "", fmt.Errorf("date %q does not match %s", s, f.Name)
+	}
+	if t.Year() < 1900 || t.Year() > time.Now().Year()+50 {
+		return "", fmt.Errorf("date %q out of range", s)
+	}
+	return t.Format(dateLayout), nil
+}
+
+// parseCSVNumber reads a number written with either a decimal point or, as European spreadsheets do, a decimal comma.
+func parseCSVNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ",") && !strings.Contains(s, ".") {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseCSVBool treats the usual spreadsheet spellings of yes as true.
+func parseCSVBool(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "y", "x", "~":
+		return true
+	}
+	return false
+}
+
+// escapeCSVCell neutralises free text that a spreadsheet would otherwise run as a formula.
+func escapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCSVCell undoes escapeCSVCell so exported files import unchanged.
+func unescapeCSVCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// formatCSVNumber writes at most three decimals and no trailing zeros.
+func formatCSVNumber(v float64) string {
+	return strings.TrimRight(strings.TrimRight(strconv.FormatFloat(v, 'f', 3, 64), "0"), ".")
+}
+
+// csvStringPtr returns nil for an empty cell.
+func csvStringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// readCSVUpload parses the multipart "file" and "mapping" fields of an import request, writing a 400 on failure.
+func readCSVUpload(w http.ResponseWriter, r *http.Request) (*csvTable, map[string]string, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCSVBytes+1<<20)
+	if err := r.ParseMultipartForm(maxCSVBytes + 1<<20); err != nil {
+		http.Error(w, `{"error":"invalid multipart or file too large"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, `{"error":"file required"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	defer file.Close()
+	table, err := readCSV(file)
+	if err != nil {
+		writeCSVError(w, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+	mapping, err := parseCSVMapping(r)
+	if err != nil {
+		writeCSVError(w, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+	return table, mapping, true
+}
+
+func csvDryRun(r *http.Request) bool {
+	v := r.URL.Query().Get("dry_run")
+	return v == "true" || v == "1"
+}
+
+// writeCSVReport creates the n planned records (a pointer to their slice) unless the report is a dry run, then
+// writes the report: 201 after an import, 200 for a dry run. In a dry run Created counts what would be created.
+func writeCSVReport(w http.ResponseWriter, db *gorm.DB, report *CSVImportReport, records interface{}, n int) {
+	report.Created = n
+	status := http.StatusOK
+	if !report.DryRun {
+		if n > 0 {
+			if err := db.CreateInBatches(records, 200).Error; err != nil {
+				http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeCSVError writes a JSON error whose message may contain quotes.
+func writeCSVError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
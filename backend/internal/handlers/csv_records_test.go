@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestReadCSV_DelimiterAndLines(t *testing.T) {
+	in := "\xef\xbb\xbfDate;Weight (kg);Notes\n01.02.2024;12,5;\n\n15.03.2024;\"13\";\"vet; fasted\"\n"
+	table, err := readCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.header) != 3 || table.header[0] != "Date" {
+		t.Fatalf("header = %q", table.header)
+	}
+	if len(table.rows) != 2 || table.lines[0] != 2 || table.lines[1] != 4 {
+		t.Fatalf("rows = %q, lines = %v", table.rows, table.lines)
+	}
+	if got := table.cell(table.rows[1], 2); got != "vet; fasted" {
+		t.Errorf("quoted cell = %q", got)
+	}
+}
+
+func TestMapColumns(t *testing.T) {
+	header := []string{"Day", "Weight (kg)", "Comments", "Unit"}
+	cols, err := mapColumns(header, weightCSVFields, weightCSVAliases, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"measured_at": 0, "weight": 1, "notes": 2, "unit": 3, "approximate": -1}
+	for f, c := range want {
+		if cols[f] != c {
+			t.Errorf("%s -> %d, want %d", f, cols[f], c)
+		}
+	}
+	cols, err = mapColumns(header, weightCSVFields, weightCSVAliases, map[string]string{"notes": "4", "unit": ""})
+	if err != nil || cols["notes"] != 3 || cols["unit"] != -1 {
+		t.Errorf("explicit mapping: %v, %v", cols, err)
+	}
+	if _, err := mapColumns(header, weightCSVFields, weightCSVAliases, map[string]string{"weight": "Mass"}); err == nil {
+		t.Error("mapping to a missing column should fail")
+	}
+	if _, err := mapColumns(header, weightCSVFields, weightCSVAliases, map[string]string{"height": "Day"}); err == nil {
+		t.Error("mapping an unknown field should fail")
+	}
+}
+
+func TestDetectDateFormat(t *testing.T) {
+	tests := []struct {
+		values    []string
+		dayFirst  bool
+		want      string
+		ambiguous bool
+	}{
+		{[]string{"2024-01-02", "2024-12-31"}, false, "YYYY-MM-DD", false},
+		{[]string{"01/02/2024", "12/31/2024"}, true, "MM/DD/YYYY", false},
+		{[]string{"01/02/2024", "31/12/2024"}, false, "DD/MM/YYYY", false},
+		{[]string{"01/02/2024", "03/04/2024"}, false, "MM/DD/YYYY", true},
+		{[]string{"01/02/2024", "03/04/2024"}, true, "DD/MM/YYYY", true},
+		{[]string{"1.2.2024", ""}, false, "DD.MM.YYYY", false},
+		{[]string{"Mar 5, 2024", "Dec 25, 2023"}, false, "MMM D, YYYY", false},
+	}
+	for _, tt := range tests {
+		f, ambiguous, err := detectDateFormat(tt.values, tt.dayFirst, "")
+		if err != nil || f.Name != tt.want || ambiguous != tt.ambiguous {
+			t.Errorf("%v (dayFirst=%v) = %s, ambiguous %v, %v; want %s, %v", tt.values, tt.dayFirst, f.Name, ambiguous, err, tt.want, tt.ambiguous)
+		}
+	}
+	if f, _, err := detectDateFormat([]string{"2024-01-02"}, false, "dd/mm/yyyy"); err != nil || f.Name != "DD/MM/YYYY" {
+		t.Errorf("named format = %s, %v", f.Name, err)
+	}
+	if _, _, err := detectDateFormat(nil, false, "julian"); err == nil {
+		t.Error("unknown named format should fail")
+	}
+}
+
+func TestParseWeightCell(t *testing.T) {
+	tests := []struct {
+		in, unit   string
+		lbs        float64
+		entryUnit  string
+		approx     bool
+		shouldFail bool
+	}{
+		{"27.5", "lbs", 27.5, "lbs", false, false},
+		{"12,5", "kg", 12.5 * lbsPerKg, "kg", false, false},
+		{"12.5 kg", "lbs", 12.5 * lbsPerKg, "kg", false, false},
+		{"~30lbs", "kg", 30, "lbs", true, false},
+		{"0", "lbs", 0, "", false, true},
+		{"12 stone", "lbs", 0, "", false, true},
+		{"heavy", "lbs", 0, "", false, true},
+		{"NaN", "lbs", 0, "", false, true},
+		{"Inf", "lbs", 0, "", false, true},
+	}
+	for _, tt := range tests {
+		lbs, unit, approx, err := parseWeightCell(tt.in, tt.unit)
+		if (err != nil) != tt.shouldFail {
+			t.Errorf("%q: err = %v", tt.in, err)
+			continue
+		}
+		if math.Abs(lbs-tt.lbs) > 1e-9 || unit != tt.entryUnit || approx != tt.approx {
+			t.Errorf("%q = %v %s %v, want %v %s %v", tt.in, lbs, unit, approx, tt.lbs, tt.entryUnit, tt.approx)
+		}
+	}
+}
+
+func TestPlanWeightImport(t *testing.T) {
+	in := "date,weight,unit\n2024-01-01,10,kg\n2024-01-02,22.05,\n2024-01-02,22.06,\nnot a date,5,\n2024-01-03,5,stone\n2024-01-04,,\n"
+	table, err := readCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols, _ := mapColumns(table.header, weightCSVFields, weightCSVAliases, nil)
+	format, _, _ := detectDateFormat(table.column(cols["measured_at"]), false, "")
+	existing := []models.WeightEntry{{MeasuredAt: "2024-01-01", WeightLbs: 10 * lbsPerKg}}
+	entries, report := planWeightImport(table, cols, format, "lbs", existing)
+	if len(entries) != 1 || entries[0].MeasuredAt != "2024-01-02" || entries[0].EntryUnit != "lbs" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if len(report.Duplicates) != 2 || report.Duplicates[0] != 2 || report.Duplicates[1] != 4 {
+		t.Errorf("duplicates = %v, want rows 2 and 4", report.Duplicates)
+	}
+	if len(report.Errors) != 3 || report.Errors[0].Row != 5 || report.Errors[2].Row != 7 {
+		t.Errorf("errors = %+v", report.Errors)
+	}
+}
+
+func TestPlanVaccinationImport(t *testing.T) {
+	in := "Vaccine,Date given,Next due,Price\nRabies,03/15/2024,03/15/2027,45.50\nrabies ,03/15/2024,,\nDHPP,04/01/2024,01/01/2024,\n,04/01/2024,,\nLepto,04/02/2024,,free\nBordetella,04/03/2024,,NaN\n"
+	table, err := readCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols, _ := mapColumns(table.header, vaccinationCSVFields, vaccinationCSVAliases, nil)
+	format, _, _ := detectDateFormat(table.column(cols["administered_at"]), false, "")
+	vaccs, report := planVaccinationImport(table, cols, format, nil)
+	if len(vaccs) != 1 || vaccs[0].AdministeredAt != "2024-03-15" || vaccs[0].NextDue == nil || *vaccs[0].NextDue != "2027-03-15" {
+		t.Fatalf("vaccs = %+v", vaccs)
+	}
+	if vaccs[0].CostUSD == nil || *vaccs[0].CostUSD != 45.5 {
+		t.Errorf("cost = %v", vaccs[0].CostUSD)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0] != 3 {
+		t.Errorf("duplicates = %v", report.Duplicates)
+	}
+	if len(report.Errors) != 4 {
+		t.Errorf("errors = %+v", report.Errors)
+	}
+}
+
+func TestCSVCellEscaping(t *testing.T) {
+	for _, s := range []string{"=HYPERLINK(\"x\")", "+1", "@SUM(A1)", "plain", "'quoted"} {
+		if got := unescapeCSVCell(escapeCSVCell(s)); got != s {
+			t.Errorf("round trip %q = %q", s, got)
+		}
+	}
+	if escapeCSVCell("=1+1") != "'=1+1" {
+		t.Error("formula should be escaped")
+	}
+	if formatCSVNumber(12.5) != "12.5" || formatCSVNumber(27.557750001) != "27.558" || formatCSVNumber(3) != "3" {
+		t.Error("formatCSVNumber")
+	}
+}
@@ -25,9 +25,10 @@ type CustomOptionsGetResponse struct {
 
 // AddRequest is the JSON body for POST /custom-options
 type CustomOptionsAddRequest struct {
-	OptionType string `json:"option_type"`
-	Value      string `json:"value"`
-	Context    string `json:"context"`
+	OptionType     string `json:"option_type"`
+	Value          string `json:"value"`
+	Context        string `json:"context"`
+	DurationMonths *int   `json:"duration_months,omitempty"` // vaccination only
 }
 
 func (h *CustomOptionsHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -91,6 +92,12 @@ func (h *CustomOptionsHandler) Get(w http.ResponseWriter, r *http.Request) {
 				out.Vaccinations[ctx] = []string{}
 			}
 			out.Vaccinations[ctx] = appendUnique(out.Vaccinations[ctx], row.Value)
+			if row.DurationMonths != nil {
+				if out.VaccinationDurations[ctx] == nil {
+					out.VaccinationDurations[ctx] = map[string]int{}
+				}
+				out.VaccinationDurations[ctx][row.Value] = *row.DurationMonths
+			}
 		}
 	}
 
@@ -141,9 +148,16 @@ func (h *CustomOptionsHandler) Add(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid option_type"}`, http.StatusBadRequest)
 		return
 	}
+	if body.DurationMonths != nil && (body.OptionType != "vaccination" || *body.DurationMonths < 1 || *body.DurationMonths > maxVaccinationDurationMonths) {
+		http.Error(w, `{"error":"invalid duration_months"}`, http.StatusBadRequest)
+		return
+	}
 	ctxVal := body.Context
-	opt := models.UserCustomOption{UserID: u.ID, OptionType: body.OptionType, Value: body.Value, Context: ctxVal}
+	opt := models.UserCustomOption{UserID: u.ID, OptionType: body.OptionType, Value: body.Value, Context: ctxVal, DurationMonths: body.DurationMonths}
 	h.GORM.Where("user_id = ? AND option_type = ? AND value = ? AND context = ?", u.ID, body.OptionType, body.Value, ctxVal).FirstOrCreate(&opt)
+	if body.DurationMonths != nil && (opt.DurationMonths == nil || *opt.DurationMonths != *body.DurationMonths) {
+		h.GORM.Model(&opt).Update("duration_months", body.DurationMonths)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
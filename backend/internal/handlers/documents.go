@@ -16,30 +16,24 @@ import (
 	"github.com/pet-medical/api/internal/middleware"
 	"github.com/pet-medical/api/internal/models"
 	"github.com/pet-medical/api/internal/upload"
+	"github.com/pet-medical/api/internal/webhooks"
 	"gorm.io/gorm"
 )
 
-// DocumentUpdateStore abstracts pet ownership and document name update for tests.
+// DocumentUpdateStore abstracts pet ownership, visit lookup, and document update for tests.
 type DocumentUpdateStore interface {
 	OwnsPet(userID, petID uuid.UUID) bool
-	UpdateName(petID, docID uuid.UUID, name string) (*models.Document, error)
+	VisitBelongsToPet(visitID, petID uuid.UUID) bool
+	// Update applies updates ("name", and "visit_id" when the link changes) and returns the document.
+	Update(petID, docID uuid.UUID, updates map[string]interface{}) (*models.Document, error)
 }
 
 type DocumentsHandler struct {
 	DB                  *gorm.DB
 	UploadDir           string
-	MaxDocumentBytes    int64 // max upload size; 0 = use default 25MB
-	DocumentUpdateStore DocumentUpdateStore // when non-nil, Update uses this instead of DB
-}
-
-func (h *DocumentsHandler) ensurePetOwnership(r *http.Request, petID uuid.UUID) bool {
-	u := middleware.GetUser(r.Context())
-	if u == nil {
-		return false
-	}
-	var count int64
-	h.DB.Model(&models.Pet{}).Where("id = ? AND user_id = ?", petID, u.ID).Count(&count)
-	return count > 0
+	MaxDocumentBytes    int64                // max upload size; 0 = use default 25MB
+	DocumentUpdateStore DocumentUpdateStore  // when non-nil, Update uses this instead of DB
+	Webhooks            *webhooks.Dispatcher // optional; emits document.uploaded
 }
 
 func (h *DocumentsHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -54,8 +48,7 @@ func (h *DocumentsHandler) List(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
 		return
 	}
 	debuglog.Debugf("documents list: pet_id=%s", petID)
@@ -101,8 +94,7 @@ func (h *DocumentsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
 		return
 	}
 	var doc models.Document
@@ -127,8 +119,7 @@ func (h *DocumentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	maxBytes := h.MaxDocumentBytes
@@ -140,6 +131,11 @@ func (h *DocumentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	name := strings.TrimSpace(r.FormValue("name"))
+	visitID, ok := parseVisitLink(r.FormValue("visit_id"), func(id uuid.UUID) bool { return visitBelongsToPet(h.DB, id, petID) })
+	if !ok {
+		http.Error(w, `{"error":"invalid visit_id"}`, http.StatusBadRequest)
+		return
+	}
 	file, header, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, `{"error":"file required"}`, http.StatusBadRequest)
@@ -180,6 +176,7 @@ func (h *DocumentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Name:     name,
 		FilePath: relPath,
 		FileSize: &header.Size,
+		VisitID:  visitID,
 	}
 	if header.Header.Get("Content-Type") != "" {
 		doc.MimeType = &header.Header["Content-Type"][0]
@@ -202,6 +199,7 @@ func (h *DocumentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 			debuglog.Debugf("document update extracted_text: %v", err)
 		}
 	}(doc.ID, absPath)
+	emitPetEvent(h.Webhooks, h.DB, petID, webhooks.EventDocumentUploaded, doc)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(doc)
@@ -231,8 +229,7 @@ func (h *DocumentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	var doc models.Document
@@ -240,6 +237,7 @@ func (h *DocumentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		absPath := filepath.Join(h.UploadDir, filepath.FromSlash(doc.FilePath))
 		_ = os.Remove(absPath)
 	}
+	h.DB.Model(&models.LabPanel{}).Where("document_id = ? AND pet_id = ?", id, petID).Update("document_id", nil)
 	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.Document{})
 	if result.Error != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
@@ -252,15 +250,17 @@ func (h *DocumentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *DocumentsHandler) updateOwnsPet(r *http.Request, petID uuid.UUID) bool {
+// updateOwnsPet authorizes a write to the pet, through the DocumentUpdateStore when one is set (tests).
+func (h *DocumentsHandler) updateOwnsPet(w http.ResponseWriter, r *http.Request, petID uuid.UUID) bool {
 	if h.DocumentUpdateStore != nil {
 		u := middleware.GetUser(r.Context())
-		if u == nil {
+		if u == nil || !h.DocumentUpdateStore.OwnsPet(u.ID, petID) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
 			return false
 		}
-		return h.DocumentUpdateStore.OwnsPet(u.ID, petID)
+		return true
 	}
-	return h.ensurePetOwnership(r, petID)
+	return requirePetRole(w, r, h.DB, petID, models.PetRoleEditor)
 }
 
 func (h *DocumentsHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -276,8 +276,7 @@ func (h *DocumentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.updateOwnsPet(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !h.updateOwnsPet(w, r, petID) {
 		return
 	}
 	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
@@ -285,7 +284,8 @@ func (h *DocumentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var body struct {
-		Name string `json:"name"`
+		Name    string  `json:"name"`
+		VisitID *string `json:"visit_id"` // optional; "" unlinks the document from its visit
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
@@ -296,8 +296,25 @@ func (h *DocumentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"name required"}`, http.StatusBadRequest)
 		return
 	}
+	updates := map[string]interface{}{"name": name}
+	if body.VisitID != nil {
+		belongs := func(visitID uuid.UUID) bool { return visitBelongsToPet(h.DB, visitID, petID) }
+		if h.DocumentUpdateStore != nil {
+			belongs = func(visitID uuid.UUID) bool { return h.DocumentUpdateStore.VisitBelongsToPet(visitID, petID) }
+		}
+		visitID, ok := parseVisitLink(*body.VisitID, belongs)
+		if !ok {
+			http.Error(w, `{"error":"invalid visit_id"}`, http.StatusBadRequest)
+			return
+		}
+		if visitID == nil {
+			updates["visit_id"] = nil
+		} else {
+			updates["visit_id"] = *visitID
+		}
+	}
 	if h.DocumentUpdateStore != nil {
-		doc, err := h.DocumentUpdateStore.UpdateName(petID, id, name)
+		doc, err := h.DocumentUpdateStore.Update(petID, id, updates)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
@@ -310,7 +327,7 @@ func (h *DocumentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(doc)
 		return
 	}
-	result := h.DB.Model(&models.Document{}).Where("id = ? AND pet_id = ?", id, petID).Update("name", name)
+	result := h.DB.Model(&models.Document{}).Where("id = ? AND pet_id = ?", id, petID).Updates(updates)
 	if result.Error != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
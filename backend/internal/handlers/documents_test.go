@@ -18,23 +18,37 @@ import (
 type mockDocumentUpdateStore struct {
 	ownsPet  bool
 	docs     map[string]*models.Document
+	visits   map[uuid.UUID]uuid.UUID // visit ID -> pet ID
 }
 
 func newMockDocumentUpdateStore(ownsPet bool) *mockDocumentUpdateStore {
-	return &mockDocumentUpdateStore{ownsPet: ownsPet, docs: make(map[string]*models.Document)}
+	return &mockDocumentUpdateStore{ownsPet: ownsPet, docs: make(map[string]*models.Document), visits: make(map[uuid.UUID]uuid.UUID)}
 }
 
 func (m *mockDocumentUpdateStore) OwnsPet(userID, petID uuid.UUID) bool {
 	return m.ownsPet
 }
 
-func (m *mockDocumentUpdateStore) UpdateName(petID, docID uuid.UUID, name string) (*models.Document, error) {
+func (m *mockDocumentUpdateStore) VisitBelongsToPet(visitID, petID uuid.UUID) bool {
+	owner, ok := m.visits[visitID]
+	return ok && owner == petID
+}
+
+func (m *mockDocumentUpdateStore) Update(petID, docID uuid.UUID, updates map[string]interface{}) (*models.Document, error) {
 	key := petID.String() + "/" + docID.String()
 	doc, ok := m.docs[key]
 	if !ok {
 		return nil, gorm.ErrRecordNotFound
 	}
-	doc.Name = name
+	doc.Name = updates["name"].(string)
+	if v, ok := updates["visit_id"]; ok {
+		if v == nil {
+			doc.VisitID = nil
+		} else {
+			id := v.(uuid.UUID)
+			doc.VisitID = &id
+		}
+	}
 	return doc, nil
 }
 
@@ -107,3 +121,37 @@ func TestDocuments_Update_NoOwnershipReturns404(t *testing.T) {
 		t.Errorf("expected 404 when not owner, got %d", rec.Code)
 	}
 }
+
+func TestDocuments_Update_VisitLink(t *testing.T) {
+	userID, petID, docID := uuid.New(), uuid.New(), uuid.New()
+	ownVisit, otherVisit := uuid.New(), uuid.New()
+	mock := newMockDocumentUpdateStore(true)
+	mock.visits[ownVisit] = petID
+	mock.visits[otherVisit] = uuid.New()
+	doc := &models.Document{ID: docID, PetID: petID, Name: "old", FilePath: "/x", CreatedAt: time.Now()}
+	mock.docs[petID.String()+"/"+docID.String()] = doc
+	h := &DocumentsHandler{DocumentUpdateStore: mock}
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/pets/"+petID.String()+"/documents/"+docID.String(), bytes.NewBufferString(body))
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserInfo{ID: userID, DisplayName: "u", Role: "user"}))
+		req = mux.SetURLVars(req, map[string]string{"petId": petID.String(), "id": docID.String()})
+		rec := httptest.NewRecorder()
+		h.Update(rec, req)
+		return rec
+	}
+
+	if rec := update(`{"name":"x","visit_id":"` + ownVisit.String() + `"}`); rec.Code != http.StatusOK || doc.VisitID == nil || *doc.VisitID != ownVisit {
+		t.Fatalf("link own visit: %d, visit_id = %v", rec.Code, doc.VisitID)
+	}
+	if rec := update(`{"name":"x"}`); rec.Code != http.StatusOK || doc.VisitID == nil {
+		t.Errorf("omitted visit_id must keep the link: %d, visit_id = %v", rec.Code, doc.VisitID)
+	}
+	for _, bad := range []string{otherVisit.String(), uuid.NewString(), "not-a-uuid"} {
+		if rec := update(`{"name":"x","visit_id":"` + bad + `"}`); rec.Code != http.StatusBadRequest || *doc.VisitID != ownVisit {
+			t.Errorf("visit_id %s: %d, visit_id = %v", bad, rec.Code, doc.VisitID)
+		}
+	}
+	if rec := update(`{"name":"x","visit_id":""}`); rec.Code != http.StatusOK || doc.VisitID != nil {
+		t.Errorf("unlink: %d, visit_id = %v", rec.Code, doc.VisitID)
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// DueHandler reports upcoming and overdue care across all of the caller's pets, including pets shared with them.
+type DueHandler struct {
+	DB *gorm.DB
+}
+
+// Due item types and statuses.
+const (
+	DueTypeVaccination   = "vaccination"
+	DueTypeVisitFollowUp = "visit_follow_up"
+
+	DueStatusOverdue  = "overdue"
+	DueStatusDue      = "due"      // within ?days= of today
+	DueStatusUpcoming = "upcoming" // after ?days= but within ?horizon=
+)
+
+const (
+	defaultDueDays    = 30
+	defaultDueHorizon = 180
+	maxDueHorizon     = 3650
+)
+
+// DueItem is one dated follow-up. ID is the vaccination or visit it comes from.
+type DueItem struct {
+	Type      string    `json:"type"`
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	DueDate   string    `json:"due_date"`
+	Status    string    `json:"status"`
+	DaysUntil int       `json:"days_until"` // negative when overdue
+}
+
+// PetDue groups a pet's due items, soonest first.
+type PetDue struct {
+	PetID   uuid.UUID `json:"pet_id"`
+	PetName string    `json:"pet_name"`
+	Items   []DueItem `json:"items"`
+}
+
+// DueResponse is the GET /due response. Pets are ordered by their soonest item.
+type DueResponse struct {
+	Today    string   `json:"today"`
+	Days     int      `json:"days"`
+	Horizon  int      `json:"horizon"`
+	Overdue  int      `json:"overdue"`
+	Due      int      `json:"due"`
+	Upcoming int      `json:"upcoming"`
+	Pets     []PetDue `json:"pets"`
+}
+
+// List handles GET /due?days=30&horizon=180. It runs one query each for pets, vaccinations, and visits.
+func (h *DueHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	days, horizon := defaultDueDays, defaultDueHorizon
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxDueHorizon {
+			http.Error(w, `{"error":"invalid days"}`, http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	if s := r.URL.Query().Get("horizon"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxDueHorizon {
+			http.Error(w, `{"error":"invalid horizon"}`, http.StatusBadRequest)
+			return
+		}
+		horizon = n
+	}
+	if horizon < days {
+		horizon = days
+	}
+
+	var pets []models.Pet
+	if err := h.DB.Select("id, name").Where("id IN (?)", models.AccessiblePetIDs(h.DB, u.ID)).Order("name").Find(&pets).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	petIDs := make([]uuid.UUID, len(pets))
+	for i, p := range pets {
+		petIDs[i] = p.ID
+	}
+	var vaccs []models.Vaccination
+	var visits []models.VetVisit
+	if len(petIDs) > 0 {
+		if err := h.DB.Where("pet_id IN ?", petIDs).Order("administered_at DESC, created_at DESC").Find(&vaccs).Error; err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		if err := h.DB.Where("pet_id IN ?", petIDs).Order("visit_date DESC").Find(&visits).Error; err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	today := time.Now().Format(dateLayout)
+	out := buildDueResponse(today, days, horizon, pets, vaccs, visits)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// buildDueResponse classifies follow-ups relative to today. Only the latest dose of each vaccine (by name, per pet)
+// counts, so a booster supersedes the previous next_due. A visit follow-up is dropped once a later visit happens on or
+// after the follow-up date. vaccs and visits must be sorted newest first.
+func buildDueResponse(today string, days, horizon int, pets []models.Pet, vaccs []models.Vaccination, visits []models.VetVisit) DueResponse {
+	out := DueResponse{Today: today, Days: days, Horizon: horizon, Pets: []PetDue{}}
+	todayT, _ := time.Parse(dateLayout, today)
+	byPet := make(map[uuid.UUID][]DueItem)
+
+	add := func(petID uuid.UUID, item DueItem) {
+		due, err := time.Parse(dateLayout, item.DueDate)
+		if err != nil {
+			return
+		}
+		item.DaysUntil = int(due.Sub(todayT).Hours() / 24)
+		switch {
+		case item.DaysUntil < 0:
+			item.Status = DueStatusOverdue
+			out.Overdue++
+		case item.DaysUntil <= days:
+			item.Status = DueStatusDue
+			out.Due++
+		case item.DaysUntil <= horizon:
+			item.Status = DueStatusUpcoming
+			out.Upcoming++
+		default:
+			return
+		}
+		byPet[petID] = append(byPet[petID], item)
+	}
+
+	for _, v := range latestDoses(vaccs) {
+		if v.NextDue == nil || *v.NextDue == "" {
+			continue
+		}
+		add(v.PetID, DueItem{Type: DueTypeVaccination, ID: v.ID, Title: v.Name, DueDate: *v.NextDue})
+	}
+
+	latestVisit := make(map[uuid.UUID]string)
+	for _, v := range visits {
+		if v.VisitDate > latestVisit[v.PetID] {
+			latestVisit[v.PetID] = v.VisitDate
+		}
+	}
+	for _, v := range visits {
+		if v.FollowUpDate == nil || *v.FollowUpDate == "" {
+			continue
+		}
+		if last := latestVisit[v.PetID]; last > v.VisitDate && last >= *v.FollowUpDate {
+			continue
+		}
+		title := "Follow-up"
+		if v.Reason != nil && strings.TrimSpace(*v.Reason) != "" {
+			title = "Follow-up: " + strings.TrimSpace(*v.Reason)
+		}
+		add(v.PetID, DueItem{Type: DueTypeVisitFollowUp, ID: v.ID, Title: title, DueDate: *v.FollowUpDate})
+	}
+
+	for _, p := range pets {
+		items := byPet[p.ID]
+		if len(items) == 0 {
+			continue
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].DueDate != items[j].DueDate {
+				return items[i].DueDate < items[j].DueDate
+			}
+			return items[i].Title < items[j].Title
+		})
+		out.Pets = append(out.Pets, PetDue{PetID: p.ID, PetName: p.Name, Items: items})
+	}
+	sort.SliceStable(out.Pets, func(i, j int) bool {
+		return out.Pets[i].Items[0].DueDate < out.Pets[j].Items[0].DueDate
+	})
+	return out
+}
+
+// latestDoses keeps only the most recent vaccination of each vaccine (by normalized name) per pet. vaccs must be
+// sorted newest first; order is preserved.
+func latestDoses(vaccs []models.Vaccination) []models.Vaccination {
+	var out []models.Vaccination
+	seen := make(map[string]bool)
+	for _, v := range vaccs {
+		key := v.PetID.String() + "|" + normalizeOptionValue(v.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestBuildDueResponse(t *testing.T) {
+	rex, mia := models.Pet{ID: uuid.New(), Name: "Rex"}, models.Pet{ID: uuid.New(), Name: "Mia"}
+	// Newest first, as the handler queries them.
+	vaccs := []models.Vaccination{
+		{ID: uuid.New(), PetID: rex.ID, Name: "Rabies", AdministeredAt: "2024-06-01", NextDue: strPtr("2025-06-01")},
+		{ID: uuid.New(), PetID: rex.ID, Name: "rabies", AdministeredAt: "2023-06-01", NextDue: strPtr("2024-06-01")}, // superseded
+		{ID: uuid.New(), PetID: rex.ID, Name: "Bordetella", AdministeredAt: "2024-01-01", NextDue: strPtr("2025-01-10")},
+		{ID: uuid.New(), PetID: mia.ID, Name: "FVRCP", AdministeredAt: "2022-01-01", NextDue: strPtr("2024-12-20")},
+		{ID: uuid.New(), PetID: mia.ID, Name: "FeLV", AdministeredAt: "2024-01-01", NextDue: strPtr("2030-01-01")}, // beyond horizon
+	}
+	visits := []models.VetVisit{
+		{ID: uuid.New(), PetID: mia.ID, VisitDate: "2024-12-01", FollowUpDate: strPtr("2025-01-05"), Reason: strPtr("Dental")},
+		{ID: uuid.New(), PetID: rex.ID, VisitDate: "2024-11-01"},
+		{ID: uuid.New(), PetID: rex.ID, VisitDate: "2024-10-01", FollowUpDate: strPtr("2024-10-20")}, // done at the 11-01 visit
+	}
+	out := buildDueResponse("2025-01-01", 30, 180, []models.Pet{mia, rex}, vaccs, visits)
+
+	if out.Overdue != 1 || out.Due != 2 || out.Upcoming != 1 {
+		t.Errorf("counts overdue=%d due=%d upcoming=%d, want 1/2/1", out.Overdue, out.Due, out.Upcoming)
+	}
+	if len(out.Pets) != 2 || out.Pets[0].PetName != "Mia" {
+		t.Fatalf("pets should be ordered by soonest item, got %+v", out.Pets)
+	}
+	mItems := out.Pets[0].Items
+	if len(mItems) != 2 || mItems[0].Title != "FVRCP" || mItems[0].Status != DueStatusOverdue || mItems[0].DaysUntil != -12 {
+		t.Errorf("unexpected Mia items %+v", mItems)
+	}
+	if mItems[1].Type != DueTypeVisitFollowUp || mItems[1].Title != "Follow-up: Dental" || mItems[1].Status != DueStatusDue {
+		t.Errorf("unexpected follow-up %+v", mItems[1])
+	}
+	rItems := out.Pets[1].Items
+	if len(rItems) != 2 || rItems[0].Title != "Bordetella" || rItems[1].Title != "Rabies" || rItems[1].Status != DueStatusUpcoming {
+		t.Errorf("unexpected Rex items %+v", rItems)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/backup"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// ExportHandler streams a full backup of the caller's account (see package backup for the archive layout). It covers
+// the pets the caller created; pets shared with them belong to someone else's export.
+type ExportHandler struct {
+	DB        *gorm.DB
+	UploadDir string
+}
+
+// Export handles GET /export. The response is streamed, so a failure part-way through truncates the ZIP (the
+// manifest is written last, which lets import detect it) rather than returning an error status.
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var user models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&user).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var pets []models.Pet
+	if err := h.DB.Where("user_id = ?", u.ID).Order("created_at").Find(&pets).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	petIDs := make([]uuid.UUID, len(pets))
+	for i, p := range pets {
+		petIDs[i] = p.ID
+	}
+
+	filename := "pet-medical-export-" + time.Now().Format(dateLayout) + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Cache-Control", "no-store")
+	if err := h.write(backup.NewWriter(w, u.ID), user, pets, petIDs); err != nil {
+		log.Printf("[EXPORT] user_id=%s: %v", u.ID, err)
+	}
+}
+
+func (h *ExportHandler) write(bw *backup.Writer, user models.User, pets []models.Pet, petIDs []uuid.UUID) error {
+	settings := SettingsDTO{
+		WeightUnit: user.WeightUnit, Currency: user.Currency, Language: user.Language,
+		Email: user.Email, DisplayName: user.DisplayName,
+		ReminderEmails: &user.ReminderEmails, ReminderLeadDays: &user.ReminderLeadDays,
+	}
+	if err := bw.WriteJSON(backup.FileSettings, settings, 1); err != nil {
+		return err
+	}
+	options := []models.UserCustomOption{}
+	if err := h.DB.Where("user_id = ?", user.ID).Order("option_type, context, value").Find(&options).Error; err != nil {
+		return err
+	}
+	if err := bw.WriteJSON(backup.FileCustomOptions, options, len(options)); err != nil {
+		return err
+	}
+	clinics := []models.Clinic{}
+	if err := h.DB.Where("user_id = ?", user.ID).Order("name").Find(&clinics).Error; err != nil {
+		return err
+	}
+	if err := bw.WriteJSON(backup.FileClinics, clinics, len(clinics)); err != nil {
+		return err
+	}
+	if err := bw.WriteJSON(backup.FilePets, pets, len(pets)); err != nil {
+		return err
+	}
+
+	var (
+		visits       = []models.VetVisit{}
+		vaccinations = []models.Vaccination{}
+		weights      = []models.WeightEntry{}
+		documents    = []models.Document{}
+		photos       = []models.PetPhoto{}
+		medications  = []models.Medication{}
+		doses        = []models.MedicationDose{}
+		allergies    = []models.Allergy{}
+		conditions   = []models.ChronicCondition{}
+		panels       = []models.LabPanel{}
+		analytes     = []models.LabAnalyte{}
+	)
+	petRecords := []struct {
+		path  string
+		order string
+		dest  any
+		count func() int
+	}{
+		{backup.FileVisits, "visit_date", &visits, func() int { return len(visits) }},
+		{backup.FileVaccinations, "administered_at", &vaccinations, func() int { return len(vaccinations) }},
+		{backup.FileWeights, "measured_at", &weights, func() int { return len(weights) }},
+		{backup.FileDocuments, "created_at", &documents, func() int { return len(documents) }},
+		{backup.FilePhotos, "display_order", &photos, func() int { return len(photos) }},
+		{backup.FileMedications, "start_date", &medications, func() int { return len(medications) }},
+		{backup.FileMedicationDoses, "given_at", &doses, func() int { return len(doses) }},
+		{backup.FileAllergies, "created_at", &allergies, func() int { return len(allergies) }},
+		{backup.FileConditions, "created_at", &conditions, func() int { return len(conditions) }},
+		{backup.FileLabPanels, "panel_date", &panels, func() int { return len(panels) }},
+		{backup.FileLabAnalytes, "created_at", &analytes, func() int { return len(analytes) }},
+	}
+	for _, rec := range petRecords {
+		if len(petIDs) > 0 {
+			if err := h.DB.Where("pet_id IN ?", petIDs).Order("pet_id, " + rec.order).Find(rec.dest).Error; err != nil {
+				return err
+			}
+		}
+		if err := bw.WriteJSON(rec.path, rec.dest, rec.count()); err != nil {
+			return err
+		}
+	}
+
+	var uploads []string
+	for _, d := range documents {
+		uploads = append(uploads, d.FilePath)
+	}
+	for _, p := range photos {
+		uploads = append(uploads, p.FilePath)
+	}
+	for _, rel := range uploads {
+		if err := h.writeUpload(bw, rel); err != nil {
+			return err
+		}
+	}
+	return bw.Close()
+}
+
+// writeUpload copies one file from UploadDir; unreadable files are listed in the manifest instead.
+func (h *ExportHandler) writeUpload(bw *backup.Writer, rel string) error {
+	native := filepath.FromSlash(rel)
+	if h.UploadDir == "" || rel == "" || !filepath.IsLocal(native) {
+		bw.Missing(rel)
+		return nil
+	}
+	f, err := os.Open(filepath.Join(h.UploadDir, native))
+	if err != nil {
+		bw.Missing(rel)
+		return nil
+	}
+	defer f.Close()
+	return bw.WriteFile(filepath.ToSlash(rel), f)
+}
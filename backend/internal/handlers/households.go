@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// HouseholdsHandler manages households (groups of users that own pets together), their members, and moving pets
+// between a personal account and a household.
+type HouseholdsHandler struct {
+	DB *gorm.DB
+}
+
+// HouseholdSummary is one entry of GET /households: the household and the caller's role in it.
+type HouseholdSummary struct {
+	models.Household
+	Role string `json:"role"`
+}
+
+// HouseholdMemberInfo is one entry of HouseholdDetail.Members.
+type HouseholdMemberInfo struct {
+	UserID      uuid.UUID `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+}
+
+// HouseholdDetail is the GET /households/{id} response.
+type HouseholdDetail struct {
+	models.Household
+	Role    string                `json:"role"`
+	Members []HouseholdMemberInfo `json:"members"`
+}
+
+const maxHouseholdNameLen = 100
+
+var errLastHouseholdAdmin = errors.New("household must keep at least one admin")
+
+func validHouseholdRole(role string) bool {
+	return role == models.HouseholdRoleAdmin || role == models.HouseholdRoleMember
+}
+
+// requireHouseholdRole returns true when the caller is in the household with at least need (admin > member);
+// otherwise it writes 404 (not a member) or 403 (not an admin).
+func requireHouseholdRole(w http.ResponseWriter, r *http.Request, db *gorm.DB, householdID uuid.UUID, need string) bool {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return false
+	}
+	role := householdRole(db, householdID, u.ID)
+	if role == "" {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return false
+	}
+	if need == models.HouseholdRoleAdmin && role != models.HouseholdRoleAdmin {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// adminCount returns the number of admins in the household.
+func adminCount(db *gorm.DB, householdID uuid.UUID) int64 {
+	var n int64
+	db.Model(&models.HouseholdMember{}).Where("household_id = ? AND role = ?", householdID, models.HouseholdRoleAdmin).Count(&n)
+	return n
+}
+
+func (h *HouseholdsHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var list []HouseholdSummary
+	err := h.DB.Table("households").
+		Select("households.*, household_members.role AS role").
+		Joins("JOIN household_members ON household_members.household_id = households.id").
+		Where("household_members.user_id = ?", u.ID).
+		Order("households.name").
+		Scan(&list).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []HouseholdSummary{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Create makes a household with the caller as its first admin.
+func (h *HouseholdsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" || len(name) > maxHouseholdNameLen {
+		http.Error(w, `{"error":"name required (max 100 characters)"}`, http.StatusBadRequest)
+		return
+	}
+	hh := models.Household{Name: name, CreatedBy: u.ID}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hh).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.HouseholdMember{HouseholdID: hh.ID, UserID: u.ID, Role: models.HouseholdRoleAdmin}).Error
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(HouseholdSummary{Household: hh, Role: models.HouseholdRoleAdmin})
+}
+
+// Get returns the household with its members. Members only.
+func (h *HouseholdsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requireHouseholdRole(w, r, h.DB, id, models.HouseholdRoleMember) {
+		return
+	}
+	out := HouseholdDetail{Role: householdRole(h.DB, id, u.ID), Members: []HouseholdMemberInfo{}}
+	if err := h.DB.Where("id = ?", id).First(&out.Household).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	err = h.DB.Table("household_members").
+		Select("household_members.user_id, household_members.role, users.display_name, users.email").
+		Joins("JOIN users ON users.id = household_members.user_id").
+		Where("household_members.household_id = ?", id).
+		Order("household_members.created_at").
+		Scan(&out.Members).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// Update renames the household. Admin only.
+func (h *HouseholdsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requireHouseholdRole(w, r, h.DB, id, models.HouseholdRoleAdmin) {
+		return
+	}
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" || len(name) > maxHouseholdNameLen {
+		http.Error(w, `{"error":"name required (max 100 characters)"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.Household{}).Where("id = ?", id).Updates(map[string]interface{}{"name": name, "updated_at": time.Now()})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	var hh models.Household
+	h.DB.Where("id = ?", id).First(&hh)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HouseholdSummary{Household: hh, Role: models.HouseholdRoleAdmin})
+}
+
+// Delete removes the household. Its pets become personal pets of their creators again. Admin only.
+func (h *HouseholdsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requireHouseholdRole(w, r, h.DB, id, models.HouseholdRoleAdmin) {
+		return
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Pet{}).Where("household_id = ?", id).Update("household_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("household_id = ?", id).Delete(&models.HouseholdMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Household{}).Error
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddMember adds an existing user, found by email, to the household. Role defaults to member. Admin only.
+func (h *HouseholdsHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requireHouseholdRole(w, r, h.DB, id, models.HouseholdRoleAdmin) {
+		return
+	}
+	var body struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	if body.Role == "" {
+		body.Role = models.HouseholdRoleMember
+	}
+	if !validHouseholdRole(body.Role) {
+		http.Error(w, `{"error":"role must be admin or member"}`, http.StatusBadRequest)
+		return
+	}
+	var user models.User
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if email == "" || h.DB.Where("LOWER(email) = ?", email).First(&user).Error != nil {
+		http.Error(w, `{"error":"no user with that email"}`, http.StatusBadRequest)
+		return
+	}
+	if householdRole(h.DB, id, user.ID) != "" {
+		http.Error(w, `{"error":"user is already a member"}`, http.StatusConflict)
+		return
+	}
+	m := models.HouseholdMember{HouseholdID: id, UserID: user.ID, Role: body.Role}
+	if err := h.DB.Create(&m).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(HouseholdMemberInfo{UserID: user.ID, DisplayName: user.DisplayName, Email: user.Email, Role: m.Role})
+}
+
+// UpdateMember changes a member's role. The last admin cannot be demoted. Admin only.
+func (h *HouseholdsHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	userID, err := uuid.Parse(vars["userId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid user id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requireHouseholdRole(w, r, h.DB, id, models.HouseholdRoleAdmin) {
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	if !validHouseholdRole(body.Role) {
+		http.Error(w, `{"error":"role must be admin or member"}`, http.StatusBadRequest)
+		return
+	}
+	current := householdRole(h.DB, id, userID)
+	if current == "" {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if current == models.HouseholdRoleAdmin && body.Role != models.HouseholdRoleAdmin && adminCount(h.DB, id) <= 1 {
+		http.Error(w, `{"error":"`+errLastHouseholdAdmin.Error()+`"}`, http.StatusConflict)
+		return
+	}
+	err = h.DB.Model(&models.HouseholdMember{}).Where("household_id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{"role": body.Role, "updated_at": time.Now()}).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	var user models.User
+	h.DB.Select("id, display_name, email").Where("id = ?", userID).First(&user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HouseholdMemberInfo{UserID: userID, DisplayName: user.DisplayName, Email: user.Email, Role: body.Role})
+}
+
+// RemoveMember takes a user out of the household. Admins can remove anyone; members can remove themselves. The last
+// admin cannot leave; delete the household instead. Pets the removed user created stay in the household.
+func (h *HouseholdsHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	userID, err := uuid.Parse(vars["userId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid user id"}`, http.StatusBadRequest)
+		return
+	}
+	need := models.HouseholdRoleAdmin
+	if userID == u.ID {
+		need = models.HouseholdRoleMember
+	}
+	if !requireHouseholdRole(w, r, h.DB, id, need) {
+		return
+	}
+	current := householdRole(h.DB, id, userID)
+	if current == "" {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if current == models.HouseholdRoleAdmin && adminCount(h.DB, id) <= 1 {
+		http.Error(w, `{"error":"`+errLastHouseholdAdmin.Error()+`"}`, http.StatusConflict)
+		return
+	}
+	if err := h.DB.Where("household_id = ? AND user_id = ?", id, userID).Delete(&models.HouseholdMember{}).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TransferPet handles POST /pets/{id}/transfer with {"household_id": "<id>"} to move a pet into a household the caller
+// belongs to, or {"household_id": null} to make it the caller's personal pet. Requires owner role on the pet. When
+// the caller is not the pet's creator, the creator is kept on as an owner member so they cannot be locked out.
+func (h *HouseholdsHandler) TransferPet(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	var body struct {
+		HouseholdID *uuid.UUID `json:"household_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	updates := map[string]interface{}{"household_id": body.HouseholdID, "updated_at": time.Now()}
+	if body.HouseholdID != nil {
+		if householdRole(h.DB, *body.HouseholdID, u.ID) == "" {
+			http.Error(w, `{"error":"invalid household_id"}`, http.StatusBadRequest)
+			return
+		}
+	} else {
+		// Back to a personal account: the caller becomes the pet's creator.
+		updates["user_id"] = u.ID
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var keep *models.PetMembership
+		if body.HouseholdID == nil {
+			keep = previousCreatorMembership(petID, u.ID, petCreatorID(tx, petID))
+		}
+		if err := tx.Model(&models.Pet{}).Where("id = ?", petID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if body.HouseholdID == nil {
+			if err := tx.Where("pet_id = ? AND user_id = ?", petID, u.ID).Delete(&models.PetMembership{}).Error; err != nil {
+				return err
+			}
+		}
+		if keep != nil {
+			if err := tx.Where("pet_id = ? AND user_id = ?", petID, keep.UserID).Delete(&models.PetMembership{}).Error; err != nil {
+				return err
+			}
+			return tx.Create(keep).Error
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	var pet models.Pet
+	h.DB.Where("id = ?", petID).First(&pet)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PetDetail{Pet: pet, Role: models.PetRoleOwner})
+}
+
+// previousCreatorMembership is the owner membership that keeps a pet's creator in control when another owner
+// (an invited owner or a household admin) takes the pet over as their personal pet; nil when the caller already is
+// the creator.
+func previousCreatorMembership(petID, callerID, creatorID uuid.UUID) *models.PetMembership {
+	if creatorID == uuid.Nil || creatorID == callerID {
+		return nil
+	}
+	return &models.PetMembership{PetID: petID, UserID: creatorID, Role: models.PetRoleOwner}
+}
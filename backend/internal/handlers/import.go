@@ -0,0 +1,672 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/backup"
+	"github.com/pet-medical/api/internal/debuglog"
+	"github.com/pet-medical/api/internal/extract"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/upload"
+	"gorm.io/gorm"
+)
+
+const defaultMaxImportBytes = 1 << 30 // 1 GB
+
+// ImportHandler restores an archive in the export format (package backup) into the caller's account. Every record
+// gets a new ID, so importing the same archive twice, or into the instance it came from, never collides; references
+// between records are remapped to the new IDs.
+type ImportHandler struct {
+	DB               *gorm.DB
+	UploadDir        string
+	MaxArchiveBytes  int64 // default 1 GB
+	MaxDocumentBytes int64 // per file; same limits as regular uploads
+	MaxPhotoBytes    int64
+}
+
+// ImportIssue is one record that failed validation. Index is the record's position in File.
+type ImportIssue struct {
+	File  string `json:"file"`
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ImportReport is the response of POST /import. Created and Reused count records per entity file name (e.g. "pets").
+// Reused are clinics matched by name and custom options the account already has.
+type ImportReport struct {
+	DryRun          bool           `json:"dry_run"`
+	SchemaVersion   int            `json:"schema_version"`
+	ExportedAt      time.Time      `json:"exported_at"`
+	Created         map[string]int `json:"created"`
+	Reused          map[string]int `json:"reused"`
+	Files           int            `json:"files"`
+	SettingsApplied bool           `json:"settings_applied"`
+	Warnings        []string       `json:"warnings"`
+	Errors          []ImportIssue  `json:"errors"`
+}
+
+// importFile is an upload to copy from the archive to a new path under UploadDir.
+type importFile struct {
+	src, dst string
+}
+
+// importPlan is everything an import will write, with new IDs already assigned.
+type importPlan struct {
+	settings     map[string]interface{}
+	options      []models.UserCustomOption
+	clinics      []models.Clinic
+	pets         []models.Pet
+	visits       []models.VetVisit
+	vaccinations []models.Vaccination
+	weights      []models.WeightEntry
+	documents    []models.Document
+	photos       []models.PetPhoto
+	medications  []models.Medication
+	doses        []models.MedicationDose
+	allergies    []models.Allergy
+	conditions   []models.ChronicCondition
+	panels       []models.LabPanel
+	analytes     []models.LabAnalyte
+	files        []importFile
+	report       ImportReport
+}
+
+// Import handles POST /import (multipart field "file"; ?dry_run=true to only report). The archive is rejected as a
+// whole if its manifest or checksums do not verify or any record is invalid; otherwise files are copied and all
+// records are created in one transaction. Responds 201 with the report, 200 for a dry run, or 422 with the errors.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	maxBytes := h.MaxArchiveBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxImportBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+1<<20)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, `{"error":"invalid multipart or archive too large"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, `{"error":"file required"}`, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBytes {
+		http.Error(w, `{"error":"error.upload_too_large"}`, http.StatusRequestEntityTooLarge)
+		return
+	}
+	limits := backup.Limits{MaxFileBytes: max(h.MaxDocumentBytes, h.MaxPhotoBytes), MaxTotalBytes: 2 * maxBytes}
+	archive, err := backup.Open(file, header.Size, limits)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var clinics []models.Clinic
+	var options []models.UserCustomOption
+	if h.DB.Where("user_id = ?", u.ID).Find(&clinics).Error != nil || h.DB.Where("user_id = ?", u.ID).Find(&options).Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	plan, err := h.buildPlan(archive, u.ID, clinics, options)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	plan.report.DryRun = r.URL.Query().Get("dry_run") == "true" || r.URL.Query().Get("dry_run") == "1"
+	status := http.StatusCreated
+	switch {
+	case len(plan.report.Errors) > 0:
+		status = http.StatusUnprocessableEntity
+	case plan.report.DryRun:
+		status = http.StatusOK
+	default:
+		if err := h.apply(archive, plan, u.ID); err != nil {
+			log.Printf("[IMPORT] user_id=%s: %v", u.ID, err)
+			http.Error(w, `{"error":"import failed"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(plan.report)
+}
+
+// apply copies the files and then creates every record in one transaction. Copied files are removed if anything fails.
+func (h *ImportHandler) apply(archive *backup.Archive, plan *importPlan, userID uuid.UUID) (err error) {
+	var written []string
+	defer func() {
+		if err != nil {
+			for _, p := range written {
+				os.Remove(p)
+			}
+		}
+	}()
+	for _, f := range plan.files {
+		rc, _, ferr := archive.File(f.src)
+		if ferr != nil {
+			return ferr
+		}
+		abs := filepath.Join(h.UploadDir, filepath.FromSlash(f.dst))
+		ferr = saveUpload(rc, abs)
+		rc.Close()
+		if ferr != nil {
+			return ferr
+		}
+		written = append(written, abs)
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		batches := []interface{}{
+			&plan.clinics, &plan.options, &plan.pets, &plan.visits, &plan.vaccinations, &plan.weights, &plan.documents,
+			&plan.photos, &plan.medications, &plan.doses, &plan.allergies, &plan.conditions, &plan.panels, &plan.analytes,
+		}
+		lengths := []int{
+			len(plan.clinics), len(plan.options), len(plan.pets), len(plan.visits), len(plan.vaccinations), len(plan.weights),
+			len(plan.documents), len(plan.photos), len(plan.medications), len(plan.doses), len(plan.allergies),
+			len(plan.conditions), len(plan.panels), len(plan.analytes),
+		}
+		for i, b := range batches {
+			if lengths[i] == 0 {
+				continue
+			}
+			if err := tx.CreateInBatches(b, 200).Error; err != nil {
+				return err
+			}
+		}
+		if plan.settings != nil {
+			plan.settings["updated_at"] = time.Now()
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(plan.settings).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Documents carry no extracted text in the archive; extract it again for search, as on upload.
+	for _, d := range plan.documents {
+		go func(docID uuid.UUID, absPath string) {
+			text, err := extract.ExtractText(absPath)
+			if err != nil || text == "" {
+				debuglog.Debugf("import extract text: %v", err)
+				return
+			}
+			h.DB.Model(&models.Document{}).Where("id = ?", docID).Update("extracted_text", text)
+		}(d.ID, filepath.Join(h.UploadDir, filepath.FromSlash(d.FilePath)))
+	}
+	return nil
+}
+
+// buildPlan reads and validates every entity file, assigns new IDs, and remaps references. Invalid records are
+// reported in plan.report.Errors; the returned error is only for files that cannot be decoded at all.
+func (h *ImportHandler) buildPlan(archive *backup.Archive, userID uuid.UUID, existingClinics []models.Clinic, existingOptions []models.UserCustomOption) (*importPlan, error) {
+	p := &importPlan{report: ImportReport{
+		SchemaVersion: archive.Manifest.SchemaVersion,
+		ExportedAt:    archive.Manifest.ExportedAt,
+		Created:       map[string]int{},
+		Reused:        map[string]int{},
+		Warnings:      []string{},
+		Errors:        []ImportIssue{},
+	}}
+	var (
+		settings     *SettingsDTO
+		options      []models.UserCustomOption
+		clinics      []models.Clinic
+		pets         []models.Pet
+		visits       []models.VetVisit
+		vaccinations []models.Vaccination
+		weights      []models.WeightEntry
+		documents    []models.Document
+		photos       []models.PetPhoto
+		medications  []models.Medication
+		doses        []models.MedicationDose
+		allergies    []models.Allergy
+		conditions   []models.ChronicCondition
+		panels       []models.LabPanel
+		analytes     []models.LabAnalyte
+	)
+	files := []struct {
+		path string
+		dest interface{}
+	}{
+		{backup.FileSettings, &settings}, {backup.FileCustomOptions, &options}, {backup.FileClinics, &clinics},
+		{backup.FilePets, &pets}, {backup.FileVisits, &visits}, {backup.FileVaccinations, &vaccinations},
+		{backup.FileWeights, &weights}, {backup.FileDocuments, &documents}, {backup.FilePhotos, &photos},
+		{backup.FileMedications, &medications}, {backup.FileMedicationDoses, &doses}, {backup.FileAllergies, &allergies},
+		{backup.FileConditions, &conditions}, {backup.FileLabPanels, &panels}, {backup.FileLabAnalytes, &analytes},
+	}
+	for _, f := range files {
+		if _, err := archive.ReadJSON(f.path, f.dest); err != nil {
+			return nil, err
+		}
+	}
+	fail := func(file string, i int, err error) {
+		p.report.Errors = append(p.report.Errors, ImportIssue{File: file, Index: i, Error: err.Error()})
+	}
+	created := func(file string) { p.report.Created[entityName(file)]++ }
+
+	if settings != nil {
+		p.settings = importSettings(*settings)
+		p.report.SettingsApplied = p.settings != nil
+		if p.settings == nil {
+			p.report.Warnings = append(p.report.Warnings, "settings.json has invalid values and was ignored")
+		}
+	}
+
+	for i, o := range options {
+		o.OptionType = strings.TrimSpace(strings.ToLower(o.OptionType))
+		o.Value = strings.TrimSpace(o.Value)
+		o.Context = strings.TrimSpace(o.Context)
+		if o.OptionType != "species" && o.OptionType != "breed" && o.OptionType != "vaccination" {
+			fail(backup.FileCustomOptions, i, errors.New("invalid option_type"))
+			continue
+		}
+		if o.Value == "" {
+			fail(backup.FileCustomOptions, i, errors.New("value required"))
+			continue
+		}
+		duplicate := false
+		for _, e := range append(existingOptions, p.options...) {
+			if e.OptionType == o.OptionType && normalizeOptionValue(e.Value) == normalizeOptionValue(o.Value) &&
+				normalizeOptionValue(e.Context) == normalizeOptionValue(o.Context) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			p.report.Reused[entityName(backup.FileCustomOptions)]++
+			continue
+		}
+		o.ID, o.UserID = uuid.New(), userID
+		p.options = append(p.options, o)
+		created(backup.FileCustomOptions)
+	}
+
+	clinicIDs := map[uuid.UUID]uuid.UUID{}
+	for i, c := range clinics {
+		if err := validateClinicInput(&c); err != nil {
+			fail(backup.FileClinics, i, err)
+			continue
+		}
+		oldID := c.ID
+		for _, e := range append(existingClinics, p.clinics...) {
+			if normalizeOptionValue(e.Name) == normalizeOptionValue(c.Name) {
+				clinicIDs[oldID] = e.ID
+				break
+			}
+		}
+		if _, ok := clinicIDs[oldID]; ok {
+			p.report.Reused[entityName(backup.FileClinics)]++
+			continue
+		}
+		c.ID, c.UserID = uuid.New(), userID
+		clinicIDs[oldID] = c.ID
+		p.clinics = append(p.clinics, c)
+		created(backup.FileClinics)
+	}
+
+	petIDs := map[uuid.UUID]uuid.UUID{}
+	for i, pet := range pets {
+		pet.Name = strings.TrimSpace(pet.Name)
+		if pet.Name == "" {
+			fail(backup.FilePets, i, errors.New("name required"))
+			continue
+		}
+		if err := validatePetInput(&pet); err != nil {
+			fail(backup.FilePets, i, err)
+			continue
+		}
+		oldID := pet.ID
+		pet.ID, pet.UserID, pet.HouseholdID = uuid.New(), userID, nil
+		pet.ClinicID = remapOptional(clinicIDs, pet.ClinicID)
+		petIDs[oldID] = pet.ID
+		p.pets = append(p.pets, pet)
+		created(backup.FilePets)
+	}
+	// missingPet reports a record whose pet is not in the archive (or was itself invalid).
+	missingPet := func(file string, i int, petID uuid.UUID) bool {
+		if _, ok := petIDs[petID]; ok {
+			return false
+		}
+		fail(file, i, errors.New("pet_id does not match a pet in the archive"))
+		return true
+	}
+	validDate := func(s string) bool { _, err := time.Parse(dateLayout, s); return err == nil }
+
+	visitIDs := map[uuid.UUID]uuid.UUID{}
+	for i, v := range visits {
+		if missingPet(backup.FileVisits, i, v.PetID) {
+			continue
+		}
+		if err := validateVisitInput(&v); err != nil {
+			fail(backup.FileVisits, i, err)
+			continue
+		}
+		oldID := v.ID
+		v.ID, v.PetID = uuid.New(), petIDs[v.PetID]
+		visitIDs[oldID] = v.ID
+		p.visits = append(p.visits, v)
+		created(backup.FileVisits)
+	}
+
+	for i, v := range vaccinations {
+		if missingPet(backup.FileVaccinations, i, v.PetID) {
+			continue
+		}
+		v.Name = strings.TrimSpace(v.Name)
+		switch {
+		case v.Name == "":
+			fail(backup.FileVaccinations, i, errors.New("name required"))
+			continue
+		case !validDate(v.AdministeredAt):
+			fail(backup.FileVaccinations, i, errors.New("invalid administered_at"))
+			continue
+		case v.NextDue != nil && *v.NextDue != "" && !validDate(*v.NextDue):
+			fail(backup.FileVaccinations, i, errors.New("invalid next_due"))
+			continue
+		}
+		v.ID, v.PetID = uuid.New(), petIDs[v.PetID]
+		v.VisitID = remapOptional(visitIDs, v.VisitID)
+		v.ClinicID = remapOptional(clinicIDs, v.ClinicID)
+		p.vaccinations = append(p.vaccinations, v)
+		created(backup.FileVaccinations)
+	}
+
+	for i, e := range weights {
+		if missingPet(backup.FileWeights, i, e.PetID) {
+			continue
+		}
+		if !validDate(e.MeasuredAt) {
+			fail(backup.FileWeights, i, errors.New("invalid measured_at"))
+			continue
+		}
+		if e.WeightLbs <= 0 {
+			fail(backup.FileWeights, i, errors.New("weight_lbs must be positive"))
+			continue
+		}
+		if e.EntryUnit != "kg" {
+			e.EntryUnit = "lbs"
+		}
+		e.ID, e.PetID = uuid.New(), petIDs[e.PetID]
+		p.weights = append(p.weights, e)
+		created(backup.FileWeights)
+	}
+
+	missing := map[string]bool{}
+	for _, m := range archive.Manifest.MissingFiles {
+		missing[m] = true
+	}
+	// checkFile validates an upload against the same type and size rules as a regular upload.
+	checkFile := func(rel string, image bool) error {
+		rc, size, err := archive.File(rel)
+		if err != nil {
+			return errors.New("file missing from archive")
+		}
+		defer rc.Close()
+		maxBytes, allowed := h.MaxDocumentBytes, upload.AllowedDocument
+		if maxBytes <= 0 {
+			maxBytes = 25 * 1024 * 1024
+		}
+		if image {
+			maxBytes, allowed = h.MaxPhotoBytes, upload.AllowedImage
+			if maxBytes <= 0 {
+				maxBytes = 10 * 1024 * 1024
+			}
+		}
+		if size > maxBytes {
+			return errors.New("file too large")
+		}
+		head := make([]byte, upload.MaxHeaderBytes)
+		n, _ := io.ReadFull(rc, head)
+		if !allowed(head[:n]) {
+			return errors.New("file type not allowed")
+		}
+		return nil
+	}
+
+	documentIDs := map[uuid.UUID]uuid.UUID{}
+	for i, d := range documents {
+		if missingPet(backup.FileDocuments, i, d.PetID) {
+			continue
+		}
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" {
+			fail(backup.FileDocuments, i, errors.New("name required"))
+			continue
+		}
+		if missing[d.FilePath] {
+			p.report.Warnings = append(p.report.Warnings, fmt.Sprintf("document %q skipped: its file was missing at export", d.Name))
+			continue
+		}
+		if err := checkFile(d.FilePath, false); err != nil {
+			fail(backup.FileDocuments, i, err)
+			continue
+		}
+		oldID, newPet := d.ID, petIDs[d.PetID]
+		dst := path.Join("documents", newPet.String(), uuid.New().String()+"_"+upload.SafeBasename(stripUUIDPrefix(path.Base(d.FilePath))))
+		p.files = append(p.files, importFile{src: d.FilePath, dst: dst})
+		d.ID, d.PetID, d.FilePath, d.ExtractedText = uuid.New(), newPet, dst, nil
+		d.VisitID = remapOptional(visitIDs, d.VisitID)
+		documentIDs[oldID] = d.ID
+		p.documents = append(p.documents, d)
+		created(backup.FileDocuments)
+	}
+
+	photoPaths := map[string]string{}
+	for i, ph := range photos {
+		if missingPet(backup.FilePhotos, i, ph.PetID) {
+			continue
+		}
+		if missing[ph.FilePath] {
+			p.report.Warnings = append(p.report.Warnings, fmt.Sprintf("photo %s skipped: its file was missing at export", ph.FilePath))
+			continue
+		}
+		if err := checkFile(ph.FilePath, true); err != nil {
+			fail(backup.FilePhotos, i, err)
+			continue
+		}
+		ext := strings.ToLower(path.Ext(ph.FilePath))
+		switch ext {
+		case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		default:
+			ext = ".jpg"
+		}
+		newPet := petIDs[ph.PetID]
+		dst := path.Join("photos", newPet.String(), uuid.New().String()+ext)
+		p.files = append(p.files, importFile{src: ph.FilePath, dst: dst})
+		photoPaths[ph.FilePath] = dst
+		ph.ID, ph.PetID, ph.FilePath = uuid.New(), newPet, dst
+		p.photos = append(p.photos, ph)
+		created(backup.FilePhotos)
+	}
+	for i := range p.pets {
+		pet := &p.pets[i]
+		if pet.PhotoURL == nil {
+			continue
+		}
+		if dst, ok := photoPaths[strings.TrimPrefix(*pet.PhotoURL, "/api/uploads/")]; ok {
+			url := "/api/uploads/" + dst
+			pet.PhotoURL = &url
+		} else {
+			pet.PhotoURL = nil
+		}
+	}
+
+	medicationIDs := map[uuid.UUID]models.Medication{}
+	for i, m := range medications {
+		if missingPet(backup.FileMedications, i, m.PetID) {
+			continue
+		}
+		if err := validateMedicationInput(&m); err != nil {
+			fail(backup.FileMedications, i, err)
+			continue
+		}
+		oldID := m.ID
+		m.ID, m.PetID = uuid.New(), petIDs[m.PetID]
+		medicationIDs[oldID] = m
+		p.medications = append(p.medications, m)
+		created(backup.FileMedications)
+	}
+
+	for i, d := range doses {
+		med, ok := medicationIDs[d.MedicationID]
+		if !ok {
+			fail(backup.FileMedicationDoses, i, errors.New("medication_id does not match a medication in the archive"))
+			continue
+		}
+		if d.Status != models.MedicationDoseGiven && d.Status != models.MedicationDoseLate && d.Status != models.MedicationDoseSkipped {
+			fail(backup.FileMedicationDoses, i, errors.New("invalid status"))
+			continue
+		}
+		d.ID, d.MedicationID, d.PetID = uuid.New(), med.ID, med.PetID
+		if d.RecordedBy != nil {
+			d.RecordedBy = &userID
+		}
+		p.doses = append(p.doses, d)
+		created(backup.FileMedicationDoses)
+	}
+
+	for i, a := range allergies {
+		if missingPet(backup.FileAllergies, i, a.PetID) {
+			continue
+		}
+		if err := validateAllergyInput(&a); err != nil {
+			fail(backup.FileAllergies, i, err)
+			continue
+		}
+		a.ID, a.PetID = uuid.New(), petIDs[a.PetID]
+		p.allergies = append(p.allergies, a)
+		created(backup.FileAllergies)
+	}
+
+	for i, c := range conditions {
+		if missingPet(backup.FileConditions, i, c.PetID) {
+			continue
+		}
+		if err := validateConditionInput(&c); err != nil {
+			fail(backup.FileConditions, i, err)
+			continue
+		}
+		c.ID, c.PetID = uuid.New(), petIDs[c.PetID]
+		c.ClinicID = remapOptional(clinicIDs, c.ClinicID)
+		p.conditions = append(p.conditions, c)
+		created(backup.FileConditions)
+	}
+
+	panelIDs := map[uuid.UUID]models.LabPanel{}
+	for i, lp := range panels {
+		if missingPet(backup.FileLabPanels, i, lp.PetID) {
+			continue
+		}
+		lp.Analytes = nil // analytes are their own file
+		if err := validateLabPanelInput(&lp); err != nil {
+			fail(backup.FileLabPanels, i, err)
+			continue
+		}
+		oldID := lp.ID
+		lp.ID, lp.PetID = uuid.New(), petIDs[lp.PetID]
+		lp.DocumentID = remapOptional(documentIDs, lp.DocumentID)
+		panelIDs[oldID] = lp
+		p.panels = append(p.panels, lp)
+		created(backup.FileLabPanels)
+	}
+
+	for i, a := range analytes {
+		panel, ok := panelIDs[a.PanelID]
+		if !ok {
+			fail(backup.FileLabAnalytes, i, errors.New("panel_id does not match a lab panel in the archive"))
+			continue
+		}
+		if err := validateLabAnalyte(&a); err != nil {
+			fail(backup.FileLabAnalytes, i, err)
+			continue
+		}
+		a.ID, a.PanelID, a.PetID = uuid.New(), panel.ID, panel.PetID
+		p.analytes = append(p.analytes, a)
+		created(backup.FileLabAnalytes)
+	}
+
+	p.report.Files = len(p.files)
+	return p, nil
+}
+
+// importSettings returns the user columns to update from an archived settings object, or nil if any value is invalid.
+func importSettings(s SettingsDTO) map[string]interface{} {
+	out := map[string]interface{}{}
+	unit := strings.TrimSpace(strings.ToLower(s.WeightUnit))
+	if unit != "" {
+		if unit != "lbs" && unit != "kg" {
+			return nil
+		}
+		out["weight_unit"] = unit
+	}
+	if c := strings.TrimSpace(strings.ToUpper(s.Currency)); c != "" {
+		out["currency"] = c
+	}
+	if l := strings.TrimSpace(strings.ToLower(s.Language)); l != "" {
+		out["language"] = l
+	}
+	if s.ReminderEmails != nil {
+		out["reminder_emails"] = *s.ReminderEmails
+	}
+	if s.ReminderLeadDays != nil {
+		if *s.ReminderLeadDays < 0 || *s.ReminderLeadDays > maxReminderLeadDays {
+			return nil
+		}
+		out["reminder_lead_days"] = *s.ReminderLeadDays
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// remapOptional maps an optional reference to its new ID, dropping references to records that are not in the archive.
+func remapOptional(ids map[uuid.UUID]uuid.UUID, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if newID, ok := ids[*id]; ok {
+		return &newID
+	}
+	return nil
+}
+
+// stripUUIDPrefix turns an uploaded document's stored name ("<uuid>_report.pdf") back into its original name.
+func stripUUIDPrefix(name string) string {
+	if len(name) > 37 && name[36] == '_' {
+		if _, err := uuid.Parse(name[:36]); err == nil {
+			return name[37:]
+		}
+	}
+	return name
+}
+
+// entityName is the report key for an entity file: "data/pets.json" -> "pets".
+func entityName(file string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(file, backup.DataDir), ".json")
+}
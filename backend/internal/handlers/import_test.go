@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/backup"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func openTestArchive(t *testing.T, build func(w *backup.Writer)) *backup.Archive {
+	t.Helper()
+	var buf bytes.Buffer
+	w := backup.NewWriter(&buf, uuid.New())
+	build(w)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a, err := backup.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()), backup.Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestBuildImportPlan_RemapsIDs(t *testing.T) {
+	clinicID, petID, visitID, docID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	photoPath := "photos/" + petID.String() + "/a.png"
+	docPath := "documents/" + petID.String() + "/" + uuid.New().String() + "_x-ray.pdf"
+	photoURL := "/api/uploads/" + photoPath
+	a := openTestArchive(t, func(w *backup.Writer) {
+		w.WriteJSON(backup.FileClinics, []models.Clinic{{ID: clinicID, Name: "Oak Vets"}}, 1)
+		w.WriteJSON(backup.FilePets, []models.Pet{{ID: petID, Name: "Rex", ClinicID: &clinicID, PhotoURL: &photoURL}}, 1)
+		w.WriteJSON(backup.FileVisits, []models.VetVisit{{ID: visitID, PetID: petID, VisitDate: "2025-01-02"}}, 1)
+		w.WriteJSON(backup.FileDocuments, []models.Document{{ID: docID, PetID: petID, Name: "X-ray", FilePath: docPath, VisitID: &visitID}}, 1)
+		w.WriteJSON(backup.FilePhotos, []models.PetPhoto{{ID: uuid.New(), PetID: petID, FilePath: photoPath}}, 1)
+		w.WriteJSON(backup.FileLabPanels, []models.LabPanel{{ID: uuid.New(), PetID: petID, PanelDate: "2025-01-02", DocumentID: &docID}}, 1)
+		w.WriteFile(photoPath, strings.NewReader("\x89PNG\r\n\x1a\nrest"))
+		w.WriteFile(docPath, strings.NewReader("%PDF-1.4 rest"))
+	})
+	userID, existingClinic := uuid.New(), models.Clinic{ID: uuid.New(), Name: "oak vets"}
+	h := &ImportHandler{}
+	plan, err := h.buildPlan(a, userID, []models.Clinic{existingClinic}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.report.Errors) > 0 {
+		t.Fatalf("errors = %+v", plan.report.Errors)
+	}
+	if len(plan.clinics) != 0 || plan.report.Reused["clinics"] != 1 {
+		t.Errorf("clinic should be reused by name, got %d created", len(plan.clinics))
+	}
+	pet := plan.pets[0]
+	if pet.ID == petID || pet.UserID != userID || pet.ClinicID == nil || *pet.ClinicID != existingClinic.ID {
+		t.Errorf("pet = %+v", pet)
+	}
+	visit, doc, panel := plan.visits[0], plan.documents[0], plan.panels[0]
+	if visit.PetID != pet.ID || doc.PetID != pet.ID || doc.VisitID == nil || *doc.VisitID != visit.ID {
+		t.Error("visit and document should point at the new pet and visit")
+	}
+	if panel.DocumentID == nil || *panel.DocumentID != doc.ID {
+		t.Error("lab panel should point at the new document")
+	}
+	if !strings.HasPrefix(doc.FilePath, "documents/"+pet.ID.String()+"/") || !strings.HasSuffix(doc.FilePath, "_x-ray.pdf") {
+		t.Errorf("document path = %s", doc.FilePath)
+	}
+	if pet.PhotoURL == nil || *pet.PhotoURL != "/api/uploads/"+plan.photos[0].FilePath {
+		t.Errorf("photo_url = %v, photo path = %s", pet.PhotoURL, plan.photos[0].FilePath)
+	}
+	if len(plan.files) != 2 || plan.report.Created["pets"] != 1 {
+		t.Errorf("files = %+v, created = %v", plan.files, plan.report.Created)
+	}
+}
+
+func TestBuildImportPlan_ReportsInvalidRecords(t *testing.T) {
+	petID := uuid.New()
+	docPath := "documents/" + petID.String() + "/script.pdf"
+	a := openTestArchive(t, func(w *backup.Writer) {
+		w.WriteJSON(backup.FilePets, []models.Pet{{ID: petID, Name: "Rex"}, {ID: uuid.New(), Name: " "}}, 2)
+		w.WriteJSON(backup.FileWeights, []models.WeightEntry{{ID: uuid.New(), PetID: uuid.New(), WeightLbs: 10, MeasuredAt: "2025-01-01"}}, 1)
+		w.WriteJSON(backup.FileDocuments, []models.Document{{ID: uuid.New(), PetID: petID, Name: "Script", FilePath: docPath}}, 1)
+		w.WriteFile(docPath, strings.NewReader("#!/bin/sh"))
+	})
+	plan, err := (&ImportHandler{}).buildPlan(a, uuid.New(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{backup.FilePets: true, backup.FileWeights: true, backup.FileDocuments: true}
+	if len(plan.report.Errors) != len(want) {
+		t.Fatalf("errors = %+v", plan.report.Errors)
+	}
+	for _, e := range plan.report.Errors {
+		if !want[e.File] {
+			t.Errorf("unexpected error %+v", e)
+		}
+	}
+}
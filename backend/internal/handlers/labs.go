@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+type LabsHandler struct {
+	DB *gorm.DB
+}
+
+// AnalyteHistoryPoint is one measurement of an analyte, with the panel date it was taken on.
+type AnalyteHistoryPoint struct {
+	PanelID   uuid.UUID `json:"panel_id"`
+	PanelDate string    `json:"panel_date"`
+	Value     float64   `json:"value"`
+	Unit      *string   `json:"unit,omitempty"`
+	RefLow    *float64  `json:"ref_low,omitempty"`
+	RefHigh   *float64  `json:"ref_high,omitempty"`
+	Flag      *string   `json:"flag,omitempty"`
+}
+
+// loadAnalytes fills Analytes on each panel with one query.
+func (h *LabsHandler) loadAnalytes(panels []models.LabPanel) error {
+	if len(panels) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(panels))
+	for i := range panels {
+		ids[i] = panels[i].ID
+		panels[i].Analytes = []models.LabAnalyte{}
+	}
+	var analytes []models.LabAnalyte
+	if err := h.DB.Where("panel_id IN ?", ids).Order("name").Find(&analytes).Error; err != nil {
+		return err
+	}
+	byPanel := make(map[uuid.UUID][]models.LabAnalyte)
+	for _, a := range analytes {
+		byPanel[a.PanelID] = append(byPanel[a.PanelID], a)
+	}
+	for i := range panels {
+		if list, ok := byPanel[panels[i].ID]; ok {
+			panels[i].Analytes = list
+		}
+	}
+	return nil
+}
+
+// List returns the pet's lab panels, newest first, each with its analytes.
+func (h *LabsHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var list []models.LabPanel
+	if err := h.DB.Where("pet_id = ?", petID).Order("panel_date DESC, created_at DESC").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.loadAnalytes(list); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.LabPanel{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *LabsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var p models.LabPanel
+	if err := h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&p).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	panels := []models.LabPanel{p}
+	if err := h.loadAnalytes(panels); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(panels[0])
+}
+
+// Create stores a panel and its analytes in one transaction.
+func (h *LabsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var p models.LabPanel
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	p.PetID = petID
+	p.ID = uuid.Nil
+	if err := validateLabPanelInput(&p); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if p.DocumentID != nil && !documentBelongsToPet(h.DB, *p.DocumentID, petID) {
+		http.Error(w, `{"error":"invalid document_id"}`, http.StatusBadRequest)
+		return
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+		return createAnalytes(tx, &p)
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Update replaces the panel fields and its full set of analytes.
+func (h *LabsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var p models.LabPanel
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	p.ID = id
+	p.PetID = petID
+	if err := validateLabPanelInput(&p); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if p.DocumentID != nil && !documentBelongsToPet(h.DB, *p.DocumentID, petID) {
+		http.Error(w, `{"error":"invalid document_id"}`, http.StatusBadRequest)
+		return
+	}
+	notFound := errors.New("not found")
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LabPanel{}).Where("id = ? AND pet_id = ?", id, petID).Updates(map[string]interface{}{
+			"panel_date": p.PanelDate, "lab_name": p.LabName, "document_id": p.DocumentID, "notes": p.Notes,
+			"updated_at": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return notFound
+		}
+		if err := tx.Where("panel_id = ?", id).Delete(&models.LabAnalyte{}).Error; err != nil {
+			return err
+		}
+		return createAnalytes(tx, &p)
+	})
+	if errors.Is(err, notFound) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	h.DB.Where("id = ?", id).First(&p)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+func (h *LabsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.LabPanel{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("panel_id = ? AND pet_id = ?", id, petID).Delete(&models.LabAnalyte{})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Analytes returns the distinct analyte names recorded for the pet, for picking what to trend.
+func (h *LabsHandler) Analytes(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var names []string
+	if err := h.DB.Model(&models.LabAnalyte{}).Where("pet_id = ?", petID).Distinct("name").Order("name").Pluck("name", &names).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+// History returns every measurement of ?analyte= (case-insensitive) for the pet, oldest first.
+func (h *LabsHandler) History(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("analyte"))
+	if name == "" {
+		http.Error(w, `{"error":"analyte required"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var points []AnalyteHistoryPoint
+	err = h.DB.Table("lab_analytes").
+		Select("lab_analytes.panel_id, lab_panels.panel_date, lab_analytes.value, lab_analytes.unit, lab_analytes.ref_low, lab_analytes.ref_high, lab_analytes.flag").
+		Joins("JOIN lab_panels ON lab_panels.id = lab_analytes.panel_id").
+		Where("lab_analytes.pet_id = ? AND LOWER(lab_analytes.name) = LOWER(?)", petID, name).
+		Order("lab_panels.panel_date, lab_panels.created_at").
+		Scan(&points).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if points == nil {
+		points = []AnalyteHistoryPoint{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(points)
+}
+
+// documentBelongsToPet reports whether docID is a document of petID. Used to validate document_id on lab panels.
+func documentBelongsToPet(db *gorm.DB, docID, petID uuid.UUID) bool {
+	var count int64
+	db.Model(&models.Document{}).Where("id = ? AND pet_id = ?", docID, petID).Count(&count)
+	return count > 0
+}
+
+func createAnalytes(tx *gorm.DB, p *models.LabPanel) error {
+	for i := range p.Analytes {
+		p.Analytes[i].ID = uuid.Nil
+		p.Analytes[i].PanelID = p.ID
+		p.Analytes[i].PetID = p.PetID
+	}
+	if len(p.Analytes) == 0 {
+		p.Analytes = []models.LabAnalyte{}
+		return nil
+	}
+	return tx.Create(&p.Analytes).Error
+}
+
+const (
+	maxLabFieldLen = 200 // lab name, analyte name
+	maxLabTextLen  = 5000
+	maxLabAnalytes = 200
+)
+
+func validateLabPanelInput(p *models.LabPanel) error {
+	p.PanelDate = strings.TrimSpace(p.PanelDate)
+	if p.PanelDate == "" {
+		return errors.New("panel_date required")
+	}
+	if _, err := time.Parse(dateLayout, p.PanelDate); err != nil {
+		return errors.New("invalid panel_date")
+	}
+	if p.LabName != nil && len(*p.LabName) > maxLabFieldLen {
+		return errors.New("lab_name too long")
+	}
+	if p.Notes != nil && len(*p.Notes) > maxLabTextLen {
+		return errors.New("notes too long")
+	}
+	if len(p.Analytes) > maxLabAnalytes {
+		return errors.New("too many analytes")
+	}
+	for i := range p.Analytes {
+		if err := validateLabAnalyte(&p.Analytes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLabAnalyte checks one analyte and fills Flag from the reference range when it is not given.
+func validateLabAnalyte(a *models.LabAnalyte) error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return errors.New("analyte name required")
+	}
+	if len(a.Name) > maxLabFieldLen {
+		return errors.New("analyte name too long")
+	}
+	if a.Unit != nil && len(*a.Unit) > maxLabFieldLen {
+		return errors.New("analyte unit too long")
+	}
+	if a.RefLow != nil && a.RefHigh != nil && *a.RefLow > *a.RefHigh {
+		return errors.New("ref_low must not exceed ref_high")
+	}
+	if a.Flag != nil {
+		flag := strings.TrimSpace(strings.ToLower(*a.Flag))
+		switch flag {
+		case "":
+			a.Flag = nil
+		case models.LabFlagNormal, models.LabFlagLow, models.LabFlagHigh, models.LabFlagAbnormal:
+			a.Flag = &flag
+		default:
+			return errors.New("invalid analyte flag")
+		}
+	}
+	if a.Flag == nil {
+		a.Flag = labFlag(a.Value, a.RefLow, a.RefHigh)
+	}
+	return nil
+}
+
+// labFlag derives low/high/normal from the reference range; nil when there is no range to compare against.
+func labFlag(value float64, low, high *float64) *string {
+	if low == nil && high == nil {
+		return nil
+	}
+	flag := models.LabFlagNormal
+	if low != nil && value < *low {
+		flag = models.LabFlagLow
+	} else if high != nil && value > *high {
+		flag = models.LabFlagHigh
+	}
+	return &flag
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestValidateLabAnalyte_DerivesFlag(t *testing.T) {
+	low, high := 0.5, 1.8
+	tests := []struct {
+		name  string
+		value float64
+		low   *float64
+		high  *float64
+		want  string // "" means no flag
+	}{
+		{"below range", 0.3, &low, &high, models.LabFlagLow},
+		{"above range", 2.4, &low, &high, models.LabFlagHigh},
+		{"in range", 1.2, &low, &high, models.LabFlagNormal},
+		{"on boundary", 1.8, &low, &high, models.LabFlagNormal},
+		{"upper bound only", 2.0, nil, &high, models.LabFlagHigh},
+		{"no range", 2.0, nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := models.LabAnalyte{Name: " Creatinine ", Value: tt.value, RefLow: tt.low, RefHigh: tt.high}
+			if err := validateLabAnalyte(&a); err != nil {
+				t.Fatalf("validateLabAnalyte: %v", err)
+			}
+			if a.Name != "Creatinine" {
+				t.Errorf("name = %q, want trimmed", a.Name)
+			}
+			got := ""
+			if a.Flag != nil {
+				got = *a.Flag
+			}
+			if got != tt.want {
+				t.Errorf("flag = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLabAnalyte_ExplicitFlag(t *testing.T) {
+	low, high := 0.5, 1.8
+	flag := " Abnormal "
+	a := models.LabAnalyte{Name: "Urine protein", Value: 1.0, RefLow: &low, RefHigh: &high, Flag: &flag}
+	if err := validateLabAnalyte(&a); err != nil {
+		t.Fatalf("validateLabAnalyte: %v", err)
+	}
+	if a.Flag == nil || *a.Flag != models.LabFlagAbnormal {
+		t.Errorf("explicit flag should be kept and normalized, got %v", a.Flag)
+	}
+
+	bad := "critical"
+	a = models.LabAnalyte{Name: "BUN", Value: 1, Flag: &bad}
+	if err := validateLabAnalyte(&a); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+	a = models.LabAnalyte{Name: "BUN", Value: 1, RefLow: &high, RefHigh: &low}
+	if err := validateLabAnalyte(&a); err == nil {
+		t.Error("expected error when ref_low > ref_high")
+	}
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// defaultAdherenceDays is the range used by Adherence when from/to are not given.
+const defaultAdherenceDays = 30
+
+// medicationForPet loads the medication from the route, scoped to the pet. Returns nil if not found.
+func (h *MedicationsHandler) medicationForPet(petID, id uuid.UUID) *models.Medication {
+	var m models.Medication
+	if err := h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&m).Error; err != nil {
+		return nil
+	}
+	return &m
+}
+
+// ListDoses returns logged doses for one medication, newest first. Optional ?from= and ?to= (YYYY-MM-DD, inclusive).
+func (h *MedicationsHandler) ListDoses(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	if h.medicationForPet(petID, id) == nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	q := h.DB.Where("medication_id = ? AND pet_id = ?", id, petID)
+	if from, err := time.Parse(dateLayout, r.URL.Query().Get("from")); err == nil {
+		q = q.Where("given_at >= ?", from)
+	}
+	if to, err := time.Parse(dateLayout, r.URL.Query().Get("to")); err == nil {
+		q = q.Where("given_at < ?", to.AddDate(0, 0, 1))
+	}
+	var list []models.MedicationDose
+	if err := q.Order("given_at DESC").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.MedicationDose{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// LogDose records a dose. given_at defaults to now; status defaults to "given"; given_by defaults to the caller's display name.
+func (h *MedicationsHandler) LogDose(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	if h.medicationForPet(petID, id) == nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var body struct {
+		GivenAt *time.Time `json:"given_at"`
+		Status  string     `json:"status"`
+		GivenBy *string    `json:"given_by"`
+		Notes   *string    `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	status := strings.TrimSpace(strings.ToLower(body.Status))
+	if status == "" {
+		status = models.MedicationDoseGiven
+	}
+	if status != models.MedicationDoseGiven && status != models.MedicationDoseLate && status != models.MedicationDoseSkipped {
+		http.Error(w, `{"error":"invalid status"}`, http.StatusBadRequest)
+		return
+	}
+	givenAt := time.Now()
+	if body.GivenAt != nil && !body.GivenAt.IsZero() {
+		givenAt = *body.GivenAt
+	}
+	givenBy := body.GivenBy
+	if givenBy == nil || strings.TrimSpace(*givenBy) == "" {
+		name := u.DisplayName
+		givenBy = &name
+	}
+	if len(*givenBy) > maxMedicationFieldLen || (body.Notes != nil && len(*body.Notes) > maxMedicationNotesLen) {
+		http.Error(w, `{"error":"field too long"}`, http.StatusBadRequest)
+		return
+	}
+	recordedBy := u.ID
+	dose := models.MedicationDose{
+		MedicationID: id,
+		PetID:        petID,
+		GivenAt:      givenAt,
+		Status:       status,
+		GivenBy:      givenBy,
+		RecordedBy:   &recordedBy,
+		Notes:        body.Notes,
+	}
+	if err := h.DB.Create(&dose).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dose)
+}
+
+// DeleteDose removes a logged dose (undo).
+func (h *MedicationsHandler) DeleteDose(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, _ := uuid.Parse(vars["id"])
+	doseID, err := uuid.Parse(vars["doseId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	result := h.DB.Where("id = ? AND medication_id = ? AND pet_id = ?", doseID, id, petID).Delete(&models.MedicationDose{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MedicationAdherence is one medication's row in the adherence summary.
+// AdherencePct is nil for as-needed medications (no schedule to compare against).
+type MedicationAdherence struct {
+	MedicationID uuid.UUID `json:"medication_id"`
+	Name         string    `json:"name"`
+	Frequency    string    `json:"frequency"`
+	Expected     int       `json:"expected"`
+	Given        int       `json:"given"`
+	Late         int       `json:"late"`
+	Skipped      int       `json:"skipped"`
+	Missed       int       `json:"missed"`
+	AdherencePct *float64  `json:"adherence_pct"`
+}
+
+type AdherenceResponse struct {
+	From         string                `json:"from"`
+	To           string                `json:"to"`
+	Medications  []MedicationAdherence `json:"medications"`
+	AdherencePct *float64              `json:"adherence_pct"` // across all scheduled medications
+}
+
+// Adherence returns expected vs recorded doses per medication for ?from= and ?to= (YYYY-MM-DD, inclusive; default last 30 days).
+func (h *MedicationsHandler) Adherence(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	today, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+	to := today
+	if s := r.URL.Query().Get("to"); s != "" {
+		if to, err = time.Parse(dateLayout, s); err != nil {
+			http.Error(w, `{"error":"invalid to"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -(defaultAdherenceDays - 1))
+	if s := r.URL.Query().Get("from"); s != "" {
+		if from, err = time.Parse(dateLayout, s); err != nil {
+			http.Error(w, `{"error":"invalid from"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if to.Before(from) {
+		http.Error(w, `{"error":"to before from"}`, http.StatusBadRequest)
+		return
+	}
+	// Doses in the future have not been missed yet.
+	if to.After(today) {
+		to = today
+	}
+
+	var meds []models.Medication
+	if err := h.DB.Where("pet_id = ?", petID).Order("start_date DESC").Find(&meds).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	var doses []models.MedicationDose
+	if err := h.DB.Where("pet_id = ? AND given_at >= ? AND given_at < ?", petID, from, to.AddDate(0, 0, 1)).Find(&doses).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	byMed := make(map[uuid.UUID][]models.MedicationDose)
+	for _, d := range doses {
+		byMed[d.MedicationID] = append(byMed[d.MedicationID], d)
+	}
+
+	out := AdherenceResponse{From: from.Format(dateLayout), To: to.Format(dateLayout), Medications: []MedicationAdherence{}}
+	var totalExpected, totalTaken int
+	for _, m := range meds {
+		row := computeAdherence(m, byMed[m.ID], from, to)
+		if row.AdherencePct != nil {
+			totalExpected += row.Expected
+			totalTaken += row.Given + row.Late
+		}
+		out.Medications = append(out.Medications, row)
+	}
+	if totalExpected > 0 {
+		out.AdherencePct = adherencePct(totalTaken, totalExpected)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// computeAdherence counts recorded doses for m and compares them with the doses its schedule expects between from and to (dates, inclusive).
+func computeAdherence(m models.Medication, doses []models.MedicationDose, from, to time.Time) MedicationAdherence {
+	row := MedicationAdherence{MedicationID: m.ID, Name: m.Name, Frequency: m.Frequency}
+	for _, d := range doses {
+		switch d.Status {
+		case models.MedicationDoseGiven:
+			row.Given++
+		case models.MedicationDoseLate:
+			row.Late++
+		case models.MedicationDoseSkipped:
+			row.Skipped++
+		}
+	}
+	if m.Frequency == models.MedicationFrequencyAsNeeded {
+		return row
+	}
+	row.Expected = expectedDoses(m, from, to)
+	row.Missed = row.Expected - row.Given - row.Late - row.Skipped
+	if row.Missed < 0 {
+		row.Missed = 0
+	}
+	if row.Expected > 0 {
+		row.AdherencePct = adherencePct(row.Given+row.Late, row.Expected)
+	}
+	return row
+}
+
+// expectedDoses returns how many doses m's schedule calls for on days in [from, to] that fall within the medication's start/end dates.
+func expectedDoses(m models.Medication, from, to time.Time) int {
+	start, err := time.Parse(dateLayout, m.StartDate)
+	if err != nil {
+		return 0
+	}
+	end := to
+	if m.EndDate != nil && *m.EndDate != "" {
+		if e, err := time.Parse(dateLayout, *m.EndDate); err == nil && e.Before(end) {
+			end = e
+		}
+	}
+	first := from
+	if start.After(first) {
+		first = start
+	}
+	if end.Before(first) {
+		return 0
+	}
+	n := 0
+	for d := first; !d.After(end); d = d.AddDate(0, 0, 1) {
+		daysSinceStart := int(d.Sub(start).Hours() / 24)
+		switch m.Frequency {
+		case models.MedicationFrequencyOnceDaily:
+			n++
+		case models.MedicationFrequencyTwiceDaily:
+			n += 2
+		case models.MedicationFrequencyThreeTimesDaily:
+			n += 3
+		case models.MedicationFrequencyEveryOtherDay:
+			if daysSinceStart%2 == 0 {
+				n++
+			}
+		case models.MedicationFrequencyWeekly:
+			if daysSinceStart%7 == 0 {
+				n++
+			}
+		case models.MedicationFrequencyMonthly:
+			if d.Day() == monthlyDoseDay(start.Day(), d) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// monthlyDoseDay clamps the start day to the last day of d's month (e.g. a 31st start falls on Feb 28).
+func monthlyDoseDay(startDay int, d time.Time) int {
+	last := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if startDay > last {
+		return last
+	}
+	return startDay
+}
+
+func adherencePct(taken, expected int) *float64 {
+	pct := float64(taken) / float64(expected) * 100
+	if pct > 100 {
+		pct = 100
+	}
+	pct = math.Round(pct*10) / 10
+	return &pct
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func mustDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return d
+}
+
+func TestExpectedDoses_ByFrequency(t *testing.T) {
+	from := mustDate(t, "2025-03-01")
+	to := mustDate(t, "2025-03-14")
+	cases := []struct {
+		freq string
+		want int
+	}{
+		{models.MedicationFrequencyOnceDaily, 14},
+		{models.MedicationFrequencyTwiceDaily, 28},
+		{models.MedicationFrequencyThreeTimesDaily, 42},
+		{models.MedicationFrequencyEveryOtherDay, 7},
+		{models.MedicationFrequencyWeekly, 2},
+		{models.MedicationFrequencyMonthly, 1},
+	}
+	for _, c := range cases {
+		m := models.Medication{Frequency: c.freq, StartDate: "2025-03-01"}
+		if got := expectedDoses(m, from, to); got != c.want {
+			t.Errorf("%s: expected %d doses, got %d", c.freq, c.want, got)
+		}
+	}
+}
+
+func TestExpectedDoses_ClampedToStartAndEnd(t *testing.T) {
+	end := "2025-03-10"
+	m := models.Medication{Frequency: models.MedicationFrequencyOnceDaily, StartDate: "2025-03-05", EndDate: &end}
+	if got := expectedDoses(m, mustDate(t, "2025-03-01"), mustDate(t, "2025-03-31")); got != 6 {
+		t.Errorf("expected 6 doses between start and end, got %d", got)
+	}
+	if got := expectedDoses(m, mustDate(t, "2025-04-01"), mustDate(t, "2025-04-30")); got != 0 {
+		t.Errorf("expected 0 doses after end date, got %d", got)
+	}
+}
+
+func TestExpectedDoses_MonthlyClampsToMonthEnd(t *testing.T) {
+	m := models.Medication{Frequency: models.MedicationFrequencyMonthly, StartDate: "2025-01-31"}
+	if got := expectedDoses(m, mustDate(t, "2025-01-01"), mustDate(t, "2025-04-30")); got != 4 {
+		t.Errorf("expected 4 monthly doses (Jan 31, Feb 28, Mar 31, Apr 30), got %d", got)
+	}
+}
+
+func TestComputeAdherence_CountsStatuses(t *testing.T) {
+	m := models.Medication{ID: uuid.New(), Name: "Apoquel", Frequency: models.MedicationFrequencyOnceDaily, StartDate: "2025-03-01"}
+	doses := []models.MedicationDose{
+		{Status: models.MedicationDoseGiven},
+		{Status: models.MedicationDoseGiven},
+		{Status: models.MedicationDoseLate},
+		{Status: models.MedicationDoseSkipped},
+	}
+	row := computeAdherence(m, doses, mustDate(t, "2025-03-01"), mustDate(t, "2025-03-05"))
+	if row.Expected != 5 || row.Given != 2 || row.Late != 1 || row.Skipped != 1 || row.Missed != 1 {
+		t.Errorf("unexpected counts: %+v", row)
+	}
+	if row.AdherencePct == nil || *row.AdherencePct != 60 {
+		t.Errorf("expected adherence 60%%, got %v", row.AdherencePct)
+	}
+}
+
+func TestComputeAdherence_AsNeededHasNoPercentage(t *testing.T) {
+	m := models.Medication{Frequency: models.MedicationFrequencyAsNeeded, StartDate: "2025-03-01"}
+	row := computeAdherence(m, []models.MedicationDose{{Status: models.MedicationDoseGiven}}, mustDate(t, "2025-03-01"), mustDate(t, "2025-03-05"))
+	if row.Expected != 0 || row.AdherencePct != nil || row.Given != 1 {
+		t.Errorf("as-needed medication should have no expected doses or percentage: %+v", row)
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+type MedicationsHandler struct {
+	DB *gorm.DB
+}
+
+func (h *MedicationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	q := h.DB.Where("pet_id = ?", petID)
+	// ?active=true limits to medications without an end date or ending today or later.
+	if strings.ToLower(r.URL.Query().Get("active")) == "true" {
+		today := time.Now().Format(dateLayout)
+		q = q.Where("end_date IS NULL OR end_date = '' OR end_date >= ?", today)
+	}
+	var list []models.Medication
+	err = q.Order("start_date DESC").Find(&list).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.Medication{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *MedicationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var m models.Medication
+	err = h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&m).Error
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+func (h *MedicationsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var m models.Medication
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	m.PetID = petID
+	m.ID = uuid.Nil
+	if err := validateMedicationInput(&m); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.Create(&m).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m)
+}
+
+func (h *MedicationsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var m models.Medication
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	m.ID = id
+	m.PetID = petID
+	if err := validateMedicationInput(&m); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.Medication{}).Where("id = ? AND pet_id = ?", id, petID).Updates(map[string]interface{}{
+		"name": m.Name, "dose": m.Dose, "frequency": m.Frequency, "route": m.Route,
+		"prescribing_vet": m.PrescribingVet, "start_date": m.StartDate, "end_date": m.EndDate,
+		"refills_remaining": m.RefillsRemaining, "notes": m.Notes,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("id = ?", id).First(&m)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+func (h *MedicationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	h.DB.Where("medication_id = ? AND pet_id = ?", id, petID).Delete(&models.MedicationDose{})
+	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.Medication{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	maxMedicationNameLen  = 200
+	maxMedicationFieldLen = 200 // dose, route, prescribing vet
+	maxMedicationNotesLen = 5000
+)
+
+// validateMedicationInput trims and checks a medication body. Frequency defaults to once_daily when empty.
+func validateMedicationInput(m *models.Medication) error {
+	m.Name = strings.TrimSpace(m.Name)
+	m.StartDate = strings.TrimSpace(m.StartDate)
+	if m.Name == "" || m.StartDate == "" {
+		return errors.New("name and start_date required")
+	}
+	if len(m.Name) > maxMedicationNameLen {
+		return errors.New("name too long")
+	}
+	m.Frequency = strings.TrimSpace(strings.ToLower(m.Frequency))
+	if m.Frequency == "" {
+		m.Frequency = models.MedicationFrequencyOnceDaily
+	}
+	if !models.ValidMedicationFrequency(m.Frequency) {
+		return errors.New("invalid frequency")
+	}
+	if _, err := time.Parse(dateLayout, m.StartDate); err != nil {
+		return errors.New("invalid start_date")
+	}
+	if m.EndDate != nil && *m.EndDate != "" {
+		if _, err := time.Parse(dateLayout, *m.EndDate); err != nil {
+			return errors.New("invalid end_date")
+		}
+		if *m.EndDate < m.StartDate {
+			return errors.New("end_date before start_date")
+		}
+	}
+	if m.RefillsRemaining != nil && *m.RefillsRemaining < 0 {
+		return errors.New("refills_remaining must not be negative")
+	}
+	for _, s := range []*string{m.Dose, m.Route, m.PrescribingVet} {
+		if s != nil && len(*s) > maxMedicationFieldLen {
+			return errors.New("field too long")
+		}
+	}
+	if m.Notes != nil && len(*m.Notes) > maxMedicationNotesLen {
+		return errors.New("notes too long")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestValidateMedicationInput(t *testing.T) {
+	str := func(s string) *string { return &s }
+	refills := func(n int) *int { return &n }
+	tests := []struct {
+		name          string
+		in            models.Medication
+		wantErr       string // "" means valid
+		wantFrequency string
+	}{
+		{"defaults to once daily", models.Medication{Name: " Apoquel ", StartDate: "2025-03-01"}, "", models.MedicationFrequencyOnceDaily},
+		{"normalizes frequency", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: " Twice_Daily "}, "", models.MedicationFrequencyTwiceDaily},
+		{"three times daily", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: "three_times_daily"}, "", models.MedicationFrequencyThreeTimesDaily},
+		{"every other day", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: "every_other_day"}, "", models.MedicationFrequencyEveryOtherDay},
+		{"weekly", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: "weekly"}, "", models.MedicationFrequencyWeekly},
+		{"monthly", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: "monthly"}, "", models.MedicationFrequencyMonthly},
+		{"as needed", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: "as_needed"}, "", models.MedicationFrequencyAsNeeded},
+		{"end date same day", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", EndDate: str("2025-03-01")}, "", models.MedicationFrequencyOnceDaily},
+		{"empty end date", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", EndDate: str("")}, "", models.MedicationFrequencyOnceDaily},
+		{"zero refills", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", RefillsRemaining: refills(0)}, "", models.MedicationFrequencyOnceDaily},
+		{"missing name", models.Medication{Name: " ", StartDate: "2025-03-01"}, "name and start_date required", ""},
+		{"missing start date", models.Medication{Name: "Apoquel"}, "name and start_date required", ""},
+		{"long name", models.Medication{Name: strings.Repeat("a", maxMedicationNameLen+1), StartDate: "2025-03-01"}, "name too long", ""},
+		{"unknown frequency", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Frequency: "hourly"}, "invalid frequency", ""},
+		{"bad start date", models.Medication{Name: "Apoquel", StartDate: "2025-02-30"}, "invalid start_date", ""},
+		{"bad end date", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", EndDate: str("03/10/2025")}, "invalid end_date", ""},
+		{"end before start", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", EndDate: str("2025-02-28")}, "end_date before start_date", ""},
+		{"negative refills", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", RefillsRemaining: refills(-1)}, "refills_remaining must not be negative", ""},
+		{"long dose", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Dose: str(strings.Repeat("a", maxMedicationFieldLen+1))}, "field too long", ""},
+		{"long notes", models.Medication{Name: "Apoquel", StartDate: "2025-03-01", Notes: str(strings.Repeat("a", maxMedicationNotesLen+1))}, "notes too long", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.in
+			err := validateMedicationInput(&m)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateMedicationInput: %v", err)
+			}
+			if m.Name != strings.TrimSpace(tt.in.Name) {
+				t.Errorf("name = %q, want trimmed", m.Name)
+			}
+			if m.Frequency != tt.wantFrequency {
+				t.Errorf("frequency = %q, want %q", m.Frequency, tt.wantFrequency)
+			}
+			if !models.ValidMedicationFrequency(m.Frequency) {
+				t.Errorf("frequency %q accepted but not a known value", m.Frequency)
+			}
+		})
+	}
+}
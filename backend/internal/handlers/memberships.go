@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// MembershipsHandler shares pets between users: owners invite by email, the invitee accepts from their own account,
+// and owners manage members' roles. The pet's creator is always an owner and cannot be removed.
+type MembershipsHandler struct {
+	DB *gorm.DB
+}
+
+// PetMember is one entry of GET /pets/{petId}/members.
+type PetMember struct {
+	UserID      uuid.UUID `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Creator     bool      `json:"creator"` // the pet's creator; cannot be removed or demoted
+}
+
+// ReceivedInvitation is one entry of GET /invitations: a pending invitation addressed to the caller's email.
+type ReceivedInvitation struct {
+	models.PetInvitation
+	PetName     string `json:"pet_name"`
+	InviterName string `json:"inviter_name"`
+}
+
+const petInvitationTTL = 14 * 24 * time.Hour
+
+func validPetRole(role string) bool {
+	return models.PetRoleRank(role) > 0
+}
+
+// Members lists everyone with access to the pet, creator first.
+func (h *MembershipsHandler) Members(w http.ResponseWriter, r *http.Request) {
+	petID, _ := uuid.Parse(mux.Vars(r)["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var pet models.Pet
+	if err := h.DB.Select("id, user_id").Where("id = ?", petID).First(&pet).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var memberships []models.PetMembership
+	if err := h.DB.Where("pet_id = ?", petID).Order("created_at").Find(&memberships).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	userIDs := []uuid.UUID{pet.UserID}
+	for _, m := range memberships {
+		userIDs = append(userIDs, m.UserID)
+	}
+	var users []models.User
+	h.DB.Select("id, display_name, email").Where("id IN ?", userIDs).Find(&users)
+	byID := make(map[uuid.UUID]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	member := func(userID uuid.UUID, role string, creator bool) PetMember {
+		u := byID[userID]
+		return PetMember{UserID: userID, DisplayName: u.DisplayName, Email: u.Email, Role: role, Creator: creator}
+	}
+	out := []PetMember{member(pet.UserID, models.PetRoleOwner, true)}
+	for _, m := range memberships {
+		out = append(out, member(m.UserID, m.Role, false))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// UpdateMember changes a member's role. Owner only.
+func (h *MembershipsHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	userID, err := uuid.Parse(vars["userId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid user id"}`, http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	if !validPetRole(body.Role) {
+		http.Error(w, `{"error":"role must be owner, editor, or viewer"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.PetMembership{}).Where("pet_id = ? AND user_id = ?", petID, userID).
+		Updates(map[string]interface{}{"role": body.Role, "updated_at": time.Now()})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var m models.PetMembership
+	h.DB.Where("pet_id = ? AND user_id = ?", petID, userID).First(&m)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// RemoveMember revokes a member's access. Owners can remove anyone but the creator; any member can remove themselves.
+func (h *MembershipsHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	userID, err := uuid.Parse(vars["userId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid user id"}`, http.StatusBadRequest)
+		return
+	}
+	need := models.PetRoleOwner
+	if userID == u.ID {
+		need = models.PetRoleViewer
+	}
+	if !requirePetRole(w, r, h.DB, petID, need) {
+		return
+	}
+	result := h.DB.Where("pet_id = ? AND user_id = ?", petID, userID).Delete(&models.PetMembership{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListInvitations returns the pet's pending, unexpired invitations. Owner only.
+func (h *MembershipsHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	petID, _ := uuid.Parse(mux.Vars(r)["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	var list []models.PetInvitation
+	if err := h.DB.Where("pet_id = ? AND expires_at > ?", petID, time.Now()).Order("created_at").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.PetInvitation{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Invite offers access to the pet to the account with the given email, replacing any earlier invitation to that
+// address. The invitee sees it under GET /invitations once signed in. Owner only.
+func (h *MembershipsHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, _ := uuid.Parse(mux.Vars(r)["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	var body struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if _, err := mail.ParseAddress(email); err != nil || email == "" {
+		http.Error(w, `{"error":"valid email required"}`, http.StatusBadRequest)
+		return
+	}
+	if body.Role == "" {
+		body.Role = models.PetRoleViewer
+	}
+	if !validPetRole(body.Role) {
+		http.Error(w, `{"error":"role must be owner, editor, or viewer"}`, http.StatusBadRequest)
+		return
+	}
+	var invitee models.User
+	if err := h.DB.Where("LOWER(email) = ?", email).First(&invitee).Error; err == nil && petRole(h.DB, petID, invitee.ID) != "" {
+		http.Error(w, `{"error":"user already has access"}`, http.StatusConflict)
+		return
+	}
+	inv := models.PetInvitation{PetID: petID, InvitedBy: u.ID, Email: email, Role: body.Role, ExpiresAt: time.Now().Add(petInvitationTTL)}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pet_id = ? AND email = ?", petID, email).Delete(&models.PetInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&inv).Error
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inv)
+}
+
+// RevokeInvitation deletes a pending invitation. Owner only.
+func (h *MembershipsHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.PetInvitation{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Received lists pending, unexpired invitations addressed to the caller's email.
+func (h *MembershipsHandler) Received(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var list []ReceivedInvitation
+	err := h.DB.Table("pet_invitations").
+		Select("pet_invitations.*, pets.name AS pet_name, users.display_name AS inviter_name").
+		Joins("JOIN pets ON pets.id = pet_invitations.pet_id").
+		Joins("LEFT JOIN users ON users.id = pet_invitations.invited_by").
+		Where("pet_invitations.email = ? AND pet_invitations.expires_at > ?", strings.ToLower(u.Email), time.Now()).
+		Order("pet_invitations.created_at").
+		Scan(&list).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []ReceivedInvitation{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// receivedInvitation loads an unexpired invitation addressed to the caller. Returns nil if not found.
+func (h *MembershipsHandler) receivedInvitation(r *http.Request, email string) *models.PetInvitation {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return nil
+	}
+	var inv models.PetInvitation
+	if err := h.DB.Where("id = ? AND email = ? AND expires_at > ?", id, strings.ToLower(email), time.Now()).First(&inv).Error; err != nil {
+		return nil
+	}
+	return &inv
+}
+
+// Accept turns the invitation into a membership for the caller. If they already have access, the higher role wins.
+func (h *MembershipsHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	inv := h.receivedInvitation(r, u.Email)
+	if inv == nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	m := models.PetMembership{PetID: inv.PetID, UserID: u.ID, Role: inv.Role}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if petCreatorID(tx, inv.PetID) == u.ID {
+			m.Role = models.PetRoleOwner // already the creator; nothing to grant
+		} else if err := tx.Where("pet_id = ? AND user_id = ?", inv.PetID, u.ID).First(&m).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Create(&m).Error; err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if models.PetRoleRank(inv.Role) > models.PetRoleRank(m.Role) {
+			m.Role = inv.Role
+			if err := tx.Model(&models.PetMembership{}).Where("id = ?", m.ID).Updates(map[string]interface{}{"role": m.Role, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", inv.ID).Delete(&models.PetInvitation{}).Error
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// Decline deletes an invitation addressed to the caller.
+func (h *MembershipsHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	inv := h.receivedInvitation(r, u.Email)
+	if inv == nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Where("id = ?", inv.ID).Delete(&models.PetInvitation{}).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
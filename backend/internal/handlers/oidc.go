@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/oidc"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateCookie    = "oidc_state"
+	oidcNonceCookie    = "oidc_nonce"
+	oidcVerifierCookie = "oidc_verifier"
+	oidcReturnCookie   = "oidc_return"
+	oidcCookiePath     = "/api/auth/oidc"
+	oidcFlowTTL        = 10 * time.Minute
+	oidcCallbackPath   = "/api/auth/oidc/callback"
+)
+
+// OIDCStatus is the response of GET /api/auth/oidc; the login page shows a "Sign in with {name}" button when enabled.
+type OIDCStatus struct {
+	Enabled bool   `json:"enabled"`
+	Name    string `json:"name,omitempty"`
+}
+
+// OIDCStatus handles GET /api/auth/oidc.
+func (h *AuthHandler) OIDCStatus(w http.ResponseWriter, r *http.Request) {
+	status := OIDCStatus{Enabled: h.OIDC != nil}
+	if status.Enabled {
+		status.Name = h.Config.OIDCProviderName
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// OIDCLogin handles GET /api/auth/oidc/login?return_to=/path: it stores state, nonce, and the PKCE verifier in
+// short-lived cookies and redirects the browser to the provider.
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	target, err := h.OIDC.AuthCodeURL(r.Context(), req, h.oidcRedirectURI(r))
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.oidc_failed", err))
+		h.oidcFail(w, r, "oidc_unavailable")
+		return
+	}
+	h.setOIDCCookie(w, r, oidcStateCookie, req.State)
+	h.setOIDCCookie(w, r, oidcNonceCookie, req.Nonce)
+	h.setOIDCCookie(w, r, oidcVerifierCookie, req.Verifier)
+	h.setOIDCCookie(w, r, oidcReturnCookie, url.QueryEscape(safeReturnPath(r.URL.Query().Get("return_to"))))
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// OIDCCallback handles GET /api/auth/oidc/callback. After verifying the ID token it logs in the user with the same
+// email (verified by the provider), creating one when OIDC_AUTO_PROVISION is on, and redirects back to the app with
+// the usual session cookies set. Accounts with two-factor enabled get no cookies: they are sent to the login page with
+// an MFA token to finish at /api/auth/login/mfa, as after a password login. Failures redirect to /login?error=....
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	state, nonce, verifier := cookieValue(r, oidcStateCookie), cookieValue(r, oidcNonceCookie), cookieValue(r, oidcVerifierCookie)
+	returnTo, _ := url.QueryUnescape(cookieValue(r, oidcReturnCookie))
+	returnTo = safeReturnPath(returnTo)
+	for _, name := range []string{oidcStateCookie, oidcNonceCookie, oidcVerifierCookie, oidcReturnCookie} {
+		h.setOIDCCookie(w, r, name, "")
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		log.Print(i18n.Tf("log.auth.oidc_failed", "provider returned "+e))
+		h.oidcFail(w, r, "oidc_denied")
+		return
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(q.Get("state"))) != 1 {
+		log.Print(i18n.Tf("log.auth.oidc_failed", "state mismatch"))
+		h.oidcFail(w, r, "oidc_failed")
+		return
+	}
+	idToken, err := h.OIDC.Exchange(r.Context(), q.Get("code"), verifier, h.oidcRedirectURI(r))
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.oidc_failed", err))
+		h.oidcFail(w, r, "oidc_failed")
+		return
+	}
+	claims, err := h.OIDC.VerifyIDToken(r.Context(), idToken, nonce)
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.oidc_failed", err))
+		h.oidcFail(w, r, "oidc_failed")
+		return
+	}
+	email := strings.ToLower(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		log.Print(i18n.Tf("log.auth.oidc_failed", "email missing or not verified for subject "+claims.Subject))
+		h.oidcFail(w, r, "email_not_verified")
+		return
+	}
+	var u models.User
+	err = h.DB.Where("LOWER(TRIM(email)) = ?", email).First(&u).Error
+	if err == gorm.ErrRecordNotFound && h.Config.OIDCAutoProvision {
+		u = middleware.CreateExternalUser(h.DB, email, oidcDisplayName(claims), h.DefaultWeightUnit, h.DefaultCurrency, h.DefaultLanguage)
+		if u.ID == uuid.Nil {
+			h.oidcFail(w, r, "oidc_failed")
+			return
+		}
+		log.Print(i18n.Tf("log.auth.oidc_user_created", u.ID, email))
+	} else if err == gorm.ErrRecordNotFound {
+		log.Print(i18n.Tf("log.auth.oidc_no_account", email))
+		h.oidcFail(w, r, "no_account")
+		return
+	} else if err != nil {
+		h.oidcFail(w, r, "oidc_failed")
+		return
+	}
+	h.applyUserDefaults(&u)
+	if u.TOTPEnabled {
+		// The provider vouches for the email, not for this account's second factor.
+		mfaToken, err := h.JWT.NewMFAToken(u.ID)
+		if err != nil {
+			log.Print(i18n.Tf("log.auth.login_jwt_error", err))
+			h.oidcFail(w, r, "oidc_failed")
+			return
+		}
+		log.Print(i18n.Tf("log.auth.login_mfa_required", u.ID))
+		http.Redirect(w, r, oidcMFARedirect(h.Config.PublicURL, returnTo, mfaToken), http.StatusFound)
+		return
+	}
+	if _, err := h.issueTokens(w, r, u); err != nil {
+		h.oidcFail(w, r, "oidc_failed")
+		return
+	}
+	log.Print(i18n.Tf("log.auth.oidc_login", u.ID))
+	http.Redirect(w, r, h.Config.PublicURL+returnTo, http.StatusFound)
+}
+
+// oidcRedirectURI is OIDC_REDIRECT_URI, or the callback route on PUBLIC_URL or the request's origin.
+func (h *AuthHandler) oidcRedirectURI(r *http.Request) string {
+	if h.Config.OIDCRedirectURI != "" {
+		return h.Config.OIDCRedirectURI
+	}
+	base := h.Config.PublicURL
+	if base == "" {
+		base = h.Config.RequestOrigin(r)
+	}
+	return base + oidcCallbackPath
+}
+
+// oidcMFARedirect points the browser at the login page's second-factor step. The MFA token goes in the fragment so it
+// is not sent to the server again or leaked in a Referer header.
+func oidcMFARedirect(publicURL, returnTo, mfaToken string) string {
+	return publicURL + "/login?return_to=" + url.QueryEscape(returnTo) + "#mfa_token=" + url.QueryEscape(mfaToken)
+}
+
+// oidcFail sends the browser back to the login page with an error code the frontend can show.
+func (h *AuthHandler) oidcFail(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, h.Config.PublicURL+"/login?error="+url.QueryEscape(code), http.StatusFound)
+}
+
+// setOIDCCookie stores one value of the login flow; an empty value deletes the cookie. Lax so the cookies are sent on
+// the provider's top-level redirect back to the callback.
+func (h *AuthHandler) setOIDCCookie(w http.ResponseWriter, r *http.Request, name, value string) {
+	maxAge := int(oidcFlowTTL.Seconds())
+	if value == "" {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func cookieValue(r *http.Request, name string) string {
+	if c, err := r.Cookie(name); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// safeReturnPath keeps only same-site paths ("/pets/1"), so the login cannot be used as an open redirect.
+func safeReturnPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.ContainsAny(p, "\\\r\n") {
+		return "/"
+	}
+	return p
+}
+
+// oidcDisplayName suggests a display name for a new account; CreateExternalUser sanitizes and de-duplicates it.
+func oidcDisplayName(c *oidc.Claims) string {
+	name := c.PreferredUsername
+	if name == "" || strings.Contains(name, "@") {
+		name = c.Name
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", ".")
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pet-medical/api/internal/config"
+	"github.com/pet-medical/api/internal/oidc"
+)
+
+func TestSafeReturnPath(t *testing.T) {
+	tests := map[string]string{
+		"":                    "/",
+		"/pets/1?tab=weights": "/pets/1?tab=weights",
+		"//evil.example.com":  "/",
+		"https://evil.com":    "/",
+		"/\\evil.example.com": "/",
+		"/ok\r\nSet-Cookie:x": "/",
+	}
+	for in, want := range tests {
+		if got := safeReturnPath(in); got != want {
+			t.Errorf("safeReturnPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOIDCCallback_StateMismatch(t *testing.T) {
+	// The provider is never contacted: a bad state is rejected before the code exchange.
+	h := &AuthHandler{Config: &config.Config{}, OIDC: oidc.New("http://127.0.0.1:1", "client", "", nil)}
+	r := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=c&state=forged", nil)
+	r.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: "expected"})
+	w := httptest.NewRecorder()
+	h.OIDCCallback(w, r)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/login?error=oidc_failed" {
+		t.Fatalf("status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	cleared := 0
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			cleared++
+		}
+	}
+	if cleared != 4 {
+		t.Errorf("cleared %d flow cookies, want 4", cleared)
+	}
+}
+
+func TestOIDCDisplayName(t *testing.T) {
+	if got := oidcDisplayName(&oidc.Claims{PreferredUsername: "jane@example.com", Name: "Jane Doe"}); got != "jane.doe" {
+		t.Errorf("got %q", got)
+	}
+	if got := oidcDisplayName(&oidc.Claims{PreferredUsername: "JDoe"}); got != "jdoe" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestOIDCMFARedirect(t *testing.T) {
+	got := oidcMFARedirect("https://pets.example.com", "/pets/1?tab=a&b", "tok.en")
+	want := "https://pets.example.com/login?return_to=%2Fpets%2F1%3Ftab%3Da%26b#mfa_token=tok.en"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,476 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/webauthn"
+	"gorm.io/gorm"
+)
+
+const (
+	passkeyChallengeTTL = 5 * time.Minute
+	passkeyNameMaxLen   = 100
+	passkeyPurposeReg   = "register"
+	passkeyPurposeLogin = "login"
+)
+
+// PasskeyOptionsResponse is returned by the options endpoints. PublicKey is passed to navigator.credentials.create()
+// or .get() (binary fields are base64url, e.g. via PublicKeyCredential.parseCreationOptionsFromJSON); ChallengeID is
+// sent back with the result.
+type PasskeyOptionsResponse struct {
+	ChallengeID string      `json:"challenge_id"`
+	PublicKey   interface{} `json:"public_key"`
+}
+
+type passkeyRP struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type passkeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type passkeyParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type passkeyDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type passkeySelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+type passkeyCreationOptions struct {
+	RP                     passkeyRP           `json:"rp"`
+	User                   passkeyUser         `json:"user"`
+	Challenge              string              `json:"challenge"`
+	PubKeyCredParams       []passkeyParam      `json:"pubKeyCredParams"`
+	Timeout                int64               `json:"timeout"`
+	Attestation            string              `json:"attestation"`
+	AuthenticatorSelection passkeySelection    `json:"authenticatorSelection"`
+	ExcludeCredentials     []passkeyDescriptor `json:"excludeCredentials"`
+}
+
+type passkeyRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+}
+
+// PasskeyCredentialJSON is a PublicKeyCredential as serialized by its toJSON() method (base64url binary fields).
+// Registration fills AttestationObject; login fills AuthenticatorData, Signature, and UserHandle.
+type PasskeyCredentialJSON struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+		AuthenticatorData string   `json:"authenticatorData"`
+		Signature         string   `json:"signature"`
+		UserHandle        string   `json:"userHandle"`
+	} `json:"response"`
+}
+
+// PasskeyFinishRequest is the body for POST /api/auth/passkeys/register and /api/auth/login/passkey. Name is only
+// used on registration.
+type PasskeyFinishRequest struct {
+	ChallengeID string                `json:"challenge_id"`
+	Name        string                `json:"name"`
+	Credential  PasskeyCredentialJSON `json:"credential"`
+}
+
+// relyingParty scopes passkeys to the host of PUBLIC_URL, or of the request when it is unset.
+func (h *AuthHandler) relyingParty(r *http.Request) (webauthn.RelyingParty, error) {
+	base := h.Config.PublicURL
+	if base == "" {
+		base = h.Config.RequestOrigin(r)
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Hostname() == "" {
+		return webauthn.RelyingParty{}, errors.New("cannot determine relying party from " + base)
+	}
+	return webauthn.RelyingParty{ID: u.Hostname(), Origin: u.Scheme + "://" + u.Host}, nil
+}
+
+// PasskeyRegisterOptions handles POST /api/auth/passkeys/register/options ({"password", "code"}). A passkey skips both
+// password and TOTP at login, so the caller must re-authenticate first (see confirmIdentity); the challenge issued
+// here is what PasskeyRegister requires. Accounts without a password (e.g. created by the trusted proxy) can enroll
+// too.
+func (h *AuthHandler) PasskeyRegisterOptions(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var req ReauthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	rp, err := h.relyingParty(r)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if !h.confirmIdentity(w, &dbUser, req) {
+		log.Print(i18n.Tf("log.auth.passkey_register_failed", u.ID, "re-authentication failed"))
+		return
+	}
+	var existing []models.Passkey
+	if err := h.DB.Where("user_id = ?", u.ID).Find(&existing).Error; err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	challenge, err := newPasskeyChallenge(h.DB, &u.ID, passkeyPurposeReg)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	exclude := make([]passkeyDescriptor, len(existing))
+	for i, p := range existing {
+		exclude[i] = passkeyDescriptor{Type: "public-key", ID: webauthn.EncodeID(p.CredentialID), Transports: splitTransports(p.Transports)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PasskeyOptionsResponse{
+		ChallengeID: challenge.ID.String(),
+		PublicKey: passkeyCreationOptions{
+			RP:        passkeyRP{ID: rp.ID, Name: totpIssuer},
+			User:      passkeyUser{ID: webauthn.EncodeID(dbUser.ID[:]), Name: dbUser.Email, DisplayName: dbUser.DisplayName},
+			Challenge: webauthn.EncodeID(challenge.Challenge),
+			PubKeyCredParams: []passkeyParam{
+				{Type: "public-key", Alg: webauthn.AlgES256},
+				{Type: "public-key", Alg: webauthn.AlgEdDSA},
+				{Type: "public-key", Alg: webauthn.AlgRS256},
+			},
+			Timeout:                passkeyChallengeTTL.Milliseconds(),
+			Attestation:            "none",
+			AuthenticatorSelection: passkeySelection{ResidentKey: "required", RequireResidentKey: true, UserVerification: "required"},
+			ExcludeCredentials:     exclude,
+		},
+	})
+}
+
+// PasskeyRegister handles POST /api/auth/passkeys/register: verifies the new credential and stores it. Responds 201
+// with the passkey.
+func (h *AuthHandler) PasskeyRegister(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var req PasskeyFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len([]rune(name)) > passkeyNameMaxLen {
+		http.Error(w, `{"error":"error.passkey_name_too_long"}`, http.StatusBadRequest)
+		return
+	}
+	rp, err := h.relyingParty(r)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	challenge := consumePasskeyChallenge(h.DB, req.ChallengeID, passkeyPurposeReg)
+	if challenge == nil || challenge.UserID == nil || *challenge.UserID != u.ID {
+		http.Error(w, `{"error":"error.passkey_challenge_invalid"}`, http.StatusBadRequest)
+		return
+	}
+	clientData, err1 := webauthn.DecodeID(req.Credential.Response.ClientDataJSON)
+	attestation, err2 := webauthn.DecodeID(req.Credential.Response.AttestationObject)
+	if err1 != nil || err2 != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	cred, err := rp.VerifyRegistration(challenge.Challenge, clientData, attestation)
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.passkey_register_failed", u.ID, err))
+		http.Error(w, `{"error":"error.passkey_invalid"}`, http.StatusBadRequest)
+		return
+	}
+	var n int64
+	h.DB.Model(&models.Passkey{}).Where("credential_id = ?", cred.ID).Count(&n)
+	if n > 0 {
+		http.Error(w, `{"error":"error.passkey_exists"}`, http.StatusConflict)
+		return
+	}
+	p := models.Passkey{
+		UserID:         u.ID,
+		Name:           name,
+		CredentialID:   cred.ID,
+		PublicKey:      cred.PublicKey,
+		SignCount:      int64(cred.SignCount),
+		Transports:     strings.Join(req.Credential.Response.Transports, ","),
+		BackupEligible: cred.BackupEligible,
+	}
+	if err := h.DB.Create(&p).Error; err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// ListPasskeys handles GET /api/auth/passkeys.
+func (h *AuthHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	list := []models.Passkey{}
+	if err := h.DB.Where("user_id = ?", u.ID).Order("created_at").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// RenamePasskey handles PATCH /api/auth/passkeys/{id} ({"name"}).
+func (h *AuthHandler) RenamePasskey(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > passkeyNameMaxLen {
+		http.Error(w, `{"error":"error.passkey_name_invalid"}`, http.StatusBadRequest)
+		return
+	}
+	var p models.Passkey
+	if err := h.DB.Where("id = ? AND user_id = ?", id, u.ID).First(&p).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Model(&p).Update("name", name).Error; err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// DeletePasskey handles DELETE /api/auth/passkeys/{id} ({"password", "code"}), with the same re-authentication as
+// PasskeyRegisterOptions.
+func (h *AuthHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	var req ReauthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if !h.confirmIdentity(w, &dbUser, req) {
+		return
+	}
+	result := h.DB.Where("id = ? AND user_id = ?", id, u.ID).Delete(&models.Passkey{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PasskeyLoginOptions handles POST /api/auth/login/passkey/options. No account is named: the browser offers the
+// passkeys it holds for this site and the chosen credential identifies the user.
+func (h *AuthHandler) PasskeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	rp, err := h.relyingParty(r)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	challenge, err := newPasskeyChallenge(h.DB, nil, passkeyPurposeLogin)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PasskeyOptionsResponse{
+		ChallengeID: challenge.ID.String(),
+		PublicKey: passkeyRequestOptions{
+			Challenge:        webauthn.EncodeID(challenge.Challenge),
+			RPID:             rp.ID,
+			Timeout:          passkeyChallengeTTL.Milliseconds(),
+			UserVerification: "required",
+		},
+	})
+}
+
+// PasskeyLogin handles POST /api/auth/login/passkey and, on a valid assertion, sets the same cookies and response as
+// Login. A verified passkey already proves possession and user verification, so no TOTP code is asked for.
+func (h *AuthHandler) PasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	var req PasskeyFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	rp, err := h.relyingParty(r)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	challenge := consumePasskeyChallenge(h.DB, req.ChallengeID, passkeyPurposeLogin)
+	if challenge == nil {
+		http.Error(w, `{"error":"error.passkey_challenge_invalid"}`, http.StatusBadRequest)
+		return
+	}
+	rawID := req.Credential.RawID
+	if rawID == "" {
+		rawID = req.Credential.ID
+	}
+	credID, err := webauthn.DecodeID(rawID)
+	clientData, err1 := webauthn.DecodeID(req.Credential.Response.ClientDataJSON)
+	authData, err2 := webauthn.DecodeID(req.Credential.Response.AuthenticatorData)
+	sig, err3 := webauthn.DecodeID(req.Credential.Response.Signature)
+	userHandle, err4 := webauthn.DecodeID(req.Credential.Response.UserHandle)
+	if err != nil || err1 != nil || err2 != nil || err3 != nil || err4 != nil || len(credID) == 0 {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	var p models.Passkey
+	if err := h.DB.Where("credential_id = ?", credID).First(&p).Error; err != nil {
+		log.Print(i18n.Tf("log.auth.passkey_failed", "-", "unknown credential"))
+		http.Error(w, `{"error":"error.passkey_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	// The user handle, when sent, is the user ID given at registration and must belong to the credential's owner.
+	if len(userHandle) > 0 && string(userHandle) != string(p.UserID[:]) {
+		log.Print(i18n.Tf("log.auth.passkey_failed", p.UserID, "user handle mismatch"))
+		http.Error(w, `{"error":"error.passkey_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	count, err := rp.VerifyAssertion(challenge.Challenge, clientData, authData, sig, p.PublicKey, uint32(p.SignCount))
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.passkey_failed", p.UserID, err))
+		http.Error(w, `{"error":"error.passkey_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	var u models.User
+	if err := h.DB.Where("id = ?", p.UserID).First(&u).Error; err != nil {
+		http.Error(w, `{"error":"error.passkey_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	// Conditional on the stored counter so two concurrent logins cannot both advance from the same value.
+	result := h.DB.Model(&models.Passkey{}).Where("id = ? AND sign_count = ?", p.ID, p.SignCount).
+		Updates(map[string]interface{}{"sign_count": int64(count), "last_used_at": time.Now()})
+	if result.Error != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"error.passkey_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	log.Print(i18n.Tf("log.auth.passkey_login", u.ID, p.ID))
+	h.applyUserDefaults(&u)
+	h.startSession(w, r, u)
+}
+
+// newPasskeyChallenge stores a fresh challenge and clears out expired ones.
+func newPasskeyChallenge(db *gorm.DB, userID *uuid.UUID, purpose string) (*models.PasskeyChallenge, error) {
+	b, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	db.Where("expires_at < ?", now).Delete(&models.PasskeyChallenge{})
+	c := models.PasskeyChallenge{UserID: userID, Purpose: purpose, Challenge: b, ExpiresAt: now.Add(passkeyChallengeTTL)}
+	if err := db.Create(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// consumePasskeyChallenge deletes and returns the unexpired challenge with this id and purpose, or nil. Deleting
+// first makes every challenge single-use, whether or not the response then verifies.
+func consumePasskeyChallenge(db *gorm.DB, id, purpose string) *models.PasskeyChallenge {
+	cid, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	var c models.PasskeyChallenge
+	if err := db.Where("id = ? AND purpose = ?", cid, purpose).First(&c).Error; err != nil {
+		return nil
+	}
+	if result := db.Where("id = ?", cid).Delete(&models.PasskeyChallenge{}); result.Error != nil || result.RowsAffected != 1 {
+		return nil
+	}
+	if time.Now().After(c.ExpiresAt) {
+		return nil
+	}
+	return &c
+}
+
+func splitTransports(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
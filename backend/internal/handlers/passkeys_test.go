@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/config"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestRelyingParty(t *testing.T) {
+	h := &AuthHandler{Config: &config.Config{PublicURL: "https://pets.example.com:8443/app"}}
+	rp, err := h.relyingParty(httptest.NewRequest("POST", "http://internal:8080/api/auth/login/passkey/options", nil))
+	if err != nil || rp.ID != "pets.example.com" || rp.Origin != "https://pets.example.com:8443" {
+		t.Errorf("from PUBLIC_URL: %+v, %v", rp, err)
+	}
+	h.Config.PublicURL = ""
+	rp, err = h.relyingParty(httptest.NewRequest("POST", "http://localhost:3000/api/auth/login/passkey/options", nil))
+	if err != nil || rp.ID != "localhost" || rp.Origin != "http://localhost:3000" {
+		t.Errorf("from request: %+v, %v", rp, err)
+	}
+}
+
+func TestSplitTransports(t *testing.T) {
+	if got := splitTransports(""); got != nil {
+		t.Errorf("empty = %v", got)
+	}
+	if got := splitTransports("internal,hybrid"); len(got) != 2 || got[1] != "hybrid" {
+		t.Errorf("split = %v", got)
+	}
+}
+
+func TestConfirmIdentity(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &AuthHandler{Config: &config.Config{}}
+	tests := []struct {
+		name    string
+		user    models.User
+		req     ReauthRequest
+		wantErr string
+	}{
+		{"password ok", models.User{PasswordHash: hash}, ReauthRequest{Password: "correct horse"}, ""},
+		{"wrong password", models.User{PasswordHash: hash}, ReauthRequest{Password: "nope"}, "error.invalid_credentials"},
+		{"session alone", models.User{PasswordHash: hash}, ReauthRequest{}, "error.invalid_credentials"},
+		// No secret stored, so no code can match; the database is never reached.
+		{"2fa code missing", models.User{PasswordHash: hash, TOTPEnabled: true}, ReauthRequest{Password: "correct horse"}, "error.invalid_code"},
+		{"passwordless proxy account", models.User{}, ReauthRequest{}, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ok := h.confirmIdentity(w, &tt.user, tt.req)
+		if ok != (tt.wantErr == "") || (tt.wantErr != "" && !strings.Contains(w.Body.String(), tt.wantErr)) {
+			t.Errorf("%s: ok=%v body=%s", tt.name, ok, w.Body.String())
+		}
+	}
+}
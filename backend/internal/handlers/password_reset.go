@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/notify"
+)
+
+// resetResendInterval is the minimum time between reset emails to one account, however many IPs ask.
+const resetResendInterval = 2 * time.Minute
+
+// ForgotPasswordRequest is the body for POST /api/auth/forgot-password.
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest is the body for POST /api/auth/reset-password.
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ForgotPassword handles POST /api/auth/forgot-password. It answers the same way whether or not the email belongs to
+// an account, and issues the token and sends the email in the background so response time does not tell either.
+// Accounts without a password (proxy or single sign-on) get no email. Needs SMTP and PUBLIC_URL: the link is never
+// built from the request's Host header, which a caller controls.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if h.Mailer == nil || h.ResetStore == nil || h.Config.PublicURL == "" {
+		http.Error(w, `{"error":"error.password_reset_unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+	if email == "" {
+		http.Error(w, `{"error":"error.email_required"}`, http.StatusBadRequest)
+		return
+	}
+	var u models.User
+	if err := h.DB.Where("LOWER(TRIM(email)) = ?", email).First(&u).Error; err == nil && u.PasswordHash != "" {
+		go h.sendPasswordReset(u)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "ok"})
+}
+
+// sendPasswordReset issues a token and emails it. It runs in the background: the token is written to the database
+// only for existing accounts, so doing it before the response would let the response time reveal which emails exist.
+func (h *AuthHandler) sendPasswordReset(u models.User) {
+	if h.ResetStore.RecentlyIssued(u.ID, time.Now().Add(-resetResendInterval)) {
+		return
+	}
+	token, err := h.ResetStore.Create(u.ID)
+	if err != nil {
+		log.Print(i18n.Tf("log.auth.password_reset_send_error", u.ID, err))
+		return
+	}
+	lang := u.Language
+	if lang == "" {
+		lang = h.DefaultLanguage
+	}
+	link := h.Config.PublicURL + "/reset-password?token=" + url.QueryEscape(token)
+	subject, body := notify.RenderPasswordReset(lang, u.DisplayName, link, auth.PasswordResetTTL)
+	if err := h.Mailer.Send(u.Email, subject, body); err != nil {
+		log.Print(i18n.Tf("log.auth.password_reset_send_error", u.ID, err))
+		return
+	}
+	log.Print(i18n.Tf("log.auth.password_reset_requested", u.ID))
+}
+
+// ResetPassword handles POST /api/auth/reset-password: redeems the emailed token, sets the new password, and signs
+// the user out everywhere. Two-factor, when enabled, still applies at the next login.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if h.ResetStore == nil {
+		http.Error(w, `{"error":"error.password_reset_unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	req.NewPassword = strings.TrimSpace(req.NewPassword)
+	if req.Token == "" || req.NewPassword == "" {
+		http.Error(w, `{"error":"error.password_required"}`, http.StatusBadRequest)
+		return
+	}
+	// Checked before the token is spent, so a rejected password does not burn the link.
+	if len(req.NewPassword) < 8 {
+		http.Error(w, `{"error":"error.password_too_short"}`, http.StatusBadRequest)
+		return
+	}
+	userID, err := h.ResetStore.Consume(strings.TrimSpace(req.Token))
+	if err != nil {
+		http.Error(w, `{"error":"error.reset_token_invalid"}`, http.StatusBadRequest)
+		return
+	}
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.Model(&models.User{}).Where("id = ?", userID).Update("password_hash", hash).Error; err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.RefreshStore.RevokeAllForUser(userID); err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	log.Print(i18n.Tf("log.auth.password_reset_done", userID))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "ok"})
+}
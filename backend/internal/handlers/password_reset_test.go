@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/config"
+)
+
+type discardMailer struct{}
+
+func (discardMailer) Send(to, subject, body string) error { return nil }
+
+func TestForgotPassword_RequiresMailerAndPublicURL(t *testing.T) {
+	for name, h := range map[string]*AuthHandler{
+		"no smtp":       {Config: &config.Config{PublicURL: "https://pets.example.com"}, ResetStore: auth.NewResetStore(nil)},
+		"no public url": {Config: &config.Config{}, ResetStore: auth.NewResetStore(nil), Mailer: discardMailer{}},
+	} {
+		w := httptest.NewRecorder()
+		h.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/forgot-password", strings.NewReader(`{"email":"a@b.c"}`)))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: status %d", name, w.Code)
+		}
+	}
+}
+
+func TestResetPassword_ValidatesBeforeSpendingToken(t *testing.T) {
+	// The store has no database: reaching it would panic, so these must be rejected first.
+	h := &AuthHandler{Config: &config.Config{}, ResetStore: auth.NewResetStore(nil)}
+	for body, wantErr := range map[string]string{
+		`{"token":"t","new_password":"short"}`:      "error.password_too_short",
+		`{"token":"","new_password":"long enough"}`: "error.password_required",
+		`not json`: "error.invalid_request",
+	} {
+		w := httptest.NewRecorder()
+		h.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/reset-password", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), wantErr) {
+			t.Errorf("%s: %d %s", body, w.Code, w.Body.String())
+		}
+	}
+}
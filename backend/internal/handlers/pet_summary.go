@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // avatar formats accepted by photo upload; WebP avatars are skipped
+	_ "image/jpeg"
+	_ "image/png"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/pdf"
+)
+
+const (
+	summaryWeightCount = 10
+	summaryDueSoonDays = 30
+	summaryMargin      = 48.0
+)
+
+var (
+	summaryBlack = color.Gray{Y: 0x22}
+	summaryGray  = color.Gray{Y: 0x77}
+	summaryRule  = color.Gray{Y: 0xcc}
+	summaryShade = color.Gray{Y: 0xee}
+	summaryRed   = color.RGBA{R: 0xc0, G: 0x26, B: 0x26, A: 0xff}
+	summaryAmber = color.RGBA{R: 0xb4, G: 0x6a, B: 0x00, A: 0xff}
+	summaryGreen = color.RGBA{R: 0x1d, G: 0x7a, B: 0x3a, A: 0xff}
+)
+
+// Vaccination statuses in the summary table.
+const (
+	summaryStatusOverdue    = "overdue"
+	summaryStatusDue        = "due"
+	summaryStatusCurrent    = "current"
+	summaryStatusSuperseded = "superseded" // a later dose of the same vaccine exists
+	summaryStatusNoDue      = "no_due"
+)
+
+// PetSummaryData is everything the summary PDF shows. Vaccinations must be sorted newest first.
+type PetSummaryData struct {
+	Pet          models.Pet
+	Avatar       image.Image // nil when the pet has no photo or it cannot be decoded
+	Vaccinations []models.Vaccination
+	Weights      []models.WeightEntry // newest first
+	Language     string
+	WeightUnit   string // "kg" or "lbs"
+	Today        string // YYYY-MM-DD
+}
+
+// Summary handles GET /pets/{id}/summary.pdf: a printable health summary in the caller's language.
+func (h *PetsHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, id, models.PetRoleViewer) {
+		return
+	}
+	var user models.User
+	if err := h.DB.Select("language, weight_unit").Where("id = ?", u.ID).First(&user).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	data := PetSummaryData{Language: user.Language, WeightUnit: user.WeightUnit, Today: time.Now().Format(dateLayout)}
+	if err := h.DB.Where("id = ?", id).First(&data.Pet).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Where("pet_id = ?", id).Order("administered_at DESC, created_at DESC").Find(&data.Vaccinations).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.Where("pet_id = ?", id).Order("measured_at DESC, created_at DESC").Limit(summaryWeightCount).Find(&data.Weights).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	data.Avatar = h.loadAvatar(data.Pet)
+
+	var buf bytes.Buffer
+	if err := renderPetSummary(data).Write(&buf); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	filename := downloadFilename(data.Pet.Name, "summary", "pdf")
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+// loadAvatar decodes the pet's avatar from UploadDir. PhotoURL is "/api/uploads/" + the photo's relative path.
+func (h *PetsHandler) loadAvatar(p models.Pet) image.Image {
+	if h.UploadDir == "" || p.PhotoURL == nil || !strings.HasPrefix(*p.PhotoURL, "/api/uploads/") {
+		return nil
+	}
+	rel := filepath.FromSlash(strings.TrimPrefix(*p.PhotoURL, "/api/uploads/"))
+	if !filepath.IsLocal(rel) {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(h.UploadDir, rel))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// downloadFilename builds an ASCII download name like "Rex-summary.pdf".
+func downloadFilename(petName, suffix, ext string) string {
+	var b strings.Builder
+	for _, r := range petName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("pet")
+	}
+	return b.String() + "-" + suffix + "." + ext
+}
+
+// vaccinationStatuses classifies each vaccination relative to today, keyed by ID. Only the latest dose of each
+// vaccine is overdue, due, or current; earlier doses are superseded. vaccs must be sorted newest first.
+func vaccinationStatuses(today string, vaccs []models.Vaccination) map[uuid.UUID]string {
+	todayT, _ := time.Parse(dateLayout, today)
+	out := make(map[uuid.UUID]string, len(vaccs))
+	for _, v := range vaccs {
+		out[v.ID] = summaryStatusSuperseded
+	}
+	for _, v := range latestDoses(vaccs) {
+		if v.NextDue == nil || *v.NextDue == "" {
+			out[v.ID] = summaryStatusNoDue
+			continue
+		}
+		due, err := time.Parse(dateLayout, *v.NextDue)
+		if err != nil {
+			out[v.ID] = summaryStatusNoDue
+			continue
+		}
+		switch days := int(due.Sub(todayT).Hours() / 24); {
+		case days < 0:
+			out[v.ID] = summaryStatusOverdue
+		case days <= summaryDueSoonDays:
+			out[v.ID] = summaryStatusDue
+		default:
+			out[v.ID] = summaryStatusCurrent
+		}
+	}
+	return out
+}
+
+// summaryPage tracks the cursor while laying out a report, adding pages (with a footer) as content overflows.
+type summaryPage struct {
+	doc    *pdf.Document
+	y      float64
+	footer string
+	lang   string
+}
+
+func (p *summaryPage) finishPage() {
+	bottom := pdf.PageHeight - 28
+	p.doc.Line(summaryMargin, bottom-12, pdf.PageWidth-summaryMargin, bottom-12, 0.5, summaryRule)
+	p.doc.Text(summaryMargin, bottom, pdf.Regular, 8, summaryGray, p.footer)
+	p.doc.TextRight(pdf.PageWidth-summaryMargin, bottom, pdf.Regular, 8, summaryGray,
+		i18n.TfLang(p.lang, "pdf.summary.page", p.doc.PageCount()))
+}
+
+// ensure starts a new page unless h more points fit above the footer.
+func (p *summaryPage) ensure(h float64) {
+	if p.y+h <= pdf.PageHeight-64 {
+		return
+	}
+	p.finishPage()
+	p.doc.AddPage()
+	p.y = summaryMargin
+}
+
+func (p *summaryPage) heading(s string) {
+	p.ensure(48)
+	p.y += 26
+	p.doc.Text(summaryMargin, p.y, pdf.Bold, 13, summaryBlack, s)
+	p.y += 6
+	p.doc.Line(summaryMargin, p.y, pdf.PageWidth-summaryMargin, p.y, 0.75, summaryRule)
+	p.y += 4
+}
+
+// labelRows draws label/value pairs, truncating values to width.
+func (p *summaryPage) labelRows(rows [][2]string, width float64) {
+	for _, row := range rows {
+		p.ensure(16)
+		p.y += 16
+		p.doc.Text(summaryMargin, p.y, pdf.Bold, 10, summaryGray, row[0])
+		p.doc.Text(summaryMargin+110, p.y, pdf.Regular, 10, summaryBlack, truncate(pdf.Regular, 10, width, row[1]))
+	}
+}
+
+type summaryColumn struct {
+	x     float64
+	label string
+}
+
+func (p *summaryPage) tableHeader(cols []summaryColumn) {
+	p.ensure(40)
+	p.doc.FillRect(summaryMargin, p.y+4, pdf.PageWidth-2*summaryMargin, 18, summaryShade)
+	for _, c := range cols {
+		p.doc.Text(c.x, p.y+17, pdf.Bold, 9, summaryBlack, c.label)
+	}
+	p.y += 22
+}
+
+// truncate shortens s with an ellipsis to fit width.
+func truncate(f pdf.Font, size, width float64, s string) string {
+	if pdf.TextWidth(f, size, s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdf.TextWidth(f, size, string(r)+"…") > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+func derefOr(s *string, fallback string) string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return fallback
+	}
+	return strings.TrimSpace(*s)
+}
+
+// renderPetSummary lays out the summary PDF: profile with avatar, vaccinations with next-due status, recent weights,
+// and notes.
+func renderPetSummary(d PetSummaryData) *pdf.Document {
+	lang := d.Language
+	t := func(key string) string { return i18n.TLang(lang, "pdf.summary."+key) }
+	doc := pdf.New()
+	doc.Title = d.Pet.Name + " - " + t("title")
+	p := &summaryPage{doc: doc, y: summaryMargin, lang: lang, footer: i18n.TfLang(lang, "pdf.summary.generated", d.Today)}
+	right := pdf.PageWidth - summaryMargin
+
+	// Header: title, name, and the avatar on the right.
+	doc.Text(summaryMargin, p.y+10, pdf.Regular, 10, summaryGray, strings.ToUpper(t("title")))
+	doc.Text(summaryMargin, p.y+38, pdf.Bold, 24, summaryBlack, truncate(pdf.Bold, 24, 380, d.Pet.Name))
+	const avatarSize = 96.0
+	if d.Avatar != nil {
+		b := d.Avatar.Bounds()
+		w, h := avatarSize, avatarSize
+		if b.Dx() > b.Dy() {
+			h = avatarSize * float64(b.Dy()) / float64(b.Dx())
+		} else if b.Dy() > 0 {
+			w = avatarSize * float64(b.Dx()) / float64(b.Dy())
+		}
+		doc.Image(d.Avatar, right-w, p.y, w, h)
+	}
+	p.y += 52
+
+	// Profile.
+	dash := "—"
+	rows := [][2]string{
+		{t("species"), derefOr(d.Pet.Species, dash)},
+		{t("breed"), derefOr(d.Pet.Breed, dash)},
+		{t("date_of_birth"), derefOr(d.Pet.DateOfBirth, dash)},
+		{t("microchip"), derefOr(d.Pet.MicrochipID, dash)},
+	}
+	if d.Pet.MicrochipID != nil && d.Pet.MicrochipCompany != nil && strings.TrimSpace(*d.Pet.MicrochipCompany) != "" {
+		rows[3][1] += " (" + strings.TrimSpace(*d.Pet.MicrochipCompany) + ")"
+	}
+	p.labelRows(rows, 260)
+	if p.y < summaryMargin+avatarSize+8 {
+		p.y = summaryMargin + avatarSize + 8
+	}
+
+	// Vaccinations.
+	p.heading(t("vaccinations"))
+	cols := []summaryColumn{
+		{summaryMargin + 6, t("vaccine")},
+		{summaryMargin + 200, t("administered")},
+		{summaryMargin + 290, t("next_due")},
+		{summaryMargin + 380, t("status")},
+	}
+	if len(d.Vaccinations) == 0 {
+		p.y += 18
+		doc.Text(summaryMargin, p.y, pdf.Regular, 10, summaryGray, t("no_vaccinations"))
+	} else {
+		p.tableHeader(cols)
+		statuses := vaccinationStatuses(d.Today, d.Vaccinations)
+		for _, v := range d.Vaccinations {
+			if p.y+18 > pdf.PageHeight-64 {
+				p.ensure(18)
+				p.tableHeader(cols)
+			}
+			p.y += 16
+			status := statuses[v.ID]
+			var statusColor color.Color = summaryGray
+			switch status {
+			case summaryStatusOverdue:
+				statusColor = summaryRed
+			case summaryStatusDue:
+				statusColor = summaryAmber
+			case summaryStatusCurrent:
+				statusColor = summaryGreen
+			}
+			doc.Text(cols[0].x, p.y, pdf.Regular, 10, summaryBlack, truncate(pdf.Regular, 10, 188, v.Name))
+			doc.Text(cols[1].x, p.y, pdf.Regular, 10, summaryBlack, v.AdministeredAt)
+			doc.Text(cols[2].x, p.y, pdf.Regular, 10, summaryBlack, derefOr(v.NextDue, dash))
+			doc.Text(cols[3].x, p.y, pdf.Bold, 10, statusColor, t("status."+status))
+			p.y += 4
+			doc.Line(summaryMargin, p.y, right, p.y, 0.25, summaryRule)
+		}
+	}
+
+	// Recent weights, in the user's unit.
+	p.heading(t("weights"))
+	if len(d.Weights) == 0 {
+		p.y += 18
+		doc.Text(summaryMargin, p.y, pdf.Regular, 10, summaryGray, t("no_weights"))
+	} else {
+		wcols := []summaryColumn{{summaryMargin + 6, t("date")}, {summaryMargin + 200, t("weight")}}
+		p.tableHeader(wcols)
+		for _, e := range d.Weights {
+			if p.y+18 > pdf.PageHeight-64 {
+				p.ensure(18)
+				p.tableHeader(wcols)
+			}
+			p.y += 16
+			value := fmt.Sprintf("%.1f lbs", e.WeightLbs)
+			if d.WeightUnit == "kg" {
+				value = fmt.Sprintf("%.1f kg", e.WeightLbs/lbsPerKg)
+			}
+			if e.Approximate {
+				value = "~" + value
+			}
+			doc.Text(wcols[0].x, p.y, pdf.Regular, 10, summaryBlack, e.MeasuredAt)
+			doc.Text(wcols[1].x, p.y, pdf.Regular, 10, summaryBlack, value)
+			p.y += 4
+			doc.Line(summaryMargin, p.y, right, p.y, 0.25, summaryRule)
+		}
+	}
+
+	// Notes.
+	if notes := derefOr(d.Pet.Notes, ""); notes != "" {
+		p.heading(t("notes"))
+		p.y += 4
+		for _, line := range pdf.Wrap(pdf.Regular, 10, right-summaryMargin, notes) {
+			p.ensure(14)
+			p.y += 14
+			doc.Text(summaryMargin, p.y, pdf.Regular, 10, summaryBlack, line)
+		}
+	}
+
+	p.finishPage()
+	return doc
+}
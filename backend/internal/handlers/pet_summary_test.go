@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestVaccinationStatuses(t *testing.T) {
+	petID := uuid.New()
+	due := func(s string) *string { return &s }
+	vaccs := []models.Vaccination{ // newest first
+		{ID: uuid.New(), PetID: petID, Name: "Rabies", AdministeredAt: "2025-06-01", NextDue: due("2026-06-01")},
+		{ID: uuid.New(), PetID: petID, Name: "DHPP", AdministeredAt: "2025-03-01", NextDue: due("2025-06-20")},
+		{ID: uuid.New(), PetID: petID, Name: "Lepto", AdministeredAt: "2025-02-01", NextDue: due("2025-05-01")},
+		{ID: uuid.New(), PetID: petID, Name: "rabies ", AdministeredAt: "2024-06-01", NextDue: due("2025-06-01")},
+		{ID: uuid.New(), PetID: petID, Name: "Bordetella", AdministeredAt: "2024-01-01"},
+	}
+	got := vaccinationStatuses("2025-06-10", vaccs)
+	want := []string{summaryStatusCurrent, summaryStatusDue, summaryStatusOverdue, summaryStatusSuperseded, summaryStatusNoDue}
+	for i, v := range vaccs {
+		if got[v.ID] != want[i] {
+			t.Errorf("%s %s: status = %q, want %q", v.Name, v.AdministeredAt, got[v.ID], want[i])
+		}
+	}
+}
+
+func TestDownloadFilename(t *testing.T) {
+	tests := map[string]string{
+		"Rex":         "Rex-summary.pdf",
+		"Mr. Whisker": "Mr-Whisker-summary.pdf",
+		"Señor/Gato":  "SeorGato-summary.pdf",
+		"日本":          "pet-summary.pdf",
+	}
+	for in, want := range tests {
+		if got := downloadFilename(in, "summary", "pdf"); got != want {
+			t.Errorf("downloadFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRenderPetSummary_PaginatesLongRecords(t *testing.T) {
+	notes := "Allergic to chicken."
+	data := PetSummaryData{
+		Pet:        models.Pet{ID: uuid.New(), Name: "Rex", Notes: &notes},
+		Avatar:     image.NewRGBA(image.Rect(0, 0, 20, 10)),
+		Language:   "de",
+		WeightUnit: "kg",
+		Today:      "2025-06-10",
+	}
+	for i := 0; i < 60; i++ {
+		data.Vaccinations = append(data.Vaccinations, models.Vaccination{ID: uuid.New(), Name: "Rabies", AdministeredAt: "2025-01-01"})
+	}
+	doc := renderPetSummary(data)
+	if doc.PageCount() < 2 {
+		t.Errorf("pages = %d, want the vaccination table to overflow onto a second page", doc.PageCount())
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil || !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("write: %v", err)
+	}
+}
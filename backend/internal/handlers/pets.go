@@ -6,18 +6,30 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/pet-medical/api/internal/middleware"
 	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/webhooks"
 	"gorm.io/gorm"
 )
 
 type PetsHandler struct {
 	DB        *gorm.DB
-	UploadDir string // optional; required for Delete to remove document/photo files
+	UploadDir string               // optional; required for Delete to remove document/photo files
+	Webhooks  *webhooks.Dispatcher // optional; emits pet.* events
+}
+
+// PetDetail is the Get response: the pet, the caller's role on it, and any related records requested via ?include=.
+// List returns the same shape without the related records.
+type PetDetail struct {
+	models.Pet
+	Role       string                     `json:"role"` // owner, editor, or viewer
+	Allergies  *[]models.Allergy          `json:"allergies,omitempty"`
+	Conditions *[]models.ChronicCondition `json:"conditions,omitempty"`
 }
 
 func (h *PetsHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -27,16 +39,37 @@ func (h *PetsHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var pets []models.Pet
-	err := h.DB.Where("user_id = ?", u.ID).Order("name").Find(&pets).Error
+	err := h.DB.Where("id IN (?)", models.AccessiblePetIDs(h.DB, u.ID)).Order("name").Find(&pets).Error
 	if err != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
 	}
-	if pets == nil {
-		pets = []models.Pet{}
+	// Same precedence as petRole, without a query per pet.
+	var memberships []models.PetMembership
+	h.DB.Where("user_id = ?", u.ID).Find(&memberships)
+	roles := make(map[uuid.UUID]string, len(memberships))
+	for _, m := range memberships {
+		roles[m.PetID] = m.Role
+	}
+	var households []models.HouseholdMember
+	h.DB.Where("user_id = ?", u.ID).Find(&households)
+	householdRoles := make(map[uuid.UUID]string, len(households))
+	for _, m := range households {
+		householdRoles[m.HouseholdID] = models.HouseholdPetRole(m.Role)
+	}
+	out := make([]PetDetail, 0, len(pets))
+	for _, p := range pets {
+		role := roles[p.ID]
+		if p.HouseholdID != nil && models.PetRoleRank(householdRoles[*p.HouseholdID]) > models.PetRoleRank(role) {
+			role = householdRoles[*p.HouseholdID]
+		}
+		if p.UserID == u.ID {
+			role = models.PetRoleOwner
+		}
+		out = append(out, PetDetail{Pet: p, Role: role})
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(pets)
+	json.NewEncoder(w).Encode(out)
 }
 
 func (h *PetsHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -51,14 +84,36 @@ func (h *PetsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	var pet models.Pet
-	err = h.DB.Where("id = ? AND user_id = ?", id, u.ID).First(&pet).Error
-	if err != nil {
+	out := PetDetail{Role: petRole(h.DB, id, u.ID)}
+	if out.Role == "" {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Where("id = ?", id).First(&out.Pet).Error; err != nil {
 		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
 		return
 	}
+	// ?include=allergies,conditions adds the pet's active allergies and chronic conditions.
+	for _, inc := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(strings.ToLower(inc)) {
+		case "allergies":
+			list := []models.Allergy{}
+			if err := h.DB.Where("pet_id = ? AND status = ?", id, models.HealthStatusActive).Order("allergen").Find(&list).Error; err != nil {
+				http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+				return
+			}
+			out.Allergies = &list
+		case "conditions":
+			list := []models.ChronicCondition{}
+			if err := h.DB.Where("pet_id = ? AND status = ?", id, models.HealthStatusActive).Order("diagnosis").Find(&list).Error; err != nil {
+				http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+				return
+			}
+			out.Conditions = &list
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(pet)
+	json.NewEncoder(w).Encode(out)
 }
 
 func (h *PetsHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -82,10 +137,19 @@ func (h *PetsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid input"}`, http.StatusBadRequest)
 		return
 	}
+	if pet.ClinicID != nil && !clinicBelongsToUser(h.DB, *pet.ClinicID, u.ID) {
+		http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+		return
+	}
+	if pet.HouseholdID != nil && householdRole(h.DB, *pet.HouseholdID, u.ID) == "" {
+		http.Error(w, `{"error":"invalid household_id"}`, http.StatusBadRequest)
+		return
+	}
 	if err := h.DB.Create(&pet).Error; err != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
 	}
+	h.Webhooks.Emit(pet.UserID, webhooks.EventPetCreated, pet)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(pet)
@@ -103,13 +167,15 @@ func (h *PetsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
+	if !requirePetRole(w, r, h.DB, id, models.PetRoleEditor) {
+		return
+	}
 	var pet models.Pet
 	if err := json.NewDecoder(r.Body).Decode(&pet); err != nil {
 		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
 		return
 	}
 	pet.ID = id
-	pet.UserID = u.ID
 	if pet.Name == "" {
 		http.Error(w, `{"error":"name required"}`, http.StatusBadRequest)
 		return
@@ -118,9 +184,14 @@ func (h *PetsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
-	result := h.DB.Model(&models.Pet{}).Where("id = ? AND user_id = ?", id, u.ID).Updates(map[string]interface{}{
+	if pet.ClinicID != nil && !clinicBelongsToUser(h.DB, *pet.ClinicID, petCreatorID(h.DB, id)) {
+		http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.Pet{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"name": pet.Name, "species": pet.Species, "breed": pet.Breed, "date_of_birth": pet.DateOfBirth,
 		"gender": pet.Gender, "fixed": pet.Fixed, "color": pet.Color, "microchip_id": pet.MicrochipID, "notes": pet.Notes, "photo_url": pet.PhotoURL,
+		"clinic_id": pet.ClinicID,
 		"updated_at": time.Now(),
 	})
 	if result.Error != nil {
@@ -132,6 +203,7 @@ func (h *PetsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.DB.Where("id = ?", id).First(&pet)
+	emitPetEvent(h.Webhooks, h.DB, id, webhooks.EventPetUpdated, pet)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(pet)
 }
@@ -148,12 +220,10 @@ func (h *PetsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	// Verify ownership
-	var count int64
-	if h.DB.Model(&models.Pet{}).Where("id = ? AND user_id = ?", id, u.ID).Count(&count).Error != nil || count == 0 {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, id, models.PetRoleOwner) {
 		return
 	}
+	creatorID := petCreatorID(h.DB, id) // read before the row is gone; pet.deleted goes to the creator's webhooks
 	// Delete uploaded files from disk (documents and photos) when UploadDir is set
 	if h.UploadDir != "" {
 		var docs []models.Document
@@ -176,11 +246,23 @@ func (h *PetsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	h.DB.Where("pet_id = ?", id).Delete(&models.WeightEntry{})
 	h.DB.Where("pet_id = ?", id).Delete(&models.Document{})
 	h.DB.Where("pet_id = ?", id).Delete(&models.PetPhoto{})
-	result := h.DB.Where("id = ? AND user_id = ?", id, u.ID).Delete(&models.Pet{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.MedicationDose{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.Medication{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.VetVisit{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.Allergy{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.ChronicCondition{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.LabAnalyte{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.LabPanel{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.PetMembership{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.PetInvitation{})
+	h.DB.Where("share_link_id IN (?)", h.DB.Model(&models.ShareLink{}).Select("id").Where("pet_id = ?", id)).Delete(&models.ShareLinkAccess{})
+	h.DB.Where("pet_id = ?", id).Delete(&models.ShareLink{})
+	result := h.DB.Where("id = ?", id).Delete(&models.Pet{})
 	if result.Error != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
 	}
+	h.Webhooks.Emit(creatorID, webhooks.EventPetDeleted, map[string]uuid.UUID{"id": id})
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -24,16 +24,6 @@ type PhotosHandler struct {
 	MaxPhotoBytes  int64 // max upload size; 0 = use default 10MB
 }
 
-func (h *PhotosHandler) ensurePetOwnership(r *http.Request, petID uuid.UUID) bool {
-	u := middleware.GetUser(r.Context())
-	if u == nil {
-		return false
-	}
-	var count int64
-	h.DB.Model(&models.Pet{}).Where("id = ? AND user_id = ?", petID, u.ID).Count(&count)
-	return count > 0
-}
-
 func (h *PhotosHandler) List(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
@@ -50,8 +40,7 @@ func (h *PhotosHandler) List(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
 		return
 	}
 	debuglog.Debugf("photos list: pet_id=%s", petID)
@@ -84,8 +73,7 @@ func (h *PhotosHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	maxBytes := h.MaxPhotoBytes
@@ -171,8 +159,7 @@ func (h *PhotosHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	var photo models.PetPhoto
@@ -212,8 +199,7 @@ func (h *PhotosHandler) SetAvatar(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	var photo models.PetPhoto
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/middleware"
+	"gorm.io/gorm"
+)
+
+// SessionDTO is one signed-in device in GET /api/auth/sessions. Current marks the session making the request.
+type SessionDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+// ListSessions handles GET /api/auth/sessions.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	list, err := h.RefreshStore.List(u.ID)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	currentHash := ""
+	if c, err := r.Cookie(refreshCookieName); err == nil && c.Value != "" {
+		currentHash = auth.HashToken(c.Value)
+	}
+	out := make([]SessionDTO, 0, len(list))
+	for _, s := range list {
+		out = append(out, SessionDTO{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    currentHash != "" && s.TokenHash == currentHash,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// RevokeSession handles DELETE /api/auth/sessions/{id}. The device can no longer refresh; an access token it already
+// holds stays valid until it expires (JWT_ACCESS_TTL_MIN).
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	found, err := h.RefreshStore.RevokeSession(u.ID, id)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	log.Print(i18n.Tf("log.auth.sessions_revoked", u.ID, 1))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions handles POST /api/auth/sessions/revoke-others ("log out everywhere else"). The current session
+// is identified by the refresh cookie, so a request without one is rejected rather than logging out every device.
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	c, err := r.Cookie(refreshCookieName)
+	if err != nil || c.Value == "" {
+		http.Error(w, `{"error":"error.session_unknown"}`, http.StatusBadRequest)
+		return
+	}
+	current, err := h.RefreshStore.Lookup(c.Value)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err != nil || current.UserID != u.ID {
+		http.Error(w, `{"error":"error.session_unknown"}`, http.StatusBadRequest)
+		return
+	}
+	n, err := h.RefreshStore.RevokeOthers(u.ID, current.ID)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	log.Print(i18n.Tf("log.auth.sessions_revoked", u.ID, n))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"revoked": n})
+}
+
+// device describes the requesting client for the session list.
+func (h *AuthHandler) device(r *http.Request) auth.Device {
+	return auth.Device{UserAgent: r.UserAgent(), IP: h.Config.ClientIP(r)}
+}
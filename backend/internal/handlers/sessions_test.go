@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/config"
+	"github.com/pet-medical/api/internal/middleware"
+)
+
+func TestRevokeOtherSessions_RequiresCurrentSession(t *testing.T) {
+	// Without the refresh cookie the current session is unknown; the store (no database) must not be reached,
+	// otherwise every session including this one would be a candidate for deletion.
+	h := &AuthHandler{Config: &config.Config{}, RefreshStore: auth.NewRefreshStore(nil)}
+	r := httptest.NewRequest(http.MethodPost, "/api/auth/sessions/revoke-others", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, &middleware.UserInfo{ID: uuid.New()}))
+	w := httptest.NewRecorder()
+	h.RevokeOtherSessions(w, r)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "error.session_unknown") {
+		t.Errorf("status %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLogout_WithoutCookieClearsCookies(t *testing.T) {
+	h := &AuthHandler{Config: &config.Config{}, RefreshStore: auth.NewRefreshStore(nil)}
+	w := httptest.NewRecorder()
+	h.Logout(w, httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	cleared := 0
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			cleared++
+		}
+	}
+	if cleared != 2 {
+		t.Errorf("cleared %d cookies, want access and refresh", cleared)
+	}
+}
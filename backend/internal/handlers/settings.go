@@ -26,8 +26,13 @@ type SettingsDTO struct {
 	DisplayName string `json:"display_name,omitempty"`
 	Role        string `json:"role,omitempty"`
 	IsOnlyAdmin bool   `json:"is_only_admin,omitempty"`
+	// Reminder email opt-in and days before next_due to send. Omitted on update = unchanged.
+	ReminderEmails   *bool `json:"reminder_emails,omitempty"`
+	ReminderLeadDays *int  `json:"reminder_lead_days,omitempty"`
 }
 
+const maxReminderLeadDays = 365
+
 func (h *SettingsHandler) GetMine(w http.ResponseWriter, r *http.Request) {
 	u := middleware.GetUser(r.Context())
 	if u == nil {
@@ -50,7 +55,10 @@ func (h *SettingsHandler) GetMine(w http.ResponseWriter, r *http.Request) {
 		language = h.DefaultLanguage
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(SettingsDTO{WeightUnit: weightUnit, Currency: currency, Language: language})
+	json.NewEncoder(w).Encode(SettingsDTO{
+		WeightUnit: weightUnit, Currency: currency, Language: language,
+		ReminderEmails: &dbUser.ReminderEmails, ReminderLeadDays: &dbUser.ReminderLeadDays,
+	})
 }
 
 func (h *SettingsHandler) UpdateMine(w http.ResponseWriter, r *http.Request) {
@@ -80,15 +88,31 @@ func (h *SettingsHandler) UpdateMine(w http.ResponseWriter, r *http.Request) {
 	if body.Language == "" {
 		body.Language = h.DefaultLanguage
 	}
-	result := h.DB.Model(&models.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+	updates := map[string]interface{}{
 		"weight_unit": body.WeightUnit, "currency": body.Currency, "language": body.Language,
-	})
+	}
+	if body.ReminderEmails != nil {
+		updates["reminder_emails"] = *body.ReminderEmails
+	}
+	if body.ReminderLeadDays != nil {
+		if *body.ReminderLeadDays < 0 || *body.ReminderLeadDays > maxReminderLeadDays {
+			http.Error(w, `{"error":"invalid reminder_lead_days"}`, http.StatusBadRequest)
+			return
+		}
+		updates["reminder_lead_days"] = *body.ReminderLeadDays
+	}
+	result := h.DB.Model(&models.User{}).Where("id = ?", u.ID).Updates(updates)
 	if result.Error != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
 	}
+	var dbUser models.User
+	h.DB.Select("reminder_emails, reminder_lead_days").Where("id = ?", u.ID).First(&dbUser)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(SettingsDTO{WeightUnit: body.WeightUnit, Currency: body.Currency, Language: body.Language})
+	json.NewEncoder(w).Encode(SettingsDTO{
+		WeightUnit: body.WeightUnit, Currency: body.Currency, Language: body.Language,
+		ReminderEmails: &dbUser.ReminderEmails, ReminderLeadDays: &dbUser.ReminderLeadDays,
+	})
 }
 
 func (h *SettingsHandler) GetForUser(w http.ResponseWriter, r *http.Request) {
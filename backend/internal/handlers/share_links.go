@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/config"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// ShareLinksHandler manages read-only share links for a pet and serves them publicly. The public routes sit outside
+// AuthRequired and are authorized only by the token in the URL, so they expose nothing beyond the link's scopes.
+type ShareLinksHandler struct {
+	DB        *gorm.DB
+	Config    *config.Config
+	UploadDir string
+}
+
+// ShareLinkDTO is the API shape of a share link. Token and URL are only set in the Create response.
+type ShareLinkDTO struct {
+	models.ShareLink
+	Scopes      []string    `json:"scopes"`
+	DocumentIDs []uuid.UUID `json:"document_ids"`
+	Active      bool        `json:"active"`
+	Token       string      `json:"token,omitempty"`
+	URL         string      `json:"url,omitempty"`
+}
+
+// SharedPet is the subset of the pet profile shown through a share link. Only Name is set without the profile scope.
+type SharedPet struct {
+	Name             string  `json:"name"`
+	Species          *string `json:"species,omitempty"`
+	Breed            *string `json:"breed,omitempty"`
+	DateOfBirth      *string `json:"date_of_birth,omitempty"`
+	Gender           *string `json:"gender,omitempty"`
+	Fixed            *bool   `json:"fixed,omitempty"`
+	Color            *string `json:"color,omitempty"`
+	MicrochipID      *string `json:"microchip_id,omitempty"`
+	MicrochipCompany *string `json:"microchip_company,omitempty"`
+}
+
+// SharedDocument is a document listed through a share link, downloadable from URL.
+type SharedDocument struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	DocType   *string   `json:"doc_type,omitempty"`
+	MimeType  *string   `json:"mime_type,omitempty"`
+	FileSize  *int64    `json:"file_size,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	URL       string    `json:"url"`
+}
+
+// SharedRecord is the public GET /api/share/{token} response. Sections outside the link's scopes are omitted.
+type SharedRecord struct {
+	Pet          SharedPet             `json:"pet"`
+	Label        *string               `json:"label,omitempty"`
+	ExpiresAt    time.Time             `json:"expires_at"`
+	Scopes       []string              `json:"scopes"`
+	Vaccinations *[]models.Vaccination `json:"vaccinations,omitempty"`
+	Weights      *[]models.WeightEntry `json:"weights,omitempty"`
+	Documents    *[]SharedDocument     `json:"documents,omitempty"`
+}
+
+const (
+	shareTokenBytes          = 32
+	defaultShareLinkHours    = 72
+	maxShareLinkHours        = 24 * 90
+	maxShareLinkLabelLen     = 100
+	maxShareLinkAccessLogLen = 500
+)
+
+var shareScopes = []string{models.ShareScopeProfile, models.ShareScopeVaccinations, models.ShareScopeWeights, models.ShareScopeDocuments}
+
+func splitList(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func hasScope(l models.ShareLink, scope string) bool {
+	for _, s := range splitList(l.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// shareLinkActive reports whether the link can be used at now: not revoked and not expired.
+func shareLinkActive(l models.ShareLink, now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+func shareLinkDTO(l models.ShareLink, now time.Time) ShareLinkDTO {
+	out := ShareLinkDTO{ShareLink: l, Scopes: splitList(l.Scopes), DocumentIDs: []uuid.UUID{}, Active: shareLinkActive(l, now)}
+	for _, s := range splitList(l.DocumentIDs) {
+		if id, err := uuid.Parse(s); err == nil {
+			out.DocumentIDs = append(out.DocumentIDs, id)
+		}
+	}
+	return out
+}
+
+// List returns the pet's share links, newest first, including expired and revoked ones. Owner only.
+func (h *ShareLinksHandler) List(w http.ResponseWriter, r *http.Request) {
+	petID, _ := uuid.Parse(mux.Vars(r)["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	var list []models.ShareLink
+	if err := h.DB.Where("pet_id = ?", petID).Order("created_at DESC").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	out := make([]ShareLinkDTO, 0, len(list))
+	for _, l := range list {
+		out = append(out, shareLinkDTO(l, now))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// Create makes a share link and returns its URL once. Body: scopes (required), document_ids (required with the
+// documents scope), expires_in_hours (default 72, max 2160), label. Owner only.
+func (h *ShareLinksHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, _ := uuid.Parse(mux.Vars(r)["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	var body struct {
+		Label          *string     `json:"label"`
+		Scopes         []string    `json:"scopes"`
+		DocumentIDs    []uuid.UUID `json:"document_ids"`
+		ExpiresInHours *int        `json:"expires_in_hours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	if len(body.Scopes) == 0 {
+		http.Error(w, `{"error":"at least one scope required"}`, http.StatusBadRequest)
+		return
+	}
+	scopes := []string{}
+	seen := make(map[string]bool)
+	for _, s := range body.Scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		valid := false
+		for _, v := range shareScopes {
+			valid = valid || v == s
+		}
+		if !valid {
+			http.Error(w, `{"error":"invalid scope"}`, http.StatusBadRequest)
+			return
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	docIDs := []string{}
+	if seen[models.ShareScopeDocuments] {
+		if len(body.DocumentIDs) == 0 {
+			http.Error(w, `{"error":"document_ids required for the documents scope"}`, http.StatusBadRequest)
+			return
+		}
+		for _, id := range body.DocumentIDs {
+			if !documentBelongsToPet(h.DB, id, petID) {
+				http.Error(w, `{"error":"invalid document_ids"}`, http.StatusBadRequest)
+				return
+			}
+			docIDs = append(docIDs, id.String())
+		}
+	}
+	hours := defaultShareLinkHours
+	if body.ExpiresInHours != nil {
+		hours = *body.ExpiresInHours
+	}
+	if hours < 1 || hours > maxShareLinkHours {
+		http.Error(w, `{"error":"expires_in_hours must be between 1 and 2160"}`, http.StatusBadRequest)
+		return
+	}
+	if body.Label != nil {
+		trimmed := strings.TrimSpace(*body.Label)
+		if len(trimmed) > maxShareLinkLabelLen {
+			http.Error(w, `{"error":"label too long"}`, http.StatusBadRequest)
+			return
+		}
+		body.Label = &trimmed
+		if trimmed == "" {
+			body.Label = nil
+		}
+	}
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(b)
+	link := models.ShareLink{
+		PetID:       petID,
+		CreatedBy:   u.ID,
+		TokenHash:   auth.HashToken(token),
+		Label:       body.Label,
+		Scopes:      strings.Join(scopes, ","),
+		DocumentIDs: strings.Join(docIDs, ","),
+		ExpiresAt:   time.Now().Add(time.Duration(hours) * time.Hour),
+	}
+	if err := h.DB.Create(&link).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	base := h.Config.PublicURL
+	if base == "" {
+		base = h.Config.RequestOrigin(r)
+	}
+	out := shareLinkDTO(link, time.Now())
+	out.Token = token
+	out.URL = base + "/api/share/" + token
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(out)
+}
+
+// Revoke disables a share link immediately. The link and its access log are kept. Owner only.
+func (h *ShareLinksHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.ShareLink{}).Where("id = ? AND pet_id = ? AND revoked_at IS NULL", id, petID).Update("revoked_at", time.Now())
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AccessLog returns when the link was opened and which documents were downloaded, newest first. Owner only.
+func (h *ShareLinksHandler) AccessLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleOwner) {
+		return
+	}
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	var count int64
+	h.DB.Model(&models.ShareLink{}).Where("id = ? AND pet_id = ?", id, petID).Count(&count)
+	if count == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var list []models.ShareLinkAccess
+	err = h.DB.Where("share_link_id = ?", id).Order("accessed_at DESC").Limit(maxShareLinkAccessLogLen).Find(&list).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.ShareLinkAccess{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// activeLink resolves the token from the route. Unknown, expired, and revoked links all return nil so the public
+// routes answer 404 without revealing which.
+func (h *ShareLinksHandler) activeLink(r *http.Request) *models.ShareLink {
+	var link models.ShareLink
+	if err := h.DB.Where("token_hash = ?", auth.HashToken(mux.Vars(r)["token"])).First(&link).Error; err != nil {
+		return nil
+	}
+	if !shareLinkActive(link, time.Now()) {
+		return nil
+	}
+	return &link
+}
+
+// logAccess records a request to the link and bumps its counters. Failures are ignored; they never block the viewer.
+func (h *ShareLinksHandler) logAccess(r *http.Request, link *models.ShareLink, documentID *uuid.UUID) {
+	now := time.Now()
+	ua := r.UserAgent()
+	if len(ua) > 500 {
+		ua = ua[:500]
+	}
+	h.DB.Create(&models.ShareLinkAccess{ShareLinkID: link.ID, DocumentID: documentID, IP: h.Config.ClientIP(r), UserAgent: ua, AccessedAt: now})
+	h.DB.Model(&models.ShareLink{}).Where("id = ?", link.ID).
+		Updates(map[string]interface{}{"last_accessed_at": now, "access_count": gorm.Expr("access_count + 1")})
+}
+
+// View serves GET /api/share/{token} (public): the pet data allowed by the link's scopes.
+func (h *ShareLinksHandler) View(w http.ResponseWriter, r *http.Request) {
+	link := h.activeLink(r)
+	if link == nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var pet models.Pet
+	if err := h.DB.Where("id = ?", link.PetID).First(&pet).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	out := SharedRecord{Pet: SharedPet{Name: pet.Name}, Label: link.Label, ExpiresAt: link.ExpiresAt, Scopes: splitList(link.Scopes)}
+	if hasScope(*link, models.ShareScopeProfile) {
+		out.Pet = SharedPet{
+			Name: pet.Name, Species: pet.Species, Breed: pet.Breed, DateOfBirth: pet.DateOfBirth, Gender: pet.Gender,
+			Fixed: pet.Fixed, Color: pet.Color, MicrochipID: pet.MicrochipID, MicrochipCompany: pet.MicrochipCompany,
+		}
+	}
+	if hasScope(*link, models.ShareScopeVaccinations) {
+		list := []models.Vaccination{}
+		if err := h.DB.Where("pet_id = ?", pet.ID).Order("administered_at DESC").Find(&list).Error; err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		out.Vaccinations = &list
+	}
+	if hasScope(*link, models.ShareScopeWeights) {
+		list := []models.WeightEntry{}
+		if err := h.DB.Where("pet_id = ?", pet.ID).Order("measured_at DESC").Find(&list).Error; err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		out.Weights = &list
+	}
+	if hasScope(*link, models.ShareScopeDocuments) {
+		var docs []models.Document
+		if ids := splitList(link.DocumentIDs); len(ids) > 0 {
+			if err := h.DB.Where("id IN ? AND pet_id = ?", ids, pet.ID).Order("created_at DESC").Find(&docs).Error; err != nil {
+				http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		list := make([]SharedDocument, 0, len(docs))
+		for _, d := range docs {
+			list = append(list, SharedDocument{
+				ID: d.ID, Name: d.Name, DocType: d.DocType, MimeType: d.MimeType, FileSize: d.FileSize, CreatedAt: d.CreatedAt,
+				URL: "/api/share/" + mux.Vars(r)["token"] + "/documents/" + d.ID.String(),
+			})
+		}
+		out.Documents = &list
+	}
+	h.logAccess(r, link, nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	json.NewEncoder(w).Encode(out)
+}
+
+// Document serves GET /api/share/{token}/documents/{id} (public): one of the documents selected for the link.
+func (h *ShareLinksHandler) Document(w http.ResponseWriter, r *http.Request) {
+	link := h.activeLink(r)
+	if link == nil || !hasScope(*link, models.ShareScopeDocuments) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	allowed := false
+	for _, s := range splitList(link.DocumentIDs) {
+		allowed = allowed || s == id.String()
+	}
+	var doc models.Document
+	if !allowed || h.DB.Where("id = ? AND pet_id = ?", id, link.PetID).First(&doc).Error != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.logAccess(r, link, &doc.ID)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	http.ServeFile(w, r, filepath.Join(h.UploadDir, filepath.FromSlash(doc.FilePath)))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestShareLinkActive(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	revoked := now.Add(-time.Minute)
+	tests := []struct {
+		name string
+		link models.ShareLink
+		want bool
+	}{
+		{"valid", models.ShareLink{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", models.ShareLink{ExpiresAt: now.Add(-time.Second)}, false},
+		{"expires now", models.ShareLink{ExpiresAt: now}, false},
+		{"revoked", models.ShareLink{ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}, false},
+	}
+	for _, tt := range tests {
+		if got := shareLinkActive(tt.link, now); got != tt.want {
+			t.Errorf("%s: shareLinkActive = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShareLinkDTO(t *testing.T) {
+	docID := uuid.New()
+	link := models.ShareLink{
+		Scopes:      "profile,documents",
+		DocumentIDs: docID.String() + ",not-a-uuid",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	out := shareLinkDTO(link, time.Now())
+	if len(out.Scopes) != 2 || out.Scopes[0] != "profile" || out.Scopes[1] != "documents" {
+		t.Errorf("Scopes = %v", out.Scopes)
+	}
+	if len(out.DocumentIDs) != 1 || out.DocumentIDs[0] != docID {
+		t.Errorf("DocumentIDs = %v", out.DocumentIDs)
+	}
+	if !out.Active {
+		t.Error("expected active")
+	}
+	if !hasScope(link, models.ShareScopeDocuments) || hasScope(link, models.ShareScopeWeights) {
+		t.Error("hasScope mismatch")
+	}
+	empty := shareLinkDTO(models.ShareLink{}, time.Now())
+	if empty.Scopes == nil || empty.DocumentIDs == nil {
+		t.Error("lists must encode as [] not null")
+	}
+}
@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/auth"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	totpIssuer        = "Pet Medical"
+	recoveryCodeCount = 10
+)
+
+// MFAChallengeResponse is returned by Login instead of a session when the account has two-factor enabled. The client
+// sends MFAToken and a code to /api/auth/login/mfa within ExpiresIn seconds. Each token allows one attempt.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// LoginMFARequest is the body for POST /api/auth/login/mfa. Code is a TOTP code or a recovery code.
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// TwoFactorStatus is the response of GET /api/auth/2fa. Pending means setup was started but not confirmed.
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Pending                bool  `json:"pending"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse carries the new secret; OTPAuthURI is what the client renders as a QR code.
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse returns freshly generated recovery codes. They are shown once; only hashes are stored.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginMFA completes a login that Login answered with an MFAChallengeResponse. The token is spent on the first
+// attempt, right or wrong, and users with too many recent failures are refused (see auth.MFAStore).
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req LoginMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	userID, tokenID, err := h.JWT.ParseMFAToken(req.MFAToken)
+	if err != nil {
+		http.Error(w, `{"error":"error.mfa_token_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	var u models.User
+	if err := h.DB.Where("id = ?", userID).First(&u).Error; err != nil || !u.TOTPEnabled {
+		http.Error(w, `{"error":"error.mfa_token_invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	switch err := h.MFAStore.Begin(tokenID, u.ID); {
+	case errors.Is(err, auth.ErrMFATokenUsed):
+		http.Error(w, `{"error":"error.mfa_token_invalid"}`, http.StatusUnauthorized)
+		return
+	case errors.Is(err, auth.ErrTooManyMFAFailures):
+		log.Print(i18n.Tf("log.auth.mfa_locked", u.ID))
+		http.Error(w, `{"error":"error.too_many_requests"}`, http.StatusTooManyRequests)
+		return
+	case err != nil:
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	ok, err := verifySecondFactor(h.DB, &u, req.Code)
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		log.Print(i18n.Tf("log.auth.mfa_failed", u.ID))
+		http.Error(w, `{"error":"error.invalid_code"}`, http.StatusUnauthorized)
+		return
+	}
+	h.MFAStore.Succeeded(tokenID) // if this fails the attempt just counts as a failure
+	h.applyUserDefaults(&u)
+	h.startSession(w, r, u)
+}
+
+// TwoFactor handles GET /api/auth/2fa.
+func (h *AuthHandler) TwoFactor(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Select("totp_secret, totp_enabled").Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	status := TwoFactorStatus{Enabled: dbUser.TOTPEnabled, Pending: !dbUser.TOTPEnabled && dbUser.TOTPSecret != nil}
+	if dbUser.TOTPEnabled {
+		h.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", u.ID).Count(&status.RecoveryCodesRemaining)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// TwoFactorSetup handles POST /api/auth/2fa/setup ({"password"}): it stores a new secret, not yet enforced, and
+// returns it for the authenticator app. Calling it again before confirming replaces the secret.
+func (h *AuthHandler) TwoFactorSetup(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if dbUser.PasswordHash == "" {
+		http.Error(w, `{"error":"error.no_password_account"}`, http.StatusBadRequest)
+		return
+	}
+	if !auth.CheckPassword(dbUser.PasswordHash, req.Password) {
+		http.Error(w, `{"error":"error.invalid_credentials"}`, http.StatusUnauthorized)
+		return
+	}
+	if dbUser.TOTPEnabled {
+		http.Error(w, `{"error":"error.2fa_already_enabled"}`, http.StatusConflict)
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.Model(&models.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"totp_secret": secret, "totp_last_step": 0,
+	}).Error; err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TwoFactorSetupResponse{Secret: secret, OTPAuthURI: auth.TOTPURI(totpIssuer, dbUser.Email, secret)})
+}
+
+// TwoFactorConfirm handles POST /api/auth/2fa/confirm ({"code"}): a valid code from the app turns two-factor on.
+// Responds with the recovery codes.
+func (h *AuthHandler) TwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if dbUser.TOTPEnabled {
+		http.Error(w, `{"error":"error.2fa_already_enabled"}`, http.StatusConflict)
+		return
+	}
+	if dbUser.TOTPSecret == nil {
+		http.Error(w, `{"error":"error.2fa_not_pending"}`, http.StatusBadRequest)
+		return
+	}
+	step, ok := auth.ValidateTOTP(*dbUser.TOTPSecret, req.Code, time.Now(), dbUser.TOTPLastStep)
+	if !ok {
+		http.Error(w, `{"error":"error.invalid_code"}`, http.StatusBadRequest)
+		return
+	}
+	var codes []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+			"totp_enabled": true, "totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// TwoFactorDisable handles POST /api/auth/2fa/disable ({"password","code"}). Both factors are required so a stolen
+// session alone cannot turn two-factor off.
+func (h *AuthHandler) TwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if !dbUser.TOTPEnabled {
+		http.Error(w, `{"error":"error.2fa_not_enabled"}`, http.StatusBadRequest)
+		return
+	}
+	if dbUser.PasswordHash == "" || !auth.CheckPassword(dbUser.PasswordHash, req.Password) {
+		http.Error(w, `{"error":"error.invalid_credentials"}`, http.StatusUnauthorized)
+		return
+	}
+	if ok, err := verifySecondFactor(h.DB, &dbUser, req.Code); err != nil || !ok {
+		http.Error(w, `{"error":"error.invalid_code"}`, http.StatusUnauthorized)
+		return
+	}
+	if err := resetTwoFactor(h.DB, u.ID); err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles POST /api/auth/2fa/recovery-codes ({"code"}), replacing every unused code.
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"error.invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	var dbUser models.User
+	if err := h.DB.Where("id = ?", u.ID).First(&dbUser).Error; err != nil {
+		http.Error(w, `{"error":"error.not_found"}`, http.StatusNotFound)
+		return
+	}
+	if !dbUser.TOTPEnabled {
+		http.Error(w, `{"error":"error.2fa_not_enabled"}`, http.StatusBadRequest)
+		return
+	}
+	if ok, err := verifySecondFactor(h.DB, &dbUser, req.Code); err != nil || !ok {
+		http.Error(w, `{"error":"error.invalid_code"}`, http.StatusUnauthorized)
+		return
+	}
+	var codes []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		http.Error(w, `{"error":"error.internal_error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetTwoFactor handles DELETE /api/users/{id}/2fa (admin): turns two-factor off for a user who lost their
+// authenticator and recovery codes. They can enroll again after logging in with their password.
+func (h *UsersHandler) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	var n int64
+	if err := h.DB.Model(&models.User{}).Where("id = ?", userID).Count(&n).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := resetTwoFactor(h.DB, userID); err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if admin := middleware.GetUser(r.Context()); admin != nil {
+		log.Printf("[AUTH] two-factor reset for user_id=%s by admin user_id=%s", userID, admin.ID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReauthRequest carries the re-authentication for changes that would let a stolen session keep or widen access, such as
+// adding or removing a passkey.
+type ReauthRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// confirmIdentity re-checks the signed-in user: the current password when the account has one, and a TOTP or recovery
+// code when two-factor is on. It writes the error response and returns false on failure. Accounts with neither, such
+// as those created by the trusted proxy, have nothing to re-check.
+func (h *AuthHandler) confirmIdentity(w http.ResponseWriter, u *models.User, req ReauthRequest) bool {
+	if u.PasswordHash != "" && !auth.CheckPassword(u.PasswordHash, req.Password) {
+		http.Error(w, `{"error":"error.invalid_credentials"}`, http.StatusUnauthorized)
+		return false
+	}
+	if u.TOTPEnabled {
+		if ok, err := verifySecondFactor(h.DB, u, req.Code); err != nil || !ok {
+			http.Error(w, `{"error":"error.invalid_code"}`, http.StatusUnauthorized)
+			return false
+		}
+	}
+	return true
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code for u. Both are consumed atomically, so
+// two concurrent requests cannot spend the same code.
+func verifySecondFactor(db *gorm.DB, u *models.User, code string) (bool, error) {
+	if u.TOTPSecret == nil {
+		return false, nil
+	}
+	if step, ok := auth.ValidateTOTP(*u.TOTPSecret, code, time.Now(), u.TOTPLastStep); ok {
+		result := db.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", u.ID, step).Update("totp_last_step", step)
+		return result.RowsAffected == 1, result.Error
+	}
+	if code == "" {
+		return false, nil
+	}
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", u.ID, auth.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	var left int64
+	db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", u.ID).Count(&left)
+	log.Print(i18n.Tf("log.auth.mfa_recovery_used", u.ID, left))
+	return true, nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores hashes of a new set, which it returns.
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]models.RecoveryCode, len(codes))
+	for i, c := range codes {
+		rows[i] = models.RecoveryCode{UserID: userID, CodeHash: auth.HashRecoveryCode(c)}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// resetTwoFactor turns two-factor off and removes the secret and recovery codes.
+func resetTwoFactor(db *gorm.DB, userID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_enabled": false, "totp_secret": nil, "totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
@@ -71,6 +71,8 @@ type UserListDTO struct {
 	Email       string `json:"email"`
 	Role        string `json:"role"`
 	CreatedAt   string `json:"created_at"`
+	// TwoFactorEnabled lets admins see who would need a reset (DELETE /users/{id}/2fa) after losing their device.
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
 }
 
 func (h *UsersHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -92,6 +94,7 @@ func (h *UsersHandler) List(w http.ResponseWriter, r *http.Request) {
 			Email:       u.Email,
 			Role:        u.Role,
 			CreatedAt:   u.CreatedAt.Format(time.RFC3339),
+			TwoFactorEnabled: u.TOTPEnabled,
 		}
 	}
 	w.Header().Set("Content-Type", "application/json")
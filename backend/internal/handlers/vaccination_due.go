@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+const maxVaccinationDurationMonths = 240
+
+// Sources for a computed next_due.
+const (
+	NextDueSourceCustomOption  = "custom_option"
+	NextDueSourceDefaultOption = "default_option"
+)
+
+// NextDueDerivation explains how the server computed next_due when the client omitted it.
+type NextDueDerivation struct {
+	Source         string `json:"source"`
+	Option         string `json:"option"`
+	Species        string `json:"species,omitempty"`
+	DurationMonths int    `json:"duration_months"`
+}
+
+// VaccinationResponse is a vaccination plus, on create, how next_due was derived.
+type VaccinationResponse struct {
+	models.Vaccination
+	NextDueDerivation *NextDueDerivation `json:"next_due_derivation,omitempty"`
+}
+
+// BackfillNextDueResult is the response of the admin next_due backfill.
+type BackfillNextDueResult struct {
+	Scanned    int `json:"scanned"`
+	Updated    int `json:"updated"`
+	Unresolved int `json:"unresolved"`
+}
+
+// vaccinationDurations resolves vaccine names to validity periods. Defaults are loaded once; custom options are cached per user.
+type vaccinationDurations struct {
+	db       *gorm.DB
+	defaults []models.DefaultDropdownOption
+	customs  map[uuid.UUID][]models.UserCustomOption
+}
+
+func loadVaccinationDurations(db *gorm.DB) (*vaccinationDurations, error) {
+	d := &vaccinationDurations{db: db, customs: make(map[uuid.UUID][]models.UserCustomOption)}
+	err := db.Where("option_type = ? AND duration_months IS NOT NULL", "vaccination").Order("context, sort_order, value").Find(&d.defaults).Error
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// resolve finds the duration for a vaccine name given to a pet of the given species. A user's custom option wins over
+// the admin default; names and species compare case- and whitespace-insensitively. Returns nil when nothing matches.
+func (d *vaccinationDurations) resolve(userID uuid.UUID, species, name string) (*NextDueDerivation, error) {
+	customs, ok := d.customs[userID]
+	if !ok {
+		if err := d.db.Where("user_id = ? AND option_type = ? AND duration_months IS NOT NULL", userID, "vaccination").Find(&customs).Error; err != nil {
+			return nil, err
+		}
+		d.customs[userID] = customs
+	}
+	key, speciesKey := normalizeOptionValue(name), normalizeOptionValue(species)
+	for _, c := range customs {
+		if normalizeOptionValue(c.Value) == key && normalizeOptionValue(c.Context) == speciesKey {
+			return &NextDueDerivation{Source: NextDueSourceCustomOption, Option: c.Value, Species: c.Context, DurationMonths: *c.DurationMonths}, nil
+		}
+	}
+	for _, o := range d.defaults {
+		if normalizeOptionValue(o.Value) == key && normalizeOptionValue(o.Context) == speciesKey {
+			return &NextDueDerivation{Source: NextDueSourceDefaultOption, Option: o.Value, Species: o.Context, DurationMonths: *o.DurationMonths}, nil
+		}
+	}
+	return nil, nil
+}
+
+func normalizeOptionValue(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// addMonths adds months to a YYYY-MM-DD date, clamping to the last day of the target month (Jan 31 + 1 month = Feb 28/29).
+func addMonths(date string, months int) (string, error) {
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return "", err
+	}
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC).Format(dateLayout), nil
+}
+
+// deriveNextDue sets v.NextDue from the matching option's duration. Returns nil (and leaves v unchanged) when
+// no option matches or administered_at is not a valid date.
+func deriveNextDue(d *vaccinationDurations, userID uuid.UUID, species string, v *models.Vaccination) (*NextDueDerivation, error) {
+	deriv, err := d.resolve(userID, species, v.Name)
+	if err != nil || deriv == nil {
+		return nil, err
+	}
+	due, err := addMonths(v.AdministeredAt, deriv.DurationMonths)
+	if err != nil {
+		return nil, nil
+	}
+	v.NextDue = &due
+	return deriv, nil
+}
+
+// BackfillNextDue (admin) computes next_due for every vaccination that has none, using each pet owner's options.
+func (h *VaccinationsHandler) BackfillNextDue(w http.ResponseWriter, r *http.Request) {
+	durations, err := loadVaccinationDurations(h.DB)
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	type row struct {
+		models.Vaccination
+		UserID  uuid.UUID
+		Species *string
+	}
+	var rows []row
+	err = h.DB.Table("vaccinations").
+		Select("vaccinations.*, pets.user_id AS user_id, pets.species AS species").
+		Joins("JOIN pets ON pets.id = vaccinations.pet_id").
+		Where("vaccinations.next_due IS NULL OR vaccinations.next_due = ''").
+		Scan(&rows).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	out := BackfillNextDueResult{Scanned: len(rows)}
+	for i := range rows {
+		v := &rows[i].Vaccination
+		species := ""
+		if rows[i].Species != nil {
+			species = *rows[i].Species
+		}
+		deriv, err := deriveNextDue(durations, rows[i].UserID, species, v)
+		if err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		if deriv == nil {
+			out.Unresolved++
+			continue
+		}
+		if err := h.DB.Model(&models.Vaccination{}).Where("id = ?", v.ID).Update("next_due", v.NextDue).Error; err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		out.Updated++
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
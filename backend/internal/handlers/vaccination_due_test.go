@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		date   string
+		months int
+		want   string
+	}{
+		{"2024-03-15", 12, "2025-03-15"},
+		{"2024-03-15", 36, "2027-03-15"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2023-01-31", 1, "2023-02-28"},
+		{"2024-11-30", 3, "2025-02-28"},
+		{"2024-02-29", 12, "2025-02-28"},
+	}
+	for _, tt := range tests {
+		got, err := addMonths(tt.date, tt.months)
+		if err != nil {
+			t.Fatalf("addMonths(%q, %d): %v", tt.date, tt.months, err)
+		}
+		if got != tt.want {
+			t.Errorf("addMonths(%q, %d) = %q, want %q", tt.date, tt.months, got, tt.want)
+		}
+	}
+	if _, err := addMonths("03/15/2024", 12); err == nil {
+		t.Error("expected error for non-ISO date")
+	}
+}
+
+func TestDeriveNextDue_CustomOptionWins(t *testing.T) {
+	userID := uuid.New()
+	one, three := 12, 36
+	d := &vaccinationDurations{
+		defaults: []models.DefaultDropdownOption{
+			{OptionType: "vaccination", Value: "Rabies (3-year)", Context: "Dog", DurationMonths: &three},
+			{OptionType: "vaccination", Value: "Rabies (1-year)", Context: "Cat", DurationMonths: &one},
+		},
+		customs: map[uuid.UUID][]models.UserCustomOption{
+			userID: {{OptionType: "vaccination", Value: "Rabies (1-year)", Context: "Cat", DurationMonths: &three}},
+		},
+	}
+
+	v := models.Vaccination{Name: "rabies  (3-year)", AdministeredAt: "2024-05-01"}
+	deriv, err := deriveNextDue(d, userID, "dog", &v)
+	if err != nil || deriv == nil {
+		t.Fatalf("expected default match, got %v, %v", deriv, err)
+	}
+	if deriv.Source != NextDueSourceDefaultOption || v.NextDue == nil || *v.NextDue != "2027-05-01" {
+		t.Errorf("got source %q next_due %v", deriv.Source, v.NextDue)
+	}
+
+	v = models.Vaccination{Name: "Rabies (1-year)", AdministeredAt: "2024-05-01"}
+	deriv, _ = deriveNextDue(d, userID, "Cat", &v)
+	if deriv == nil || deriv.Source != NextDueSourceCustomOption || *v.NextDue != "2027-05-01" {
+		t.Errorf("custom option should override default, got %+v", deriv)
+	}
+
+	v = models.Vaccination{Name: "Rabies (3-year)", AdministeredAt: "2024-05-01"}
+	deriv, _ = deriveNextDue(d, userID, "Cat", &v)
+	if deriv != nil || v.NextDue != nil {
+		t.Errorf("option for another species should not match, got %+v", deriv)
+	}
+}
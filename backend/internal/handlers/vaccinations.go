@@ -9,21 +9,30 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pet-medical/api/internal/middleware"
 	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/webhooks"
 	"gorm.io/gorm"
 )
 
 type VaccinationsHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Webhooks *webhooks.Dispatcher // optional; emits vaccination.* events
 }
 
-func (h *VaccinationsHandler) ensurePetOwnership(r *http.Request, petID uuid.UUID) bool {
-	u := middleware.GetUser(r.Context())
-	if u == nil {
+// applyClinic validates v.ClinicID against the user's directory and, when the free-text veterinarian is empty,
+// fills it from the directory entry so both stay readable. Returns false if the clinic is not the user's.
+func (h *VaccinationsHandler) applyClinic(v *models.Vaccination, userID uuid.UUID) bool {
+	if v.ClinicID == nil {
+		return true
+	}
+	var c models.Clinic
+	if err := h.DB.Where("id = ? AND user_id = ?", *v.ClinicID, userID).First(&c).Error; err != nil {
 		return false
 	}
-	var count int64
-	h.DB.Model(&models.Pet{}).Where("id = ? AND user_id = ?", petID, u.ID).Count(&count)
-	return count > 0
+	if v.Veterinarian == nil || *v.Veterinarian == "" {
+		name := c.Name
+		v.Veterinarian = &name
+	}
+	return true
 }
 
 func (h *VaccinationsHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -38,8 +47,7 @@ func (h *VaccinationsHandler) List(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
 		return
 	}
 	var list []models.Vaccination
@@ -68,8 +76,7 @@ func (h *VaccinationsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
 		return
 	}
 	var v models.Vaccination
@@ -94,8 +101,7 @@ func (h *VaccinationsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	var v models.Vaccination
@@ -109,13 +115,49 @@ func (h *VaccinationsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"name and administered_at required"}`, http.StatusBadRequest)
 		return
 	}
+	if v.VisitID != nil && !visitBelongsToPet(h.DB, *v.VisitID, petID) {
+		http.Error(w, `{"error":"invalid visit_id"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.applyClinic(&v, petCreatorID(h.DB, petID)) {
+		http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+		return
+	}
+	out := VaccinationResponse{}
+	if v.NextDue == nil || *v.NextDue == "" {
+		deriv, err := h.deriveNextDue(u.ID, petID, &v)
+		if err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		out.NextDueDerivation = deriv
+	}
 	if err := h.DB.Create(&v).Error; err != nil {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
 	}
+	out.Vaccination = v
+	emitPetEvent(h.Webhooks, h.DB, petID, webhooks.EventVaccinationCreated, v)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(v)
+	json.NewEncoder(w).Encode(out)
+}
+
+// deriveNextDue fills v.NextDue from the duration of the vaccine option matching v.Name for the pet's species.
+func (h *VaccinationsHandler) deriveNextDue(userID, petID uuid.UUID, v *models.Vaccination) (*NextDueDerivation, error) {
+	var pet models.Pet
+	if err := h.DB.Select("species").Where("id = ?", petID).First(&pet).Error; err != nil {
+		return nil, err
+	}
+	species := ""
+	if pet.Species != nil {
+		species = *pet.Species
+	}
+	durations, err := loadVaccinationDurations(h.DB)
+	if err != nil {
+		return nil, err
+	}
+	return deriveNextDue(durations, userID, species, v)
 }
 
 func (h *VaccinationsHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -131,8 +173,7 @@ func (h *VaccinationsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	var v models.Vaccination
@@ -146,9 +187,18 @@ func (h *VaccinationsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"name and administered_at required"}`, http.StatusBadRequest)
 		return
 	}
+	if v.VisitID != nil && !visitBelongsToPet(h.DB, *v.VisitID, petID) {
+		http.Error(w, `{"error":"invalid visit_id"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.applyClinic(&v, petCreatorID(h.DB, petID)) {
+		http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+		return
+	}
 	result := h.DB.Model(&models.Vaccination{}).Where("id = ? AND pet_id = ?", id, petID).Updates(map[string]interface{}{
 		"name": v.Name, "administered_at": v.AdministeredAt, "next_due": v.NextDue, "cost_usd": v.CostUSD,
-		"veterinarian": v.Veterinarian, "batch_number": v.BatchNumber, "notes": v.Notes,
+		"veterinarian": v.Veterinarian, "batch_number": v.BatchNumber, "notes": v.Notes, "visit_id": v.VisitID,
+		"clinic_id":  v.ClinicID,
 		"updated_at": time.Now(),
 	})
 	if result.Error != nil {
@@ -160,6 +210,7 @@ func (h *VaccinationsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.DB.Where("id = ?", id).First(&v)
+	emitPetEvent(h.Webhooks, h.DB, petID, webhooks.EventVaccinationUpdated, v)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(v)
 }
@@ -177,8 +228,7 @@ func (h *VaccinationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.Vaccination{})
@@ -190,5 +240,6 @@ func (h *VaccinationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
 		return
 	}
+	emitPetEvent(h.Webhooks, h.DB, petID, webhooks.EventVaccinationDeleted, map[string]uuid.UUID{"id": id, "pet_id": petID})
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+)
+
+var (
+	vaccinationCSVFields  = []string{"name", "administered_at", "next_due", "veterinarian", "batch_number", "cost", "notes"}
+	vaccinationCSVAliases = map[string][]string{
+		"name":            {"name", "vaccine", "vaccination", "vaccine_name"},
+		"administered_at": {"administered_at", "administered", "date_given", "given", "date"},
+		"next_due":        {"next_due", "due", "due_date", "next", "expires", "expiry"},
+		"veterinarian":    {"veterinarian", "vet", "clinic"},
+		"batch_number":    {"batch_number", "batch", "lot", "lot_number"},
+		"cost":            {"cost", "cost_usd", "price", "amount"},
+		"notes":           {"notes", "note", "comment", "comments"},
+	}
+)
+
+// ExportCSV handles GET /pets/{petId}/vaccinations.csv, oldest first, with the same columns ImportCSV reads.
+func (h *VaccinationsHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var pet models.Pet
+	if err := h.DB.Select("name").Where("id = ?", petID).First(&pet).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var list []models.Vaccination
+	if err := h.DB.Where("pet_id = ?", petID).Order("administered_at, name").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadFilename(pet.Name, "vaccinations", "csv")}))
+	cw := csv.NewWriter(w)
+	cw.Write(vaccinationCSVFields)
+	for _, v := range list {
+		cost := ""
+		if v.CostUSD != nil {
+			cost = formatCSVNumber(*v.CostUSD)
+		}
+		cw.Write([]string{
+			escapeCSVCell(v.Name), v.AdministeredAt, derefOr(v.NextDue, ""), escapeCSVCell(derefOr(v.Veterinarian, "")),
+			escapeCSVCell(derefOr(v.BatchNumber, "")), cost, escapeCSVCell(derefOr(v.Notes, "")),
+		})
+	}
+	cw.Flush()
+}
+
+// ImportCSV handles POST /pets/{petId}/vaccinations/import with the same form fields as weight import (no unit).
+// A row without next_due gets one derived from the vaccine's duration, as on create.
+func (h *VaccinationsHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	table, mapping, ok := readCSVUpload(w, r)
+	if !ok {
+		return
+	}
+	cols, err := mapColumns(table.header, vaccinationCSVFields, vaccinationCSVAliases, mapping)
+	if err != nil {
+		writeCSVError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, f := range []string{"name", "administered_at"} {
+		if cols[f] < 0 {
+			writeCSVError(w, http.StatusBadRequest, "no column for "+f+"; name one in mapping")
+			return
+		}
+	}
+	var user models.User
+	if err := h.DB.Select("language").Where("id = ?", u.ID).First(&user).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	dates := append(table.column(cols["administered_at"]), table.column(cols["next_due"])...)
+	format, ambiguous, err := detectDateFormat(dates, user.Language != "en", r.FormValue("date_format"))
+	if err != nil {
+		writeCSVError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var existing []models.Vaccination
+	if err := h.DB.Select("name, administered_at").Where("pet_id = ?", petID).Find(&existing).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	vaccs, report := planVaccinationImport(table, cols, format, existing)
+	report.DryRun = csvDryRun(r)
+	if ambiguous {
+		report.Warnings = append(report.Warnings, "dates read as "+format.Name+"; set date_format if that is wrong")
+	}
+	var pet models.Pet
+	if err := h.DB.Select("species").Where("id = ?", petID).First(&pet).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	species := derefOr(pet.Species, "")
+	durations, err := loadVaccinationDurations(h.DB)
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	for i := range vaccs {
+		vaccs[i].ID, vaccs[i].PetID = uuid.New(), petID
+		if vaccs[i].NextDue == nil {
+			if _, err := deriveNextDue(durations, u.ID, species, &vaccs[i]); err != nil {
+				http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	writeCSVReport(w, h.DB, &report, &vaccs, len(vaccs))
+}
+
+// planVaccinationImport converts rows to vaccinations, skipping rows with the same vaccine and date as an existing
+// record or an earlier row.
+func planVaccinationImport(t *csvTable, cols map[string]int, format csvDateFormat, existing []models.Vaccination) ([]models.Vaccination, CSVImportReport) {
+	report := newCSVImportReport()
+	report.DateFormat, report.Rows = format.Name, len(t.rows)
+	for f, c := range cols {
+		if c >= 0 {
+			report.Columns[f] = t.header[c]
+		}
+	}
+	seen := make(map[[2]string]bool, len(existing))
+	for _, v := range existing {
+		seen[[2]string{normalizeOptionValue(v.Name), v.AdministeredAt}] = true
+	}
+	vaccs := []models.Vaccination{}
+	for i, row := range t.rows {
+		line := t.lines[i]
+		fail := func(err error) { report.Errors = append(report.Errors, CSVRowIssue{Row: line, Error: err.Error()}) }
+		name := t.cell(row, cols["name"])
+		if name == "" {
+			fail(errors.New("name required"))
+			continue
+		}
+		date := t.cell(row, cols["administered_at"])
+		if date == "" {
+			fail(errors.New("administered_at required"))
+			continue
+		}
+		administeredAt, err := parseCSVDate(format, date)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		v := models.Vaccination{
+			Name:           name,
+			AdministeredAt: administeredAt,
+			Veterinarian:   csvStringPtr(t.cell(row, cols["veterinarian"])),
+			BatchNumber:    csvStringPtr(t.cell(row, cols["batch_number"])),
+			Notes:          csvStringPtr(t.cell(row, cols["notes"])),
+		}
+		if due := t.cell(row, cols["next_due"]); due != "" {
+			nextDue, err := parseCSVDate(format, due)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			if nextDue < administeredAt {
+				fail(errors.New("next_due is before administered_at"))
+				continue
+			}
+			v.NextDue = &nextDue
+		}
+		if c := t.cell(row, cols["cost"]); c != "" {
+			cost, err := parseCSVNumber(c)
+			if err != nil || cost < 0 || math.IsInf(cost, 0) || math.IsNaN(cost) {
+				fail(errors.New("invalid cost " + c))
+				continue
+			}
+			v.CostUSD = &cost
+		}
+		key := [2]string{normalizeOptionValue(name), administeredAt}
+		if seen[key] {
+			report.Duplicates = append(report.Duplicates, line)
+			continue
+		}
+		seen[key] = true
+		vaccs = append(vaccs, v)
+	}
+	return vaccs, report
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+)
+
+type VisitsHandler struct {
+	DB *gorm.DB
+}
+
+// VisitDetail is a visit with the vaccinations and documents that reference it.
+type VisitDetail struct {
+	models.VetVisit
+	Vaccinations []models.Vaccination `json:"vaccinations"`
+	Documents    []models.Document    `json:"documents"`
+}
+
+// visitBelongsToPet reports whether visitID is a visit of petID. Used to validate visit_id on linked records.
+func visitBelongsToPet(db *gorm.DB, visitID, petID uuid.UUID) bool {
+	var count int64
+	db.Model(&models.VetVisit{}).Where("id = ? AND pet_id = ?", visitID, petID).Count(&count)
+	return count > 0
+}
+
+// parseVisitLink reads a visit_id sent as a string: "" means no visit, anything else must parse and be accepted by
+// belongs (a visit of the same pet). ok is false for an invalid ID or another pet's visit.
+func parseVisitLink(raw string, belongs func(visitID uuid.UUID) bool) (visitID *uuid.UUID, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil || !belongs(id) {
+		return nil, false
+	}
+	return &id, true
+}
+
+func (h *VisitsHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var list []models.VetVisit
+	err = h.DB.Where("pet_id = ?", petID).Order("visit_date DESC").Find(&list).Error
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.VetVisit{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Get returns the visit with its linked vaccinations and documents.
+func (h *VisitsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var out VisitDetail
+	if err := h.DB.Where("id = ? AND pet_id = ?", id, petID).First(&out.VetVisit).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := h.DB.Where("visit_id = ? AND pet_id = ?", id, petID).Order("administered_at DESC").Find(&out.Vaccinations).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.Where("visit_id = ? AND pet_id = ?", id, petID).Order("created_at DESC").Find(&out.Documents).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if out.Vaccinations == nil {
+		out.Vaccinations = []models.Vaccination{}
+	}
+	if out.Documents == nil {
+		out.Documents = []models.Document{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+func (h *VisitsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, err := uuid.Parse(vars["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var v models.VetVisit
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	v.PetID = petID
+	v.ID = uuid.Nil
+	if err := validateVisitInput(&v); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.Create(&v).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *VisitsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	var v models.VetVisit
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	v.ID = id
+	v.PetID = petID
+	if err := validateVisitInput(&v); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Model(&models.VetVisit{}).Where("id = ? AND pet_id = ?", id, petID).Updates(map[string]interface{}{
+		"visit_date": v.VisitDate, "clinic": v.Clinic, "veterinarian": v.Veterinarian, "reason": v.Reason,
+		"diagnosis": v.Diagnosis, "follow_up_date": v.FollowUpDate, "cost_usd": v.CostUSD, "notes": v.Notes,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("id = ?", id).First(&v)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Delete removes the visit. Linked vaccinations and documents are kept and unlinked.
+func (h *VisitsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	petID, _ := uuid.Parse(vars["petId"])
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	h.DB.Model(&models.Vaccination{}).Where("visit_id = ? AND pet_id = ?", id, petID).Update("visit_id", nil)
+	h.DB.Model(&models.Document{}).Where("visit_id = ? AND pet_id = ?", id, petID).Update("visit_id", nil)
+	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.VetVisit{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	maxVisitFieldLen = 200 // clinic, veterinarian, reason
+	maxVisitTextLen  = 5000
+)
+
+func validateVisitInput(v *models.VetVisit) error {
+	v.VisitDate = strings.TrimSpace(v.VisitDate)
+	if v.VisitDate == "" {
+		return errors.New("visit_date required")
+	}
+	if _, err := time.Parse(dateLayout, v.VisitDate); err != nil {
+		return errors.New("invalid visit_date")
+	}
+	if v.FollowUpDate != nil && *v.FollowUpDate != "" {
+		if _, err := time.Parse(dateLayout, *v.FollowUpDate); err != nil {
+			return errors.New("invalid follow_up_date")
+		}
+	}
+	if v.CostUSD != nil && *v.CostUSD < 0 {
+		return errors.New("cost must not be negative")
+	}
+	for _, s := range []*string{v.Clinic, v.Veterinarian, v.Reason} {
+		if s != nil && len(*s) > maxVisitFieldLen {
+			return errors.New("field too long")
+		}
+	}
+	for _, s := range []*string{v.Diagnosis, v.Notes} {
+		if s != nil && len(*s) > maxVisitTextLen {
+			return errors.New("field too long")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestValidateVisitInput(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cost := func(f float64) *float64 { return &f }
+	tests := []struct {
+		name    string
+		visit   models.VetVisit
+		wantErr string // "" means valid
+	}{
+		{"minimal", models.VetVisit{VisitDate: " 2025-03-04 "}, ""},
+		{"full", models.VetVisit{VisitDate: "2025-03-04", FollowUpDate: str("2025-04-01"), CostUSD: cost(0), Reason: str("checkup")}, ""},
+		{"empty follow-up", models.VetVisit{VisitDate: "2025-03-04", FollowUpDate: str("")}, ""},
+		{"missing date", models.VetVisit{VisitDate: "  "}, "visit_date required"},
+		{"bad date", models.VetVisit{VisitDate: "04/03/2025"}, "invalid visit_date"},
+		{"bad follow-up", models.VetVisit{VisitDate: "2025-03-04", FollowUpDate: str("2025-13-01")}, "invalid follow_up_date"},
+		{"negative cost", models.VetVisit{VisitDate: "2025-03-04", CostUSD: cost(-1)}, "cost must not be negative"},
+		{"long clinic", models.VetVisit{VisitDate: "2025-03-04", Clinic: str(strings.Repeat("x", maxVisitFieldLen+1))}, "field too long"},
+		{"long notes", models.VetVisit{VisitDate: "2025-03-04", Notes: str(strings.Repeat("x", maxVisitTextLen+1))}, "field too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.visit
+			err := validateVisitInput(&v)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if v.VisitDate != strings.TrimSpace(tt.visit.VisitDate) {
+					t.Errorf("visit_date = %q, want trimmed", v.VisitDate)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseVisitLink(t *testing.T) {
+	petVisit := uuid.New()
+	belongs := func(id uuid.UUID) bool { return id == petVisit }
+	tests := []struct {
+		name   string
+		raw    string
+		want   *uuid.UUID
+		wantOK bool
+	}{
+		{"empty unlinks", "", nil, true},
+		{"blank unlinks", "  ", nil, true},
+		{"visit of the pet", petVisit.String(), &petVisit, true},
+		{"another pet's visit", uuid.NewString(), nil, false},
+		{"not a uuid", "visit-1", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseVisitLink(tt.raw, belongs)
+			if ok != tt.wantOK || (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseVisitLink(%q) = %v, %v", tt.raw, got, ok)
+			}
+		})
+	}
+}
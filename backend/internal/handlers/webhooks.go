@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// WebhooksHandler manages the current user's outbound webhook subscriptions and their delivery log.
+type WebhooksHandler struct {
+	DB       *gorm.DB
+	Webhooks *webhooks.Dispatcher
+}
+
+// WebhookDTO is the API shape of a subscription. Secret is only included in the Create response.
+type WebhookDTO struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookRequest is the body for create/update. Active defaults to true; an empty secret on create is generated.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+	Secret string   `json:"secret"`
+}
+
+const (
+	webhookSecretBytes     = 32
+	maxWebhooksPerUser     = 20
+	maxWebhookURLLen       = 2000
+	defaultDeliveryLogSize = 50
+	maxDeliveryLogSize     = 200
+)
+
+func webhookDTO(s models.WebhookSubscription) WebhookDTO {
+	events := []string{}
+	for _, e := range strings.Split(s.Events, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			events = append(events, e)
+		}
+	}
+	return WebhookDTO{ID: s.ID, URL: s.URL, Events: events, Active: s.Active, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt}
+}
+
+// Events lists the event types a subscription can listen for.
+func (h *WebhooksHandler) Events(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks.Events)
+}
+
+func (h *WebhooksHandler) List(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var list []models.WebhookSubscription
+	if err := h.DB.Where("user_id = ?", u.ID).Order("created_at").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]WebhookDTO, 0, len(list))
+	for _, s := range list {
+		out = append(out, webhookDTO(s))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// Create adds a subscription and returns it with its signing secret, which is not shown again.
+func (h *WebhooksHandler) Create(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var body WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	events, err := validateWebhookRequest(&body)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	var count int64
+	h.DB.Model(&models.WebhookSubscription{}).Where("user_id = ?", u.ID).Count(&count)
+	if count >= maxWebhooksPerUser {
+		http.Error(w, `{"error":"too many webhooks"}`, http.StatusBadRequest)
+		return
+	}
+	secret := body.Secret
+	if secret == "" {
+		b := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(b); err != nil {
+			http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		secret = hex.EncodeToString(b)
+	}
+	sub := models.WebhookSubscription{UserID: u.ID, URL: body.URL, Secret: secret, Events: events, Active: true}
+	if body.Active != nil {
+		sub.Active = *body.Active
+	}
+	if err := h.DB.Create(&sub).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	out := webhookDTO(sub)
+	out.Secret = secret
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(out)
+}
+
+// Update changes URL, events, and active flag. A non-empty secret replaces the signing secret.
+func (h *WebhooksHandler) Update(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	var body WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+		return
+	}
+	events, err := validateWebhookRequest(&body)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	updates := map[string]interface{}{"url": body.URL, "events": events, "updated_at": time.Now()}
+	if body.Active != nil {
+		updates["active"] = *body.Active
+	}
+	if body.Secret != "" {
+		updates["secret"] = body.Secret
+	}
+	result := h.DB.Model(&models.WebhookSubscription{}).Where("id = ? AND user_id = ?", id, u.ID).Updates(updates)
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var sub models.WebhookSubscription
+	h.DB.Where("id = ?", id).First(&sub)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhookDTO(sub))
+}
+
+// Delete removes the subscription and its delivery log.
+func (h *WebhooksHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	result := h.DB.Where("id = ? AND user_id = ?", id, u.ID).Delete(&models.WebhookSubscription{})
+	if result.Error != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	h.DB.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries returns the subscription's most recent deliveries, newest first. Optional ?limit= (default 50, max 200).
+func (h *WebhooksHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	limit := defaultDeliveryLogSize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxDeliveryLogSize {
+			http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var count int64
+	h.DB.Model(&models.WebhookSubscription{}).Where("id = ? AND user_id = ?", id, u.ID).Count(&count)
+	if count == 0 {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var list []models.WebhookDelivery
+	if err := h.DB.Where("subscription_id = ?", id).Order("created_at DESC").Limit(limit).Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.WebhookDelivery{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Test queues a webhook.test event for the subscription (even if inactive or not subscribed to it) and returns the delivery.
+func (h *WebhooksHandler) Test(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	var sub models.WebhookSubscription
+	if err := h.DB.Where("id = ? AND user_id = ?", id, u.ID).First(&sub).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if h.Webhooks == nil {
+		http.Error(w, `{"error":"webhooks disabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+	sub.Active = true // deliver the test even when the subscription is paused
+	del, err := h.Webhooks.Enqueue(sub, webhooks.EventTest, map[string]string{"message": "Test event from Pet Medical"})
+	if err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(del)
+}
+
+// validateWebhookRequest normalizes the body and returns the events as stored (comma-separated, de-duplicated).
+// Error messages are fixed strings, never client input, because the handlers embed them in a JSON body as is.
+func validateWebhookRequest(body *WebhookRequest) (string, error) {
+	body.URL = strings.TrimSpace(body.URL)
+	if body.URL == "" {
+		return "", errors.New("url required")
+	}
+	if len(body.URL) > maxWebhookURLLen {
+		return "", errors.New("url too long")
+	}
+	parsed, err := url.Parse(body.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("url must be an http or https URL")
+	}
+	if len(body.Events) == 0 {
+		return "", errors.New("at least one event required")
+	}
+	var events []string
+	seen := make(map[string]bool)
+	for _, e := range body.Events {
+		e = strings.TrimSpace(strings.ToLower(e))
+		if !webhooks.ValidEvent(e) {
+			return "", errors.New("invalid event")
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	if body.Secret != "" && len(body.Secret) < 16 {
+		return "", errors.New("secret must be at least 16 characters")
+	}
+	return strings.Join(events, ","), nil
+}
+
+// emitPetEvent sends a webhook event about a pet's records to the pet's creator, whoever made the change: a
+// subscription covers the pets its account owns, so edits by collaborators reach the owner and are not sent to the
+// collaborator's own subscriptions.
+func emitPetEvent(d *webhooks.Dispatcher, db *gorm.DB, petID uuid.UUID, event string, data interface{}) {
+	if d == nil {
+		return
+	}
+	d.Emit(petCreatorID(db, petID), event, data)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateWebhookRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       WebhookRequest
+		wantErr    string // "" means valid
+		wantEvents string
+	}{
+		{"valid", WebhookRequest{URL: " https://example.com/hook ", Events: []string{"Pet.Created", "pet.created", "weight.recorded"}}, "", "pet.created,weight.recorded"},
+		{"missing url", WebhookRequest{Events: []string{"pet.created"}}, "url required", ""},
+		{"not http", WebhookRequest{URL: "ftp://example.com", Events: []string{"pet.created"}}, "url must be an http or https URL", ""},
+		{"no events", WebhookRequest{URL: "https://example.com"}, "at least one event required", ""},
+		{"unknown event", WebhookRequest{URL: "https://example.com", Events: []string{`x"}`}}, "invalid event", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			events, err := validateWebhookRequest(&body)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				// Handlers build the error body by hand, so the message must keep it valid JSON.
+				if !json.Valid([]byte(`{"error":"` + err.Error() + `"}`)) {
+					t.Errorf("error body for %q is not valid JSON", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateWebhookRequest: %v", err)
+			}
+			if events != tt.wantEvents || body.URL != "https://example.com/hook" {
+				t.Errorf("events, url = %q, %q", events, body.URL)
+			}
+		})
+	}
+}
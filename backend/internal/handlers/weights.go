@@ -8,9 +8,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pet-medical/api/internal/middleware"
 	"github.com/pet-medical/api/internal/models"
+	"github.com/pet-medical/api/internal/webhooks"
 	"gorm.io/gorm"
 )
 
+// lbsPerKg converts kilograms to the pounds that WeightEntry stores.
+const lbsPerKg = 2.20462
+
 // WeightCreateStore abstracts pet ownership and weight creation for tests (mocked instead of DB).
 type WeightCreateStore interface {
 	OwnsPet(userID, petID uuid.UUID) bool
@@ -19,17 +23,8 @@ type WeightCreateStore interface {
 
 type WeightsHandler struct {
 	DB               *gorm.DB
-	WeightCreateStore WeightCreateStore // when non-nil, Create uses this instead of DB
-}
-
-func (h *WeightsHandler) ensurePetOwnership(r *http.Request, petID uuid.UUID) bool {
-	u := middleware.GetUser(r.Context())
-	if u == nil {
-		return false
-	}
-	var count int64
-	h.DB.Model(&models.Pet{}).Where("id = ? AND user_id = ?", petID, u.ID).Count(&count)
-	return count > 0
+	WeightCreateStore WeightCreateStore    // when non-nil, Create uses this instead of DB
+	Webhooks          *webhooks.Dispatcher // optional; emits weight.recorded
 }
 
 func (h *WeightsHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -44,8 +39,7 @@ func (h *WeightsHandler) List(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
 		return
 	}
 	var list []models.WeightEntry
@@ -61,15 +55,17 @@ func (h *WeightsHandler) List(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(list)
 }
 
-func (h *WeightsHandler) createOwnsPet(r *http.Request, petID uuid.UUID) bool {
+// createOwnsPet authorizes a write to the pet, through the WeightCreateStore when one is set (tests).
+func (h *WeightsHandler) createOwnsPet(w http.ResponseWriter, r *http.Request, petID uuid.UUID) bool {
 	if h.WeightCreateStore != nil {
 		u := middleware.GetUser(r.Context())
-		if u == nil {
+		if u == nil || !h.WeightCreateStore.OwnsPet(u.ID, petID) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
 			return false
 		}
-		return h.WeightCreateStore.OwnsPet(u.ID, petID)
+		return true
 	}
-	return h.ensurePetOwnership(r, petID)
+	return requirePetRole(w, r, h.DB, petID, models.PetRoleEditor)
 }
 
 func (h *WeightsHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -84,8 +80,7 @@ func (h *WeightsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.createOwnsPet(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !h.createOwnsPet(w, r, petID) {
 		return
 	}
 	var body struct {
@@ -110,7 +105,7 @@ func (h *WeightsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 	weightLbs := body.WeightLbs
 	if body.WeightKg != nil {
-		weightLbs = *body.WeightKg * 2.20462
+		weightLbs = *body.WeightKg * lbsPerKg
 		entryUnit = "kg"
 	}
 	entry := models.WeightEntry{
@@ -130,6 +125,7 @@ func (h *WeightsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
 		return
 	}
+	emitPetEvent(h.Webhooks, h.DB, petID, webhooks.EventWeightRecorded, entry)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(entry)
@@ -148,8 +144,7 @@ func (h *WeightsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
 		return
 	}
-	if !h.ensurePetOwnership(r, petID) {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
 		return
 	}
 	result := h.DB.Where("id = ? AND pet_id = ?", id, petID).Delete(&models.WeightEntry{})
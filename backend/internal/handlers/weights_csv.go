@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pet-medical/api/internal/middleware"
+	"github.com/pet-medical/api/internal/models"
+)
+
+// weightDuplicateLbs is how close two weigh-ins on the same day must be to count as the same entry; it absorbs the
+// rounding of a kg value exported and imported again.
+const weightDuplicateLbs = 0.05
+
+var (
+	weightCSVFields  = []string{"measured_at", "weight", "unit", "approximate", "notes"}
+	weightCSVAliases = map[string][]string{
+		"measured_at": {"measured_at", "date", "measured", "day"},
+		"weight":      {"weight", "weight_lbs", "weight_kg", "lbs", "lb", "kg", "pounds", "kilograms"},
+		"unit":        {"unit", "units", "entry_unit"},
+		"approximate": {"approximate", "approx", "estimated", "estimate"},
+		"notes":       {"notes", "note", "comment", "comments"},
+	}
+)
+
+// ExportCSV handles GET /pets/{petId}/weights.csv. Each weight is written in the unit it was entered in, oldest first,
+// with the same columns ImportCSV reads.
+func (h *WeightsHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleViewer) {
+		return
+	}
+	var pet models.Pet
+	if err := h.DB.Select("name").Where("id = ?", petID).First(&pet).Error; err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var list []models.WeightEntry
+	if err := h.DB.Where("pet_id = ?", petID).Order("measured_at, created_at").Find(&list).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadFilename(pet.Name, "weights", "csv")}))
+	cw := csv.NewWriter(w)
+	cw.Write(weightCSVFields)
+	for _, e := range list {
+		weight := e.WeightLbs
+		if e.EntryUnit == "kg" {
+			weight = e.WeightLbs / lbsPerKg
+		}
+		approx := "false"
+		if e.Approximate {
+			approx = "true"
+		}
+		cw.Write([]string{e.MeasuredAt, formatCSVNumber(weight), e.EntryUnit, approx, escapeCSVCell(derefOr(e.Notes, ""))})
+	}
+	cw.Flush()
+}
+
+// ImportCSV handles POST /pets/{petId}/weights/import: multipart "file" plus optional form fields "mapping" (JSON
+// object of field to header or column number), "unit" (lbs or kg, for values without one), and "date_format".
+// Valid rows are created and the rest reported by row; ?dry_run=true reports without creating anything.
+func (h *WeightsHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	u := middleware.GetUser(r.Context())
+	if u == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	petID, err := uuid.Parse(mux.Vars(r)["petId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid pet id"}`, http.StatusBadRequest)
+		return
+	}
+	if !requirePetRole(w, r, h.DB, petID, models.PetRoleEditor) {
+		return
+	}
+	table, mapping, ok := readCSVUpload(w, r)
+	if !ok {
+		return
+	}
+	cols, err := mapColumns(table.header, weightCSVFields, weightCSVAliases, mapping)
+	if err != nil {
+		writeCSVError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, f := range []string{"measured_at", "weight"} {
+		if cols[f] < 0 {
+			writeCSVError(w, http.StatusBadRequest, "no column for "+f+"; name one in mapping")
+			return
+		}
+	}
+	var user models.User
+	if err := h.DB.Select("language, weight_unit").Where("id = ?", u.ID).First(&user).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+	unit := strings.ToLower(strings.TrimSpace(r.FormValue("unit")))
+	if unit != "" && unit != "lbs" && unit != "kg" {
+		http.Error(w, `{"error":"unit must be lbs or kg"}`, http.StatusBadRequest)
+		return
+	}
+	if unit == "" {
+		unit = headerUnit(table.header[cols["weight"]])
+	}
+	if unit == "" {
+		unit = user.WeightUnit
+	}
+	format, ambiguous, err := detectDateFormat(table.column(cols["measured_at"]), user.Language != "en", r.FormValue("date_format"))
+	if err != nil {
+		writeCSVError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var existing []models.WeightEntry
+	if err := h.DB.Select("measured_at, weight_lbs").Where("pet_id = ?", petID).Find(&existing).Error; err != nil {
+		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	entries, report := planWeightImport(table, cols, format, unit, existing)
+	report.DryRun = csvDryRun(r)
+	if ambiguous {
+		report.Warnings = append(report.Warnings, "dates read as "+format.Name+"; set date_format if that is wrong")
+	}
+	for i := range entries {
+		entries[i].ID, entries[i].PetID = uuid.New(), petID
+	}
+	writeCSVReport(w, h.DB, &report, &entries, len(entries))
+}
+
+// planWeightImport converts rows to entries, skipping duplicates of existing entries and of earlier rows.
+// unit applies to weights with no unit of their own (in the cell or a unit column).
+func planWeightImport(t *csvTable, cols map[string]int, format csvDateFormat, unit string, existing []models.WeightEntry) ([]models.WeightEntry, CSVImportReport) {
+	report := newCSVImportReport()
+	report.DateFormat, report.Rows = format.Name, len(t.rows)
+	for f, c := range cols {
+		if c >= 0 {
+			report.Columns[f] = t.header[c]
+		}
+	}
+	seen := append([]models.WeightEntry{}, existing...)
+	entries := []models.WeightEntry{}
+	for i, row := range t.rows {
+		line := t.lines[i]
+		fail := func(err error) { report.Errors = append(report.Errors, CSVRowIssue{Row: line, Error: err.Error()}) }
+		date := t.cell(row, cols["measured_at"])
+		if date == "" {
+			fail(errors.New("measured_at required"))
+			continue
+		}
+		measuredAt, err := parseCSVDate(format, date)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		rowUnit := unit
+		if c := t.cell(row, cols["unit"]); c != "" {
+			if rowUnit = parseWeightUnit(c); rowUnit == "" {
+				fail(errors.New("unknown unit " + c))
+				continue
+			}
+		}
+		lbs, entryUnit, approx, err := parseWeightCell(t.cell(row, cols["weight"]), rowUnit)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		duplicate := false
+		for _, e := range seen {
+			if e.MeasuredAt == measuredAt && math.Abs(e.WeightLbs-lbs) < weightDuplicateLbs {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			report.Duplicates = append(report.Duplicates, line)
+			continue
+		}
+		entry := models.WeightEntry{
+			WeightLbs:   lbs,
+			EntryUnit:   entryUnit,
+			MeasuredAt:  measuredAt,
+			Approximate: approx || parseCSVBool(t.cell(row, cols["approximate"])),
+			Notes:       csvStringPtr(t.cell(row, cols["notes"])),
+		}
+		seen = append(seen, entry)
+		entries = append(entries, entry)
+	}
+	return entries, report
+}
+
+// parseWeightCell reads a weight such as "12.5", "12,5 kg", or "~27 lbs" (the tilde marks an estimate) and returns
+// it in pounds along with the unit it was written in.
+func parseWeightCell(s, unit string) (lbs float64, entryUnit string, approx bool, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, "", false, errors.New("weight required")
+	}
+	if strings.HasPrefix(s, "~") {
+		approx, s = true, strings.TrimSpace(s[1:])
+	}
+	if i := strings.IndexFunc(s, func(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') }); i > 0 {
+		if unit = parseWeightUnit(s[i:]); unit == "" {
+			return 0, "", false, errors.New("unknown unit in weight " + s)
+		}
+		s = strings.TrimSpace(s[:i])
+	}
+	v, err := parseCSVNumber(s)
+	if err != nil || v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, "", false, errors.New("invalid weight " + s)
+	}
+	if unit == "kg" {
+		return v * lbsPerKg, "kg", approx, nil
+	}
+	return v, "lbs", approx, nil
+}
+
+// parseWeightUnit normalizes a unit cell to "kg" or "lbs"; "" when it is neither.
+func parseWeightUnit(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "kg", "kgs", "kilo", "kilos", "kilogram", "kilograms":
+		return "kg"
+	case "lb", "lbs", "pound", "pounds":
+		return "lbs"
+	}
+	return ""
+}
+
+// headerUnit reads a unit from a header such as "Weight (kg)"; "" when there is none.
+func headerUnit(header string) string {
+	for _, word := range strings.Split(normalizeHeader(header), "_") {
+		if u := parseWeightUnit(word); u != "" {
+			return u
+		}
+	}
+	return ""
+}
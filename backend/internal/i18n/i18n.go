@@ -14,6 +14,8 @@ var (
 	mu     sync.RWMutex
 	locale string
 	msgs   map[string]string
+	// byLang caches locales loaded by TLang (per-user language, e.g. for emails).
+	byLang = map[string]map[string]string{}
 )
 
 // Init loads messages for the given language code (e.g. "en"). Falls back to "en" if file missing.
@@ -42,13 +44,18 @@ func loadLocale(lang string) map[string]string {
 	return out
 }
 
-// T returns the translation for key. If not found, returns key.
+// T returns the translation for key. If not found, falls back to English and then returns key.
 func T(key string) string {
 	mu.RLock()
-	defer mu.RUnlock()
-	if s, ok := msgs[key]; ok && s != "" {
+	s, ok := msgs[key]
+	lang := locale
+	mu.RUnlock()
+	if ok && s != "" {
 		return s
 	}
+	if lang != "en" {
+		return TLang("en", key)
+	}
 	return key
 }
 
@@ -56,3 +63,36 @@ func T(key string) string {
 func Tf(key string, args ...interface{}) string {
 	return fmt.Sprintf(T(key), args...)
 }
+
+// TLang returns the translation for key in lang (e.g. a user's language setting), falling back to English and then
+// to key. Use for messages sent to a specific user rather than server logs.
+func TLang(lang, key string) string {
+	if s, ok := lookupLang(lang)[key]; ok && s != "" {
+		return s
+	}
+	if lang != "en" {
+		if s, ok := lookupLang("en")[key]; ok && s != "" {
+			return s
+		}
+	}
+	return key
+}
+
+// TfLang returns TLang(lang, key) formatted with fmt.Sprintf.
+func TfLang(lang, key string, args ...interface{}) string {
+	return fmt.Sprintf(TLang(lang, key), args...)
+}
+
+func lookupLang(lang string) map[string]string {
+	mu.RLock()
+	m, ok := byLang[lang]
+	mu.RUnlock()
+	if ok {
+		return m
+	}
+	m = loadLocale(lang)
+	mu.Lock()
+	byLang[lang] = m
+	mu.Unlock()
+	return m
+}
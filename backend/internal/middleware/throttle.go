@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,8 +11,8 @@ import (
 )
 
 // ThrottleByPath returns a middleware that rate-limits by client IP with different limits per path:
-// - /api/auth/login: strict (e.g. 5/min) to prevent brute force
-// - /api/auth/refresh, /api/auth/logout: moderate (e.g. 20/min)
+// - /api/auth/login, /login/mfa, /login/passkey*, /forgot-password, /reset-password: strict (e.g. 5/min) against brute force and mail floods
+// - /api/auth/refresh, /api/auth/logout, /api/auth/2fa/*, /api/auth/passkeys*, /api/auth/sessions*, /api/auth/oidc/*: moderate (e.g. 20/min)
 // - /api/* (rest): general (e.g. 120/min)
 // Uses config for client IP (X-Forwarded-For when from trusted proxy). Returns 429 with Retry-After when exceeded.
 func ThrottleByPath(cfg *config.Config, authLoginPerMin, authOtherPerMin, apiPerMin int) func(http.Handler) http.Handler {
@@ -34,9 +35,12 @@ func ThrottleByPath(cfg *config.Config, authLoginPerMin, authOtherPerMin, apiPer
 
 			var t *throttler
 			switch {
-			case path == "/api/auth/login":
+			case path == "/api/auth/login" || path == "/api/auth/login/mfa" || strings.HasPrefix(path, "/api/auth/login/passkey") ||
+				path == "/api/auth/forgot-password" || path == "/api/auth/reset-password":
 				t = login
-			case path == "/api/auth/refresh" || path == "/api/auth/logout" || path == "/api/auth/change-password":
+			case path == "/api/auth/refresh" || path == "/api/auth/logout" || path == "/api/auth/change-password" ||
+				strings.HasPrefix(path, "/api/auth/2fa/") || strings.HasPrefix(path, "/api/auth/passkeys") ||
+				strings.HasPrefix(path, "/api/auth/sessions") || strings.HasPrefix(path, "/api/auth/oidc/"):
 				t = authOther
 			case len(path) > 4 && path[:4] == "/api":
 				t = api
@@ -64,7 +64,7 @@ func TrustedProxyAuth(cfg *config.Config, db *gorm.DB, jwt *auth.JWT, refreshSto
 				return
 			}
 			expiresAt := time.Now().Add(jwt.RefreshTokenDuration())
-			refreshToken, err := refreshStore.Create(u.ID, expiresAt)
+			refreshToken, err := refreshStore.Create(u.ID, expiresAt, auth.Device{UserAgent: r.UserAgent(), IP: cfg.ClientIP(r)})
 			if err != nil {
 				log.Printf("[TRUSTED_PROXY] refresh create: %v", err)
 				next.ServeHTTP(w, r)
@@ -106,6 +106,12 @@ func applyDefaults(u *models.User, defaultWeightUnit, defaultCurrency, defaultLa
 	}
 }
 
+// CreateExternalUser creates a passwordless user for an email that another system has verified (the trusted proxy
+// or an OpenID Connect provider). name, when set, is used for the display name. Returns a zero User on failure.
+func CreateExternalUser(db *gorm.DB, email, name, defaultWeightUnit, defaultCurrency, defaultLanguage string) models.User {
+	return createUserFromProxy(db, email, name, defaultWeightUnit, defaultCurrency, defaultLanguage)
+}
+
 // createUserFromProxy creates a user with the given email; display name is derived from forwarded-user header or email. PasswordHash is empty (OAuth-only).
 func createUserFromProxy(db *gorm.DB, email, forwardedUser, defaultWeightUnit, defaultCurrency, defaultLanguage string) models.User {
 	displayName := strings.TrimSpace(forwardedUser)
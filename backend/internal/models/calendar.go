@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeed is a user's secret ICS subscription token. Only the SHA-256 hash is stored, like refresh tokens;
+// the plain token is shown once when the feed is created or rotated. One feed per user.
+type CalendarFeed struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex;column:user_id" json:"user_id"`
+	TokenHash      string     `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	LastAccessedAt *time.Time `gorm:"column:last_accessed_at" json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (CalendarFeed) TableName() string { return "calendar_feeds" }
+
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
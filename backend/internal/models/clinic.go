@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Clinic is a per-user directory entry for a veterinary clinic or veterinarian.
+// Pets and vaccinations reference it via ClinicID; the free-text Veterinarian field on vaccinations is kept alongside.
+type Clinic struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;column:user_id" json:"user_id"`
+	Name         string    `gorm:"not null" json:"name"`
+	Veterinarian *string   `json:"veterinarian,omitempty"` // optional doctor name at the clinic
+	Address      *string   `json:"address,omitempty"`
+	Phone        *string   `json:"phone,omitempty"`
+	Email        *string   `json:"email,omitempty"`
+	Emergency    bool      `gorm:"not null;default:false" json:"emergency"`
+	Notes        *string   `json:"notes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Clinic) TableName() string { return "clinics" }
+
+func (c *Clinic) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
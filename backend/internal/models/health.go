@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status values shared by allergies and chronic conditions.
+const (
+	HealthStatusActive   = "active"
+	HealthStatusResolved = "resolved"
+)
+
+// Allergy severities, least to most severe.
+const (
+	AllergySeverityMild            = "mild"
+	AllergySeverityModerate        = "moderate"
+	AllergySeveritySevere          = "severe"
+	AllergySeverityLifeThreatening = "life_threatening"
+)
+
+// Allergy is a known allergen for a pet (food, medication, environmental, etc.).
+type Allergy struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID          uuid.UUID `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	Allergen       string    `gorm:"not null" json:"allergen"`
+	Reaction       *string   `json:"reaction,omitempty"`
+	Severity       string    `gorm:"not null" json:"severity"`
+	Status         string    `gorm:"not null;default:'active'" json:"status"`
+	IdentifiedDate *string   `gorm:"column:identified_date" json:"identified_date,omitempty"`
+	Notes          *string   `json:"notes,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (Allergy) TableName() string { return "allergies" }
+
+func (a *Allergy) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChronicCondition is a long-term diagnosis (e.g. diabetes, hypothyroidism) and who manages it.
+type ChronicCondition struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID         uuid.UUID  `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	Diagnosis     string     `gorm:"not null" json:"diagnosis"`
+	DiagnosedDate *string    `gorm:"column:diagnosed_date" json:"diagnosed_date,omitempty"`
+	Status        string     `gorm:"not null;default:'active'" json:"status"`
+	ManagingVet   *string    `gorm:"column:managing_vet" json:"managing_vet,omitempty"`
+	ClinicID      *uuid.UUID `gorm:"type:uuid;column:clinic_id" json:"clinic_id,omitempty"`
+	Notes         *string    `json:"notes,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (ChronicCondition) TableName() string { return "chronic_conditions" }
+
+func (c *ChronicCondition) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
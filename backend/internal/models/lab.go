@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Lab analyte flags relative to the reference range.
+const (
+	LabFlagNormal   = "normal"
+	LabFlagLow      = "low"
+	LabFlagHigh     = "high"
+	LabFlagAbnormal = "abnormal" // out of range without a direction (e.g. qualitative results)
+)
+
+// LabPanel is one set of lab results (bloodwork, urinalysis, etc.), optionally linked to the uploaded report.
+type LabPanel struct {
+	ID         uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID      uuid.UUID    `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	PanelDate  string       `gorm:"column:panel_date;not null" json:"panel_date"`
+	LabName    *string      `gorm:"column:lab_name" json:"lab_name,omitempty"`
+	DocumentID *uuid.UUID   `gorm:"type:uuid;column:document_id" json:"document_id,omitempty"`
+	Notes      *string      `json:"notes,omitempty"`
+	Analytes   []LabAnalyte `gorm:"-" json:"analytes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+func (LabPanel) TableName() string { return "lab_panels" }
+
+func (p *LabPanel) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// LabAnalyte is a single measured value in a panel. PetID is copied from the panel so history queries need no join.
+type LabAnalyte struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PanelID   uuid.UUID `gorm:"type:uuid;not null;column:panel_id" json:"panel_id"`
+	PetID     uuid.UUID `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Value     float64   `gorm:"not null" json:"value"`
+	Unit      *string   `json:"unit,omitempty"`
+	RefLow    *float64  `gorm:"column:ref_low" json:"ref_low,omitempty"`
+	RefHigh   *float64  `gorm:"column:ref_high" json:"ref_high,omitempty"`
+	Flag      *string   `json:"flag,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (LabAnalyte) TableName() string { return "lab_analytes" }
+
+func (a *LabAnalyte) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Medication is a prescription or ongoing medication for a pet.
+// Frequency is one of the MedicationFrequency* values; StartDate/EndDate are YYYY-MM-DD like other record dates.
+type Medication struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID            uuid.UUID `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	Name             string    `gorm:"not null" json:"name"`
+	Dose             *string   `json:"dose,omitempty"` // free text, e.g. "5 mg" or "1/2 tablet"
+	Frequency        string    `gorm:"not null" json:"frequency"`
+	Route            *string   `json:"route,omitempty"` // oral, topical, injection, etc.
+	PrescribingVet   *string   `gorm:"column:prescribing_vet" json:"prescribing_vet,omitempty"`
+	StartDate        string    `gorm:"column:start_date;not null" json:"start_date"`
+	EndDate          *string   `gorm:"column:end_date" json:"end_date,omitempty"`
+	RefillsRemaining *int      `gorm:"column:refills_remaining" json:"refills_remaining,omitempty"`
+	Notes            *string   `json:"notes,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+const (
+	MedicationFrequencyOnceDaily       = "once_daily"
+	MedicationFrequencyTwiceDaily      = "twice_daily"
+	MedicationFrequencyThreeTimesDaily = "three_times_daily"
+	MedicationFrequencyEveryOtherDay   = "every_other_day"
+	MedicationFrequencyWeekly          = "weekly"
+	MedicationFrequencyMonthly         = "monthly"
+	MedicationFrequencyAsNeeded        = "as_needed"
+)
+
+// ValidMedicationFrequency reports whether f is a known frequency value.
+func ValidMedicationFrequency(f string) bool {
+	switch f {
+	case MedicationFrequencyOnceDaily, MedicationFrequencyTwiceDaily, MedicationFrequencyThreeTimesDaily,
+		MedicationFrequencyEveryOtherDay, MedicationFrequencyWeekly, MedicationFrequencyMonthly, MedicationFrequencyAsNeeded:
+		return true
+	}
+	return false
+}
+
+func (Medication) TableName() string { return "medications" }
+
+func (m *Medication) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// MedicationDose is one administered (or skipped) dose of a Medication.
+// Status is one of MedicationDoseGiven, MedicationDoseLate, MedicationDoseSkipped.
+type MedicationDose struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	MedicationID uuid.UUID  `gorm:"type:uuid;not null;column:medication_id" json:"medication_id"`
+	PetID        uuid.UUID  `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	GivenAt      time.Time  `gorm:"column:given_at;not null" json:"given_at"`
+	Status       string     `gorm:"not null" json:"status"`
+	GivenBy      *string    `gorm:"column:given_by" json:"given_by,omitempty"` // name of the person who gave the dose
+	RecordedBy   *uuid.UUID `gorm:"type:uuid;column:recorded_by" json:"recorded_by,omitempty"`
+	Notes        *string    `json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+const (
+	MedicationDoseGiven   = "given"
+	MedicationDoseLate    = "late"
+	MedicationDoseSkipped = "skipped"
+)
+
+func (MedicationDose) TableName() string { return "medication_doses" }
+
+func (d *MedicationDose) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
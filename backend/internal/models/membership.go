@@ -0,0 +1,130 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Pet roles, from least to most privileged. The pet's creator (pets.user_id) is always an owner without a membership row.
+const (
+	PetRoleViewer = "viewer" // read-only
+	PetRoleEditor = "editor" // read and write records; cannot share or delete the pet
+	PetRoleOwner  = "owner"  // full control, including sharing and deleting the pet
+)
+
+// PetRoleRank orders roles for comparison; unknown roles rank 0.
+func PetRoleRank(role string) int {
+	switch role {
+	case PetRoleViewer:
+		return 1
+	case PetRoleEditor:
+		return 2
+	case PetRoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// PetMembership grants a user other than the pet's creator access to the pet.
+type PetMembership struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID     uuid.UUID `gorm:"type:uuid;not null;column:pet_id;uniqueIndex:idx_pet_memberships_pet_user" json:"pet_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;column:user_id;uniqueIndex:idx_pet_memberships_pet_user;index" json:"user_id"`
+	Role      string    `gorm:"not null" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (PetMembership) TableName() string { return "pet_memberships" }
+
+func (m *PetMembership) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// PetInvitation is a pending offer to share a pet with whoever signs in with Email. Accepting it creates a PetMembership.
+type PetInvitation struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID     uuid.UUID `gorm:"type:uuid;not null;column:pet_id;index" json:"pet_id"`
+	InvitedBy uuid.UUID `gorm:"type:uuid;not null;column:invited_by" json:"invited_by"`
+	Email     string    `gorm:"not null;index" json:"email"` // lowercased
+	Role      string    `gorm:"not null" json:"role"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PetInvitation) TableName() string { return "pet_invitations" }
+
+func (i *PetInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// Household roles. Admins manage the household and its members; on the household's pets, admins act as owners and
+// members as editors.
+const (
+	HouseholdRoleAdmin  = "admin"
+	HouseholdRoleMember = "member"
+)
+
+// HouseholdPetRole maps a household role to the role its holder gets on the household's pets.
+func HouseholdPetRole(role string) string {
+	switch role {
+	case HouseholdRoleAdmin:
+		return PetRoleOwner
+	case HouseholdRoleMember:
+		return PetRoleEditor
+	}
+	return ""
+}
+
+// Household is a group of users that owns pets together; pets with a household_id are visible to every member.
+type Household struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null;column:created_by" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Household) TableName() string { return "households" }
+
+func (h *Household) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+// HouseholdMember puts a user in a household with an admin or member role.
+type HouseholdMember struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	HouseholdID uuid.UUID `gorm:"type:uuid;not null;column:household_id;uniqueIndex:idx_household_members_household_user" json:"household_id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;column:user_id;uniqueIndex:idx_household_members_household_user;index" json:"user_id"`
+	Role        string    `gorm:"not null" json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (HouseholdMember) TableName() string { return "household_members" }
+
+func (m *HouseholdMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// AccessiblePetIDs is a subquery of the ids of every pet the user can read: their own, those shared with them, and
+// those of every household they belong to.
+func AccessiblePetIDs(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&Pet{}).Select("id").Where("user_id = ? OR id IN (?) OR household_id IN (?)", userID,
+		db.Model(&PetMembership{}).Select("pet_id").Where("user_id = ?", userID),
+		db.Model(&HouseholdMember{}).Select("household_id").Where("user_id = ?", userID))
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SchemaMigration records a one-time data migration that has completed, so it is not run again.
+type SchemaMigration struct {
+	Name      string    `gorm:"primaryKey" json:"name"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null" json:"applied_at"`
+}
+
+func (SchemaMigration) TableName() string { return "schema_migrations" }
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification kinds recorded in SentNotification.
+const (
+	NotificationVaccinationDue     = "vaccination_due"
+	NotificationVaccinationOverdue = "vaccination_overdue"
+	// NotificationWebhookVaccinationDue marks a vaccination.due webhook event as emitted.
+	NotificationWebhookVaccinationDue = "webhook_vaccination_due"
+)
+
+// SentNotification records a reminder that was emailed (or a webhook event emitted) so it is not sent again, including after a restart.
+// DueDate is part of the key so a changed next_due produces a fresh reminder, and UserID so each user who can see the
+// pet gets their own.
+type SentNotification struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;column:user_id;uniqueIndex:idx_sent_notifications_key" json:"user_id"`
+	Kind      string    `gorm:"not null;uniqueIndex:idx_sent_notifications_key" json:"kind"`
+	SubjectID uuid.UUID `gorm:"type:uuid;not null;column:subject_id;uniqueIndex:idx_sent_notifications_key" json:"subject_id"`
+	DueDate   string    `gorm:"column:due_date;not null;uniqueIndex:idx_sent_notifications_key" json:"due_date"`
+	SentAt    time.Time `gorm:"column:sent_at;not null" json:"sent_at"`
+}
+
+func (SentNotification) TableName() string { return "sent_notifications" }
+
+func (n *SentNotification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
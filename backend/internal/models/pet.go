@@ -21,6 +21,8 @@ type Pet struct {
 	MicrochipCompany *string `gorm:"column:microchip_company" json:"microchip_company,omitempty"`
 	Notes       *string    `json:"notes,omitempty"`
 	PhotoURL    *string    `gorm:"column:photo_url" json:"photo_url,omitempty"`
+	ClinicID    *uuid.UUID `gorm:"type:uuid;column:clinic_id" json:"clinic_id,omitempty"` // primary vet from the clinic directory
+	HouseholdID *uuid.UUID `gorm:"type:uuid;column:household_id;index" json:"household_id"` // nil for a personal pet
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -44,6 +46,8 @@ type Vaccination struct {
 	Veterinarian   *string    `json:"veterinarian,omitempty"`
 	BatchNumber    *string    `gorm:"column:batch_number" json:"batch_number,omitempty"`
 	Notes          *string    `json:"notes,omitempty"`
+	VisitID        *uuid.UUID `gorm:"type:uuid;column:visit_id" json:"visit_id,omitempty"`
+	ClinicID       *uuid.UUID `gorm:"type:uuid;column:clinic_id" json:"clinic_id,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
@@ -104,6 +108,7 @@ type Document struct {
 	MimeType      *string   `gorm:"column:mime_type" json:"mime_type,omitempty"`
 	Notes         *string   `json:"notes,omitempty"`
 	ExtractedText *string   `gorm:"column:extracted_text" json:"-"` // OCR/text extraction for search; not exposed in API
+	VisitID       *uuid.UUID `gorm:"type:uuid;column:visit_id" json:"visit_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Share link scopes: which parts of a pet's record a link exposes.
+const (
+	ShareScopeProfile      = "profile"
+	ShareScopeVaccinations = "vaccinations"
+	ShareScopeWeights      = "weights"
+	ShareScopeDocuments    = "documents" // only the documents listed in DocumentIDs
+)
+
+// ShareLink is a public, read-only, expiring link to part of a pet's record, for vets and sitters without an account.
+// Like CalendarFeed, only the SHA-256 hash of the token is stored. Scopes and DocumentIDs are comma-separated.
+// Revoked links are kept (RevokedAt set) so their access log stays readable.
+type ShareLink struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID          uuid.UUID  `gorm:"type:uuid;not null;column:pet_id;index" json:"pet_id"`
+	CreatedBy      uuid.UUID  `gorm:"type:uuid;not null;column:created_by" json:"created_by"`
+	TokenHash      string     `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	Label          *string    `json:"label,omitempty"`
+	Scopes         string     `gorm:"not null" json:"-"`
+	DocumentIDs    string     `gorm:"column:document_ids;not null;default:''" json:"-"`
+	ExpiresAt      time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	RevokedAt      *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	LastAccessedAt *time.Time `gorm:"column:last_accessed_at" json:"last_accessed_at,omitempty"`
+	AccessCount    int        `gorm:"column:access_count;not null;default:0" json:"access_count"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (ShareLink) TableName() string { return "share_links" }
+
+func (l *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// ShareLinkAccess records one request to a share link: opening it, or downloading one of its documents.
+type ShareLinkAccess struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ShareLinkID uuid.UUID  `gorm:"type:uuid;not null;column:share_link_id;index" json:"share_link_id"`
+	DocumentID  *uuid.UUID `gorm:"type:uuid;column:document_id" json:"document_id,omitempty"` // nil when the link itself was opened
+	IP          string     `gorm:"column:ip" json:"ip"`
+	UserAgent   string     `gorm:"column:user_agent" json:"user_agent"`
+	AccessedAt  time.Time  `gorm:"column:accessed_at;not null" json:"accessed_at"`
+}
+
+func (ShareLinkAccess) TableName() string { return "share_link_accesses" }
+
+func (a *ShareLinkAccess) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -16,6 +16,14 @@ type User struct {
 	WeightUnit   string    `gorm:"column:weight_unit" json:"weight_unit"`
 	Currency     string    `gorm:"not null" json:"currency"`
 	Language     string    `gorm:"not null" json:"language"`
+	// Reminder emails: opt-in, sent ReminderLeadDays before a vaccination's next_due and again once overdue.
+	ReminderEmails   bool `gorm:"column:reminder_emails;not null;default:false" json:"reminder_emails"`
+	ReminderLeadDays int  `gorm:"column:reminder_lead_days;not null;default:14" json:"reminder_lead_days"`
+	// TOTP two-factor: TOTPSecret is set at enrollment and TOTPEnabled once a code confirms it. TOTPLastStep is the
+	// last accepted time step, so each code works only once.
+	TOTPSecret   *string   `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabled  bool      `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64     `gorm:"column:totp_last_step;not null;default:0" json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -29,12 +37,17 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// RefreshToken is one login session. The row keeps its ID when the token is rotated on refresh, so CreatedAt is the
+// login time; UserAgent, IP, and LastUsedAt describe the device as of the latest refresh.
 type RefreshToken struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;column:user_id" json:"user_id"`
-	TokenHash string    `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
-	ExpiresAt time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;column:user_id" json:"user_id"`
+	TokenHash  string     `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UserAgent  string     `gorm:"column:user_agent;not null;default:''" json:"user_agent"`
+	IP         string     `gorm:"column:ip;not null;default:''" json:"ip"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 func (RefreshToken) TableName() string { return "refresh_tokens" }
@@ -46,6 +59,98 @@ func (r *RefreshToken) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// RecoveryCode is a hashed one-time code that stands in for the TOTP code when the authenticator is lost.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index" json:"user_id"`
+	CodeHash  string     `gorm:"column:code_hash;not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string { return "recovery_codes" }
+
+func (c *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// MFAAttempt records a second-factor attempt made with an MFA token. ID is the token's jti, so each token can be
+// tried once; failed attempts are also counted per user to lock out guessing with many tokens.
+type MFAAttempt struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;column:user_id;index" json:"user_id"`
+	Succeeded bool      `gorm:"not null;default:false" json:"succeeded"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (MFAAttempt) TableName() string { return "mfa_attempts" }
+
+// PasswordResetToken is a hashed, single-use token emailed by "forgot password". UsedAt is set when it is redeemed.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index" json:"user_id"`
+	TokenHash string     `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string { return "password_reset_tokens" }
+
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Passkey is a WebAuthn credential the user can sign in with instead of a password. PublicKey is the COSE key from
+// registration; SignCount is the authenticator's last signature counter (zero for authenticators that do not count).
+type Passkey struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;column:user_id;index" json:"user_id"`
+	Name           string     `gorm:"not null" json:"name"`
+	CredentialID   []byte     `gorm:"column:credential_id;not null;uniqueIndex" json:"-"`
+	PublicKey      []byte     `gorm:"column:public_key;not null" json:"-"`
+	SignCount      int64      `gorm:"column:sign_count;not null;default:0" json:"-"`
+	Transports     string     `gorm:"column:transports" json:"-"` // comma-separated hints from the browser, e.g. "internal,hybrid"
+	BackupEligible bool       `gorm:"column:backup_eligible;not null;default:false" json:"backup_eligible"`
+	LastUsedAt     *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (Passkey) TableName() string { return "passkeys" }
+
+func (p *Passkey) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// PasskeyChallenge is a WebAuthn challenge waiting for its response. Each is consumed by the first attempt to use it.
+// UserID is set for registration and nil for login, where the credential identifies the user.
+type PasskeyChallenge struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;column:user_id" json:"user_id"`
+	Purpose   string     `gorm:"not null" json:"purpose"` // "register" or "login"
+	Challenge []byte     `gorm:"not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasskeyChallenge) TableName() string { return "passkey_challenges" }
+
+func (c *PasskeyChallenge) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
 // UserCustomOption stores per-user custom dropdown values (species, breed, vaccination).
 type UserCustomOption struct {
 	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
@@ -53,7 +158,9 @@ type UserCustomOption struct {
 	OptionType string    `gorm:"column:option_type;not null" json:"option_type"`
 	Value      string    `gorm:"not null" json:"value"`
 	Context    string    `gorm:"not null;default:''" json:"context"`
-	CreatedAt  time.Time `json:"created_at"`
+	// DurationMonths is an optional validity period for custom vaccinations, used to compute next_due.
+	DurationMonths *int      `gorm:"column:duration_months" json:"duration_months,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 func (UserCustomOption) TableName() string { return "user_custom_options" }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VetVisit is a clinic visit or appointment. Vaccinations and documents from the visit reference it via VisitID.
+type VetVisit struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PetID        uuid.UUID `gorm:"type:uuid;not null;column:pet_id" json:"pet_id"`
+	VisitDate    string    `gorm:"column:visit_date;not null" json:"visit_date"`
+	Clinic       *string   `json:"clinic,omitempty"`
+	Veterinarian *string   `json:"veterinarian,omitempty"`
+	Reason       *string   `json:"reason,omitempty"`
+	Diagnosis    *string   `json:"diagnosis,omitempty"`
+	FollowUpDate *string   `gorm:"column:follow_up_date" json:"follow_up_date,omitempty"`
+	CostUSD      *float64  `gorm:"column:cost_usd" json:"cost_usd,omitempty"`
+	Notes        *string   `json:"notes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (VetVisit) TableName() string { return "vet_visits" }
+
+func (v *VetVisit) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
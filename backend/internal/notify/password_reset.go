@@ -0,0 +1,19 @@
+package notify
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pet-medical/api/internal/i18n"
+)
+
+// RenderPasswordReset builds the localized subject and plain-text body of a password reset email.
+func RenderPasswordReset(lang, displayName, link string, ttl time.Duration) (string, string) {
+	var b strings.Builder
+	b.WriteString(i18n.TfLang(lang, "email.password_reset.greeting", displayName) + "\n\n")
+	b.WriteString(i18n.TLang(lang, "email.password_reset.body") + "\n\n")
+	b.WriteString(link + "\n\n")
+	b.WriteString(i18n.TfLang(lang, "email.password_reset.expiry", int(ttl.Minutes())) + "\n\n")
+	b.WriteString(i18n.TLang(lang, "email.password_reset.footer") + "\n")
+	return i18n.TLang(lang, "email.password_reset.subject"), b.String()
+}
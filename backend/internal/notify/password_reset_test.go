@@ -0,0 +1,19 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderPasswordReset(t *testing.T) {
+	subject, body := RenderPasswordReset("de", "Ana", "https://pets.example.com/reset-password?token=abc", time.Hour)
+	if subject != "Pet-Medical-Passwort zurücksetzen" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{"Hallo Ana,", "https://pets.example.com/reset-password?token=abc", "60 Minuten"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+}
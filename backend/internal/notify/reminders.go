@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/i18n"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const dateLayout = "2006-01-02"
+
+// ReminderScheduler periodically emails opted-in users about vaccinations coming due within their lead time and
+// again once they are overdue, for every pet they can access (their own, shared with them, or in their households).
+// Each (kind, vaccination, due date) is sent to a user at most once, tracked in sent_notifications.
+type ReminderScheduler struct {
+	DB              *gorm.DB
+	Mailer          Mailer
+	Interval        time.Duration
+	PublicURL       string // optional; adds a link to the app
+	DefaultLanguage string // used when the user has no language set
+}
+
+// reminderVaccination is a vaccination joined with its pet's name.
+type reminderVaccination struct {
+	ID             uuid.UUID
+	PetID          uuid.UUID
+	PetName        string
+	Name           string
+	AdministeredAt string
+	NextDue        *string
+}
+
+// reminderItem is one line of a reminder email.
+type reminderItem struct {
+	Kind          string
+	VaccinationID uuid.UUID
+	PetName       string
+	Vaccine       string
+	DueDate       string
+}
+
+// Start runs the scheduler until ctx is cancelled: once immediately, then every Interval.
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	go func() {
+		s.runLogged()
+		t := time.NewTicker(s.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				s.runLogged()
+			}
+		}
+	}()
+}
+
+func (s *ReminderScheduler) runLogged() {
+	sent, err := s.RunOnce(time.Now())
+	if err != nil {
+		log.Printf("[REMINDERS] run failed: %v", err)
+		return
+	}
+	if sent > 0 {
+		log.Printf("[REMINDERS] sent %d reminder email(s)", sent)
+	}
+}
+
+// RunOnce checks every opted-in user and sends at most one digest email each. Returns the number of emails sent.
+// A failed send is logged and retried on the next run since nothing is recorded for it.
+func (s *ReminderScheduler) RunOnce(now time.Time) (int, error) {
+	var users []models.User
+	if err := s.DB.Where("reminder_emails = ?", true).Find(&users).Error; err != nil {
+		return 0, err
+	}
+	today := now.Format(dateLayout)
+	sent := 0
+	for _, u := range users {
+		var rows []reminderVaccination
+		err := s.DB.Table("vaccinations").
+			Select("vaccinations.id, vaccinations.pet_id, pets.name AS pet_name, vaccinations.name, vaccinations.administered_at, vaccinations.next_due").
+			Joins("JOIN pets ON pets.id = vaccinations.pet_id").
+			Where("pets.id IN (?)", models.AccessiblePetIDs(s.DB, u.ID)).
+			Order("vaccinations.administered_at DESC, vaccinations.created_at DESC").
+			Scan(&rows).Error
+		if err != nil {
+			return sent, err
+		}
+		items, err := s.unsent(u.ID, dueReminders(today, u.ReminderLeadDays, rows))
+		if err != nil {
+			return sent, err
+		}
+		if len(items) == 0 {
+			continue
+		}
+		lang := u.Language
+		if lang == "" {
+			lang = s.DefaultLanguage
+		}
+		subject, body := renderReminder(lang, u.DisplayName, s.PublicURL, items)
+		if err := s.Mailer.Send(u.Email, subject, body); err != nil {
+			log.Printf("[REMINDERS] send to user_id=%s failed: %v", u.ID, err)
+			continue
+		}
+		records := make([]models.SentNotification, len(items))
+		for i, it := range items {
+			records[i] = models.SentNotification{UserID: u.ID, Kind: it.Kind, SubjectID: it.VaccinationID, DueDate: it.DueDate, SentAt: now}
+		}
+		if err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&records).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// unsent drops items already sent to userID. Records are per user, so one household member's reminder does not
+// stop the others from getting theirs.
+func (s *ReminderScheduler) unsent(userID uuid.UUID, items []reminderItem) ([]reminderItem, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, len(items))
+	for i, it := range items {
+		ids[i] = it.VaccinationID
+	}
+	var prior []models.SentNotification
+	if err := s.DB.Where("user_id = ? AND subject_id IN ?", userID, ids).Find(&prior).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(prior))
+	for _, p := range prior {
+		done[p.Kind+"|"+p.SubjectID.String()+"|"+p.DueDate] = true
+	}
+	out := items[:0]
+	for _, it := range items {
+		if !done[it.Kind+"|"+it.VaccinationID.String()+"|"+it.DueDate] {
+			out = append(out, it)
+		}
+	}
+	return out, nil
+}
+
+// dueReminders picks the vaccinations to remind about on today. Only the latest dose of each vaccine per pet counts
+// (rows must be newest first), so a booster supersedes the earlier next_due.
+func dueReminders(today string, leadDays int, rows []reminderVaccination) []reminderItem {
+	todayT, err := time.Parse(dateLayout, today)
+	if err != nil {
+		return nil
+	}
+	var out []reminderItem
+	seen := make(map[string]bool)
+	for _, v := range rows {
+		key := v.PetID.String() + "|" + strings.ToLower(strings.Join(strings.Fields(v.Name), " "))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if v.NextDue == nil {
+			continue
+		}
+		due, err := time.Parse(dateLayout, *v.NextDue)
+		if err != nil {
+			continue
+		}
+		days := int(due.Sub(todayT).Hours() / 24)
+		kind := ""
+		switch {
+		case days < 0:
+			kind = models.NotificationVaccinationOverdue
+		case days <= leadDays:
+			kind = models.NotificationVaccinationDue
+		default:
+			continue
+		}
+		out = append(out, reminderItem{Kind: kind, VaccinationID: v.ID, PetName: v.PetName, Vaccine: v.Name, DueDate: *v.NextDue})
+	}
+	return out
+}
+
+// renderReminder builds the localized subject and plain-text body, overdue items first.
+func renderReminder(lang, displayName, publicURL string, items []reminderItem) (string, string) {
+	var due, overdue []string
+	for _, it := range items {
+		line := i18n.TfLang(lang, "email.reminder.item", it.PetName, it.Vaccine, it.DueDate)
+		if it.Kind == models.NotificationVaccinationOverdue {
+			overdue = append(overdue, line)
+		} else {
+			due = append(due, line)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(i18n.TfLang(lang, "email.reminder.greeting", displayName) + "\n\n")
+	if len(overdue) > 0 {
+		b.WriteString(i18n.TLang(lang, "email.reminder.overdue_heading") + "\n")
+		b.WriteString(strings.Join(overdue, "\n") + "\n\n")
+	}
+	if len(due) > 0 {
+		b.WriteString(i18n.TLang(lang, "email.reminder.due_heading") + "\n")
+		b.WriteString(strings.Join(due, "\n") + "\n\n")
+	}
+	if publicURL != "" {
+		b.WriteString(i18n.TfLang(lang, "email.reminder.link", publicURL) + "\n\n")
+	}
+	b.WriteString(i18n.TLang(lang, "email.reminder.footer") + "\n")
+	return i18n.TfLang(lang, "email.reminder.subject", len(items)), b.String()
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestDueReminders(t *testing.T) {
+	rex := uuid.New()
+	rows := []reminderVaccination{ // newest first
+		{ID: uuid.New(), PetID: rex, PetName: "Rex", Name: "Rabies", AdministeredAt: "2024-06-01", NextDue: strPtr("2025-01-10")},
+		{ID: uuid.New(), PetID: rex, PetName: "Rex", Name: "rabies", AdministeredAt: "2023-06-01", NextDue: strPtr("2024-06-01")}, // superseded
+		{ID: uuid.New(), PetID: rex, PetName: "Rex", Name: "Bordetella", AdministeredAt: "2023-12-01", NextDue: strPtr("2024-12-01")},
+		{ID: uuid.New(), PetID: rex, PetName: "Rex", Name: "Leptospirosis", AdministeredAt: "2024-03-01", NextDue: strPtr("2025-03-01")}, // beyond lead
+		{ID: uuid.New(), PetID: rex, PetName: "Rex", Name: "Lyme", AdministeredAt: "2024-03-01"},
+	}
+	items := dueReminders("2025-01-01", 14, rows)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2: %+v", len(items), items)
+	}
+	if items[0].Vaccine != "Rabies" || items[0].Kind != models.NotificationVaccinationDue {
+		t.Errorf("item 0 = %+v, want Rabies due", items[0])
+	}
+	if items[1].Vaccine != "Bordetella" || items[1].Kind != models.NotificationVaccinationOverdue {
+		t.Errorf("item 1 = %+v, want Bordetella overdue", items[1])
+	}
+}
+
+func TestRenderReminder_Localized(t *testing.T) {
+	items := []reminderItem{
+		{Kind: models.NotificationVaccinationDue, PetName: "Rex", Vaccine: "Rabies", DueDate: "2025-01-10"},
+		{Kind: models.NotificationVaccinationOverdue, PetName: "Mia", Vaccine: "FVRCP", DueDate: "2024-12-01"},
+	}
+	subject, body := renderReminder("es", "Ana", "https://pets.example.com", items)
+	if !strings.Contains(subject, "2 vacuna") {
+		t.Errorf("subject not localized: %q", subject)
+	}
+	for _, want := range []string{"Hola Ana,", "- Mia: FVRCP (vence el 2024-12-01)", "https://pets.example.com"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "Vencidas") > strings.Index(body, "Próximas") {
+		t.Errorf("overdue section should come first:\n%s", body)
+	}
+
+	// Unknown language falls back to English.
+	subject, _ = renderReminder("xx", "Ana", "", items)
+	if !strings.HasPrefix(subject, "Pet care reminder") {
+		t.Errorf("expected English fallback, got %q", subject)
+	}
+}
+
+// TestSMTPMailer_Send delivers through a minimal in-process SMTP capture server.
+func TestSMTPMailer_Send(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	captured := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 capture ready")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					captured <- data.String()
+					reply("250 queued")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 capture")
+			case "DATA":
+				inData = true
+				reply("354 go ahead")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	m := &SMTPMailer{Host: "127.0.0.1", Port: port, From: "pets@example.com", TLS: "none"}
+	if err := m.Send("owner@example.com", "Pet care reminder", "Line one\nLine two"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	msg := <-captured
+	for _, want := range []string{"To: owner@example.com\r\n", "Subject: Pet care reminder\r\n", "Line one\r\nLine two"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIssuer is a minimal OpenID provider: discovery, JWKS, and a token endpoint that issues one code.
+type mockIssuer struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+	code   string
+	pkce   string // expected S256 challenge
+	claims jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{t: t, key: key, kid: "k1", code: "the-code"}
+	mux := http.NewServeMux()
+	discovery := func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	}
+	mux.HandleFunc("/.well-known/openid-configuration", discovery)
+	// Served under another path too, so a provider configured with that issuer sees a mismatch.
+	mux.HandleFunc("/realm/.well-known/openid-configuration", discovery)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := m.key.PublicKey
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": m.kid, "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		if r.PostForm.Get("code") != m.code || CodeChallenge(r.PostForm.Get("code_verifier")) != m.pkce ||
+			id != "client" || secret != "s3cret%2F" || r.PostForm.Get("redirect_uri") != "https://app/cb" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": m.sign(m.claims)})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockIssuer) sign(claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = m.kid
+	s, err := tok.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return s
+}
+
+func (m *mockIssuer) baseClaims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": m.srv.URL, "aud": "client", "sub": "user-1", "nonce": nonce,
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+		"email": "Jane@Example.com", "email_verified": "true", "name": "Jane Doe",
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	m := newMockIssuer(t)
+	p := New(m.srv.URL+"/", "client", "s3cret/", []string{"email"})
+	ctx := context.Background()
+
+	req, err := NewAuthRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(ctx, req, "https://app/cb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("state") != req.State || q.Get("nonce") != req.Nonce ||
+		q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid email" {
+		t.Fatalf("auth url = %s", authURL)
+	}
+	m.pkce = q.Get("code_challenge")
+	m.claims = m.baseClaims(req.Nonce)
+
+	idToken, err := p.Exchange(ctx, m.code, req.Verifier, "https://app/cb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := p.VerifyIDToken(ctx, idToken, req.Nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "Jane@Example.com" || !claims.EmailVerified || claims.Name != "Jane Doe" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	if _, err := p.Exchange(ctx, m.code, "wrong-verifier", "https://app/cb"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("wrong verifier: %v", err)
+	}
+}
+
+func TestVerifyIDToken_Rejections(t *testing.T) {
+	m := newMockIssuer(t)
+	p := New(m.srv.URL, "client", "", nil)
+	ctx := context.Background()
+
+	tests := map[string]func(c jwt.MapClaims){
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"wrong nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"azp mismatch":   func(c jwt.MapClaims) { c["aud"] = []string{"client", "other"}; c["azp"] = "other" },
+	}
+	for name, mutate := range tests {
+		c := m.baseClaims("n")
+		mutate(c)
+		if _, err := p.VerifyIDToken(ctx, m.sign(c), "n"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+
+	forged, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, m.baseClaims("n"))
+	tok.Header["kid"] = m.kid
+	raw, _ := tok.SignedString(forged)
+	if _, err := p.VerifyIDToken(ctx, raw, "n"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("forged signature: %v", err)
+	}
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, m.baseClaims("n"))
+	raw, _ = hs.SignedString([]byte("client"))
+	if _, err := p.VerifyIDToken(ctx, raw, "n"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("HS256 token: %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	m := newMockIssuer(t)
+	p := New(m.srv.URL, "client", "", nil)
+	ctx := context.Background()
+	if _, err := p.VerifyIDToken(ctx, m.sign(m.baseClaims("n")), "n"); err != nil {
+		t.Fatal(err)
+	}
+	m.key, _ = rsa.GenerateKey(rand.Reader, 2048)
+	m.kid = "k2"
+	// Within the refresh interval an unknown key is not refetched.
+	if _, err := p.VerifyIDToken(ctx, m.sign(m.baseClaims("n")), "n"); err == nil {
+		t.Fatal("unknown key accepted without refetch")
+	}
+	p.keysFetched = time.Time{}
+	if _, err := p.VerifyIDToken(ctx, m.sign(m.baseClaims("n")), "n"); err != nil {
+		t.Errorf("after rotation: %v", err)
+	}
+}
+
+func TestDiscovery_IssuerMismatch(t *testing.T) {
+	m := newMockIssuer(t)
+	p := New(m.srv.URL+"/realm", "client", "", nil)
+	if _, err := p.AuthCodeURL(context.Background(), AuthRequest{}, "https://app/cb"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Error("metadata for another issuer accepted")
+	}
+}
@@ -0,0 +1,288 @@
+// Package pdf is a minimal PDF writer for server-side reports (pet summaries, certificates). It supports A4 pages,
+// the standard Helvetica fonts with WinAnsi text, lines, filled rectangles, and images embedded as JPEG, which is all
+// the reports need and keeps the binary free of a PDF dependency. Coordinates are in points from the top-left corner.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font selects one of the built-in fonts.
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+type pdfImage struct {
+	data          []byte // JPEG
+	width, height int
+}
+
+// Document accumulates pages in memory; call Write once all content is drawn.
+type Document struct {
+	Title   string
+	Author  string
+	pages   []*bytes.Buffer
+	images  []pdfImage
+	imgRefs []map[int]bool // per page, images used
+	created time.Time
+}
+
+// New returns an empty document with one page.
+func New() *Document {
+	d := &Document{created: time.Now()}
+	d.AddPage()
+	return d
+}
+
+// AddPage starts a new page; subsequent drawing goes to it.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.imgRefs = append(d.imgRefs, map[int]bool{})
+}
+
+// PageCount returns the number of pages.
+func (d *Document) PageCount() int { return len(d.pages) }
+
+func (d *Document) page() *bytes.Buffer { return d.pages[len(d.pages)-1] }
+
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// Text draws s with its baseline at (x, y) in color c.
+func (d *Document) Text(x, y float64, f Font, size float64, c color.Color, s string) {
+	fontName := "F1"
+	if f == Bold {
+		fontName = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT %s rg /%s %s Tf %s %s Td (%s) Tj ET\n",
+		rgb(c), fontName, num(size), num(x), num(PageHeight-y), escape(winAnsi(s)))
+}
+
+// TextRight draws s so that it ends at x.
+func (d *Document) TextRight(x, y float64, f Font, size float64, c color.Color, s string) {
+	d.Text(x-TextWidth(f, size, s), y, f, size, c, s)
+}
+
+// Line draws a straight line.
+func (d *Document) Line(x1, y1, x2, y2, width float64, c color.Color) {
+	fmt.Fprintf(d.page(), "%s RG %s w %s %s m %s %s l S\n",
+		rgb(c), num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// FillRect fills the rectangle whose top-left corner is (x, y).
+func (d *Document) FillRect(x, y, w, h float64, c color.Color) {
+	fmt.Fprintf(d.page(), "%s rg %s %s %s %s re f\n", rgb(c), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// Image draws img scaled into the w x h box whose top-left corner is (x, y). Transparent areas become white.
+func (d *Document) Image(img image.Image, x, y, w, h float64) error {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Over)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: 85}); err != nil {
+		return err
+	}
+	d.images = append(d.images, pdfImage{data: buf.Bytes(), width: b.Dx(), height: b.Dy()})
+	idx := len(d.images) - 1
+	d.imgRefs[len(d.pages)-1][idx] = true
+	fmt.Fprintf(d.page(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(PageHeight-y-h), idx)
+	return nil
+}
+
+func rgb(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%s %s %s", num(float64(r)/0xffff), num(float64(g)/0xffff), num(float64(b)/0xffff))
+}
+
+// Write serializes the document.
+func (d *Document) Write(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) int {
+		offsets = append(offsets, out.Len())
+		n := len(offsets)
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", n, body)
+		return n
+	}
+	stream := func(dict string, data []byte) int {
+		offsets = append(offsets, out.Len())
+		n := len(offsets)
+		fmt.Fprintf(&out, "%d 0 obj\n<< %s /Length %d >>\nstream\n", n, dict, len(data))
+		out.Write(data)
+		out.WriteString("\nendstream\nendobj\n")
+		return n
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// Fixed object numbers: 1 catalog, 2 page tree, 3-4 fonts, 5 info. Images and pages follow.
+	pageCount := len(d.pages)
+	firstImage := 6
+	firstPage := firstImage + len(d.images)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	obj(fmt.Sprintf("<< /Title (%s) /Author (%s) /Producer (Pet Medical) /CreationDate (D:%s) >>",
+		escape(winAnsi(d.Title)), escape(winAnsi(d.Author)), d.created.UTC().Format("20060102150405Z")))
+	for _, img := range d.images {
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode",
+			img.width, img.height), img.data)
+	}
+	for i, p := range d.pages {
+		var xobjects []string
+		for idx := range d.images {
+			if d.imgRefs[i][idx] {
+				xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", idx, firstImage+idx))
+			}
+		}
+		resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+		if len(xobjects) > 0 {
+			resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+		}
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), resources, firstPage+2*i+1))
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(p.Bytes())
+		zw.Close()
+		stream("/Filter /FlateDecode", z.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// winAnsiSpecial maps the non-Latin-1 characters of Windows-1252 that reports are likely to contain.
+var winAnsiSpecial = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96,
+	'—': 0x97, '™': 0x99,
+}
+
+// winAnsi converts UTF-8 to the single-byte WinAnsi encoding used by the standard fonts; other runes become '?'.
+func winAnsi(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			b = append(b, ' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b = append(b, byte(r))
+		case winAnsiSpecial[r] != 0:
+			b = append(b, winAnsiSpecial[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return string(b)
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// Glyph widths (per 1000 em) for ASCII 32-126, from the standard Helvetica AFM metrics.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// TextWidth returns the width of s in points. Characters outside ASCII are approximated.
+func TextWidth(f Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if f == Bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126:
+			total += widths[r-32]
+		case unicode.IsUpper(r):
+			total += 722
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Wrap splits s into lines no wider than width, breaking at spaces (and inside words longer than a line).
+func Wrap(f Font, size, width float64, s string) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(f, size, candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && TextWidth(f, size, line+string(r)) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
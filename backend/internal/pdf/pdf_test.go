@@ -0,0 +1,93 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	reader "github.com/ledongthuc/pdf"
+)
+
+func TestWrite_RoundTrip(t *testing.T) {
+	d := New()
+	d.Title = "Summary (test)"
+	d.Text(50, 60, Bold, 18, color.Black, "Rex (dog)")
+	d.FillRect(50, 80, 100, 20, color.Gray{Y: 0xee})
+	d.Line(50, 110, 200, 110, 1, color.Black)
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	if err := d.Image(img, 300, 50, 40, 30); err != nil {
+		t.Fatal(err)
+	}
+	d.AddPage()
+	d.Text(50, 60, Regular, 10, color.Black, "Überfällig")
+
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-1.4")) || !bytes.HasSuffix(buf.Bytes(), []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	r, err := reader.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reader: %v", err)
+	}
+	if r.NumPage() != 2 {
+		t.Fatalf("pages = %d, want 2", r.NumPage())
+	}
+	var text strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		s, err := r.Page(i).GetPlainText(nil)
+		if err != nil {
+			t.Fatalf("page %d: %v", i, err)
+		}
+		text.WriteString(s)
+	}
+	for _, want := range []string{"Rex (dog)", "Überfällig"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text %q does not contain %q", text.String(), want)
+		}
+	}
+}
+
+func TestWinAnsi(t *testing.T) {
+	tests := map[string]string{
+		"abc":     "abc",
+		"café":    "caf\xe9",
+		"5 €":     "5 \x80",
+		"a–b":     "a\x96b",
+		"日本":      "??",
+		"two\nln": "two ln",
+	}
+	for in, want := range tests {
+		if got := winAnsi(in); got != want {
+			t.Errorf("winAnsi(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := escape(`a(b)\c`); got != `a\(b\)\\c` {
+		t.Errorf("escape = %q", got)
+	}
+}
+
+func TestTextWidthAndWrap(t *testing.T) {
+	if got := TextWidth(Regular, 10, "Hi"); got != (722+222)*10/1000.0 {
+		t.Errorf("TextWidth = %v", got)
+	}
+	if TextWidth(Bold, 10, "abc") <= TextWidth(Regular, 10, "abc") {
+		t.Error("bold should be wider")
+	}
+	lines := Wrap(Regular, 10, 60, "the quick brown fox jumps\n\nover")
+	if len(lines) < 4 || lines[len(lines)-2] != "" || lines[len(lines)-1] != "over" {
+		t.Errorf("Wrap = %q", lines)
+	}
+	for _, l := range lines {
+		if TextWidth(Regular, 10, l) > 60 {
+			t.Errorf("line %q exceeds width", l)
+		}
+	}
+	if long := Wrap(Regular, 10, 30, "abcdefghijklmnop"); len(long) < 2 {
+		t.Errorf("long word not split: %q", long)
+	}
+}
@@ -0,0 +1,433 @@
+// Package qr encodes short byte strings (URLs) as QR codes. It implements byte mode at error-correction level M for
+// versions 1-20 (up to 666 bytes), which covers verification links printed on certificates, and picks the smallest
+// version and the mask with the lowest penalty score as the specification describes.
+package qr
+
+import "errors"
+
+// ErrTooLong is returned when the data does not fit in a version 20 symbol.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is an encoded symbol. Modules[y][x] is true for a dark module; the quiet zone is not included.
+type Code struct {
+	Version int
+	Size    int
+	Modules [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark.
+func (c *Code) Dark(x, y int) bool { return c.Modules[y][x] }
+
+// Level M block structure per version: EC codewords per block, then (blocks, data codewords) for both groups.
+var levelM = [21][5]int{
+	{},
+	{10, 1, 16, 0, 0}, {16, 1, 28, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 32, 0, 0}, {24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0}, {18, 4, 31, 0, 0}, {22, 2, 38, 2, 39}, {22, 3, 36, 2, 37}, {26, 4, 43, 1, 44},
+	{30, 1, 50, 4, 51}, {22, 6, 36, 2, 37}, {22, 8, 37, 1, 38}, {24, 4, 40, 5, 41}, {24, 5, 41, 5, 42},
+	{28, 7, 45, 3, 46}, {28, 10, 46, 1, 47}, {26, 9, 43, 4, 44}, {26, 3, 44, 11, 45}, {26, 3, 41, 13, 42},
+}
+
+var alignment = [21][]int{
+	{}, {}, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50}, {6, 30, 54}, {6, 32, 58}, {6, 34, 62},
+	{6, 26, 46, 66}, {6, 26, 48, 70}, {6, 26, 50, 74}, {6, 30, 54, 78}, {6, 30, 56, 82}, {6, 30, 58, 86},
+	{6, 34, 62, 90},
+}
+
+func dataCapacity(version int) int {
+	b := levelM[version]
+	return b[1]*b[2] + b[3]*b[4]
+}
+
+// Encode returns the QR code for data in byte mode.
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v := 1; v <= 20; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*dataCapacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+	codewords := addErrorCorrection(version, dataCodewords(version, data))
+
+	size := 17 + 4*version
+	m := &matrix{size: size, dark: newGrid(size), function: newGrid(size)}
+	m.drawFunctionPatterns(version)
+	m.drawCodewords(codewords)
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormat(mask)
+		if p := m.penalty(); best < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // masks are XOR, so applying again undoes it
+	}
+	m.applyMask(best)
+	m.drawFormat(best)
+	return &Code{Version: version, Size: size, Modules: m.dark}, nil
+}
+
+// dataCodewords builds the mode indicator, length, data, terminator, and padding for the version's capacity.
+func dataCodewords(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4) // byte mode
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := dataCapacity(version) * 8
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (v>>i)&1 == 1)
+	}
+}
+
+// addErrorCorrection splits data into blocks, appends Reed-Solomon codewords to each, and interleaves the result.
+func addErrorCorrection(version int, data []byte) []byte {
+	spec := levelM[version]
+	ecLen := spec[0]
+	var blocks [][]byte
+	for g := 0; g < 2; g++ {
+		count, size := spec[1+2*g], spec[2+2*g]
+		for i := 0; i < count; i++ {
+			blocks = append(blocks, data[:size])
+			data = data[size:]
+		}
+	}
+	gen := generator(ecLen)
+	ecBlocks := make([][]byte, len(blocks))
+	maxData := 0
+	for i, b := range blocks {
+		ecBlocks[i] = remainder(b, gen)
+		if len(b) > maxData {
+			maxData = len(b)
+		}
+	}
+	var out []byte
+	for i := 0; i < maxData; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < ecLen; i++ {
+		for _, ec := range ecBlocks {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// GF(256) arithmetic with the QR primitive polynomial x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1D
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// generator returns the coefficients (highest degree first, leading 1 omitted) of prod(x - 2^i) for i < degree.
+func generator(degree int) []byte {
+	coef := make([]byte, degree)
+	coef[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			coef[j] = gfMul(coef[j], root)
+			if j+1 < degree {
+				coef[j] ^= coef[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return coef
+}
+
+func remainder(data, gen []byte) []byte {
+	rem := make([]byte, len(gen))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, g := range gen {
+			rem[i] ^= gfMul(g, factor)
+		}
+	}
+	return rem
+}
+
+type matrix struct {
+	size     int
+	dark     [][]bool
+	function [][]bool // finder, timing, alignment, format, and version modules, excluded from data and masking
+}
+
+func newGrid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.dark[y][x] = dark
+	m.function[y][x] = true
+}
+
+func (m *matrix) drawFunctionPatterns(version int) {
+	for i := 0; i < m.size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {m.size - 4, 3}, {3, m.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= m.size || y >= m.size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				m.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	pos := alignment[version]
+	for i := range pos {
+		for j := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == len(pos)-1) || (i == len(pos)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.set(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	m.drawFormat(0) // reserve the format areas; the real bits are written once the mask is chosen
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			bit := (bits>>i)&1 == 1
+			a, b := m.size-11+i%3, i/3
+			m.set(a, b, bit)
+			m.set(b, a, bit)
+		}
+	}
+}
+
+// drawFormat writes both copies of the level M format information for mask, plus the dark module.
+func (m *matrix) drawFormat(mask int) {
+	data := mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+// drawCodewords places data bits in the two-column zigzag from the bottom-right corner, skipping function modules.
+func (m *matrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !m.function[y][x] && i < len(data)*8 {
+					m.dark[y][x] = data[i/8]&(0x80>>(i%8)) != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.function[y][x] {
+				continue
+			}
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip {
+				m.dark[y][x] = !m.dark[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of the specification; lower is better.
+func (m *matrix) penalty() int {
+	n := m.size
+	score := 0
+	line := make([]bool, n)
+	for pass := 0; pass < 2; pass++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if pass == 0 {
+					line[j] = m.dark[i][j]
+				} else {
+					line[j] = m.dark[j][i]
+				}
+			}
+			score += linePenalty(line)
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if m.dark[y][x] {
+				dark++
+			}
+			if x < n-1 && y < n-1 {
+				c := m.dark[y][x]
+				if c == m.dark[y][x+1] && c == m.dark[y+1][x] && c == m.dark[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	score += abs(dark*100/(n*n)-50) / 5 * 10
+	return score
+}
+
+// linePenalty applies rule 1 (runs of five or more) and rule 3 (finder-like 1:1:3:1:1 patterns) to one row or column.
+func linePenalty(line []bool) int {
+	score := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += 3 + run - 5
+		}
+		run = 1
+	}
+	finder := []bool{true, false, true, true, true, false, true}
+	for i := 0; i+7 <= len(line); i++ {
+		match := true
+		for k, f := range finder {
+			if line[i+k] != f {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if light(line, i-4, i) || light(line, i+7, i+11) {
+			score += 40
+		}
+	}
+	return score
+}
+
+// light reports whether line[from:to] is all light, treating positions outside the symbol as light (quiet zone).
+func light(line []bool, from, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,198 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// The worked example from the QR specification: "01234567" as version 1-M numeric data.
+func TestRemainder_SpecExample(t *testing.T) {
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	if got := remainder(data, generator(10)); !bytes.Equal(got, want) {
+		t.Errorf("remainder = % X, want % X", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	m := &matrix{size: 45, dark: newGrid(45), function: newGrid(45)}
+	formatBits := func() string {
+		var b strings.Builder
+		for i := 14; i >= 9; i-- {
+			b.WriteByte(bitChar(m.dark[8][14-i]))
+		}
+		b.WriteByte(bitChar(m.dark[8][7]))
+		b.WriteByte(bitChar(m.dark[8][8]))
+		b.WriteByte(bitChar(m.dark[7][8]))
+		for i := 5; i >= 0; i-- {
+			b.WriteByte(bitChar(m.dark[i][8]))
+		}
+		return b.String()
+	}
+	m.drawFormat(0)
+	if got := formatBits(); got != "101010000010010" {
+		t.Errorf("M/mask 0 format = %s", got)
+	}
+	m.drawFormat(5)
+	if got := formatBits(); got != "100000011001110" {
+		t.Errorf("M/mask 5 format = %s", got)
+	}
+
+	m.drawFunctionPatterns(7)
+	var version int
+	for i := 17; i >= 0; i-- {
+		version <<= 1
+		if m.dark[i/3][m.size-11+i%3] {
+			version |= 1
+		}
+	}
+	if version != 0x07C94 {
+		t.Errorf("version 7 info = %05X, want 07C94", version)
+	}
+}
+
+func bitChar(b bool) byte {
+	if b {
+		return '1'
+	}
+	return '0'
+}
+
+// TestEncode_RoundTrip reads each symbol back (format, unmask, de-interleave, error check) and compares the payload.
+func TestEncode_RoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"https://pets.example.com/api/verify/abc",
+		strings.Repeat("https://pets.example.com/api/verify/", 3) + "x",
+		strings.Repeat("0123456789abcdef", 20),
+	}
+	for _, in := range inputs {
+		code, err := Encode([]byte(in))
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(in), err)
+		}
+		if code.Size != 17+4*code.Version || len(code.Modules) != code.Size {
+			t.Fatalf("size %d for version %d", code.Size, code.Version)
+		}
+		if got := decode(t, code); got != in {
+			t.Errorf("decoded %q, want %q", got, in)
+		}
+	}
+	if _, err := Encode(make([]byte, 700)); err != ErrTooLong {
+		t.Errorf("700 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+	m := &matrix{size: code.Size, dark: newGrid(code.Size), function: newGrid(code.Size)}
+	m.drawFunctionPatterns(code.Version)
+	var format int
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | b2i(code.Dark(14-i, 8))
+	}
+	format = format<<1 | b2i(code.Dark(7, 8))
+	format = format<<1 | b2i(code.Dark(8, 8))
+	format = format<<1 | b2i(code.Dark(8, 7))
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | b2i(code.Dark(8, i))
+	}
+	format ^= 0x5412
+	if format>>13 != 0 {
+		t.Fatalf("error correction level bits = %b, want M (00)", format>>13)
+	}
+	mask := format >> 10 & 7
+
+	for y := range m.dark {
+		copy(m.dark[y], code.Modules[y])
+	}
+	m.applyMask(mask)
+	var bits bitBuffer
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if !m.function[y][right-j] {
+					bits = append(bits, m.dark[y][right-j])
+				}
+			}
+		}
+	}
+	raw := make([]byte, len(bits)/8)
+	for i := range raw {
+		for k := 0; k < 8; k++ {
+			if bits[i*8+k] {
+				raw[i] |= 0x80 >> k
+			}
+		}
+	}
+
+	spec := levelM[code.Version]
+	var sizes []int
+	for g := 0; g < 2; g++ {
+		for i := 0; i < spec[1+2*g]; i++ {
+			sizes = append(sizes, spec[2+2*g])
+		}
+	}
+	blocks := make([][]byte, len(sizes))
+	pos := 0
+	for i := 0; i < sizes[len(sizes)-1]; i++ {
+		for b, n := range sizes {
+			if i < n {
+				blocks[b] = append(blocks[b], raw[pos])
+				pos++
+			}
+		}
+	}
+	var data []byte
+	gen := generator(spec[0])
+	for b := range blocks {
+		ec := make([]byte, spec[0])
+		for i := range ec {
+			ec[i] = raw[pos+i*len(blocks)+b]
+		}
+		if got := remainder(blocks[b], gen); !bytes.Equal(got, ec) {
+			t.Fatalf("block %d error correction mismatch", b)
+		}
+		data = append(data, blocks[b]...)
+	}
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode = %x, want byte mode", data[0]>>4)
+	}
+	var r bitBuffer
+	for _, b := range data {
+		r.append(int(b), 8)
+	}
+	read := func(off, n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | b2i(r[off+i])
+		}
+		return v
+	}
+	countBits := 8
+	if code.Version >= 10 {
+		countBits = 16
+	}
+	n := read(4, countBits)
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(read(4+countBits+8*i, 8))
+	}
+	return string(out)
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth bounds nesting; attestation objects and COSE keys are at most three levels deep.
+const maxCBORDepth = 8
+
+// decodeCBOR decodes one CBOR data item (RFC 8949) from the front of data and returns it with the remaining bytes.
+// It covers what WebAuthn uses: integers (as int64), byte and text strings, arrays, maps (keyed by int64 or string),
+// booleans, and null. Indefinite lengths, tags, and floats are rejected.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeItem(data, 0)
+}
+
+func decodeItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("cbor: unexpected end of data")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data[1:], nil
+		case 21:
+			return true, data[1:], nil
+		case 22:
+			return nil, data[1:], nil
+		}
+		return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+	}
+	n, rest, err := readArgument(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(n), rest, nil
+	case 1:
+		if n > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(n), rest, nil
+	case 2, 3:
+		if n > uint64(len(rest)) {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		if major == 2 {
+			return append([]byte(nil), rest[:n]...), rest[n:], nil
+		}
+		return string(rest[:n]), rest[n:], nil
+	case 4:
+		// Every item takes at least one byte, so a count beyond the remaining data is malformed.
+		if n > uint64(len(rest)) {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		arr := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var v interface{}
+			if v, rest, err = decodeItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, rest, nil
+	case 5:
+		if n > uint64(len(rest))/2 {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		m := make(map[interface{}]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			var k, v interface{}
+			if k, rest, err = decodeItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("cbor: unsupported map key type")
+			}
+			if _, dup := m[k]; dup {
+				return nil, nil, errors.New("cbor: duplicate map key")
+			}
+			if v, rest, err = decodeItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[k] = v
+		}
+		return m, rest, nil
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+// readArgument reads the length or value that follows the initial byte.
+func readArgument(data []byte) (uint64, []byte, error) {
+	info := data[0] & 0x1f
+	data = data[1:]
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, errors.New("cbor: indefinite length or reserved value")
+	}
+	if len(data) < size {
+		return 0, nil, errors.New("cbor: unexpected end of data")
+	}
+	var n uint64
+	switch size {
+	case 1:
+		n = uint64(data[0])
+	case 2:
+		n = uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		n = uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		n = binary.BigEndian.Uint64(data)
+	}
+	return n, data[size:], nil
+}
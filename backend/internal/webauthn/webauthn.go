@@ -0,0 +1,305 @@
+// Package webauthn verifies passkey registrations and assertions (WebAuthn Level 2) for one relying party. It
+// supports ES256, EdDSA, and RS256 credential keys and does not verify attestation statements: passkeys are requested
+// with attestation "none", so the key is trusted on first use like a password set at signup.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers offered to authenticators, in order of preference.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// ChallengeSize is the number of random bytes in a challenge.
+const ChallengeSize = 32
+
+// Authenticator data flags.
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagAttested       = 0x40
+	flagExtensions     = 0x80
+)
+
+var (
+	// ErrMalformed means a client or authenticator structure could not be parsed.
+	ErrMalformed = errors.New("webauthn: malformed data")
+	// ErrClientData means clientDataJSON has the wrong type, challenge, or origin.
+	ErrClientData = errors.New("webauthn: client data does not match")
+	// ErrRelyingParty means the authenticator data is for another RP ID, or user presence or verification is missing.
+	ErrRelyingParty = errors.New("webauthn: authenticator data does not match")
+	// ErrUnsupportedKey means the credential uses an algorithm or key type this package does not verify.
+	ErrUnsupportedKey = errors.New("webauthn: unsupported credential key")
+	// ErrSignature means the assertion signature is invalid.
+	ErrSignature = errors.New("webauthn: invalid signature")
+	// ErrSignCount means the signature counter did not increase, which suggests a cloned authenticator.
+	ErrSignCount = errors.New("webauthn: signature counter did not increase")
+)
+
+// RelyingParty identifies this site. ID is the host name credentials are scoped to; Origin is the scheme://host[:port]
+// the browser reports in client data.
+type RelyingParty struct {
+	ID     string
+	Origin string
+}
+
+// Credential is what registration yields and what the server stores for later assertions.
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key, as sent by the authenticator
+	SignCount      uint32
+	BackupEligible bool // synced passkey (can exist on several devices)
+}
+
+// NewChallenge returns ChallengeSize random bytes.
+func NewChallenge() ([]byte, error) {
+	b := make([]byte, ChallengeSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// EncodeID base64url-encodes (without padding) challenges, credential IDs, and user handles, as the JSON API uses.
+func EncodeID(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// DecodeID reverses EncodeID. Padded input is accepted since some clients add it.
+func DecodeID(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(trimPadding(s))
+}
+
+func trimPadding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// VerifyRegistration checks a navigator.credentials.create() response against the challenge issued for it and
+// returns the new credential. User verification is required because passkeys replace both password and second factor.
+func (rp RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+	obj, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrMalformed
+	}
+	m, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrMalformed
+	}
+	authData, ok := m["authData"].([]byte)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	if _, ok := m["fmt"].(string); !ok {
+		return nil, ErrMalformed
+	}
+	ad, err := parseAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkAuthData(ad); err != nil {
+		return nil, err
+	}
+	if ad.credentialID == nil {
+		return nil, ErrMalformed
+	}
+	if _, _, err := parsePublicKey(ad.publicKey); err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:             ad.credentialID,
+		PublicKey:      ad.publicKey,
+		SignCount:      ad.signCount,
+		BackupEligible: ad.flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion checks a navigator.credentials.get() response signed by the stored credential key and returns the
+// authenticator's new signature counter. storedCount is the last counter seen; authenticators that do not count
+// (most synced passkeys) always report zero, which is accepted.
+func (rp RelyingParty) VerifyAssertion(challenge, clientDataJSON, authenticatorData, signature, publicKey []byte, storedCount uint32) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := parseAuthData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.checkAuthData(ad); err != nil {
+		return 0, err
+	}
+	alg, key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authenticatorData...), clientHash[:]...)
+	if !verifySignature(alg, key, signed, signature) {
+		return 0, ErrSignature
+	}
+	if (ad.signCount != 0 || storedCount != 0) && ad.signCount <= storedCount {
+		return 0, ErrSignCount
+	}
+	return ad.signCount, nil
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func (rp RelyingParty) checkClientData(raw []byte, typ string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return ErrMalformed
+	}
+	got, err := DecodeID(cd.Challenge)
+	if err != nil || cd.Type != typ || cd.CrossOrigin || cd.Origin != rp.Origin ||
+		len(challenge) == 0 || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return ErrClientData
+	}
+	return nil
+}
+
+type authData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte // only when the attested credential data flag is set
+	publicKey    []byte
+}
+
+// parseAuthData splits authenticator data (WebAuthn §6.1) into its fields.
+func parseAuthData(b []byte) (*authData, error) {
+	if len(b) < 37 {
+		return nil, ErrMalformed
+	}
+	ad := &authData{rpIDHash: b[:32], flags: b[32], signCount: binary.BigEndian.Uint32(b[33:37])}
+	rest := b[37:]
+	if ad.flags&flagAttested != 0 {
+		// AAGUID (16 bytes), credential ID length (2), credential ID, COSE public key.
+		if len(rest) < 18 {
+			return nil, ErrMalformed
+		}
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, ErrMalformed
+		}
+		ad.credentialID = append([]byte(nil), rest[:idLen]...)
+		rest = rest[idLen:]
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		ad.publicKey = append([]byte(nil), rest[:len(rest)-len(after)]...)
+		rest = after
+	}
+	if ad.flags&flagExtensions != 0 {
+		var err error
+		if _, rest, err = decodeCBOR(rest); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformed
+	}
+	return ad, nil
+}
+
+func (rp RelyingParty) checkAuthData(ad *authData) error {
+	want := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.rpIDHash, want[:]) {
+		return ErrRelyingParty
+	}
+	if ad.flags&flagUserPresent == 0 || ad.flags&flagUserVerified == 0 {
+		return ErrRelyingParty
+	}
+	return nil
+}
+
+// parsePublicKey decodes a COSE_Key (RFC 9053) into a Go public key, checking it matches a supported algorithm.
+func parsePublicKey(cose []byte) (int64, crypto.PublicKey, error) {
+	v, rest, err := decodeCBOR(cose)
+	if err != nil || len(rest) != 0 {
+		return 0, nil, ErrMalformed
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return 0, nil, ErrMalformed
+	}
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+	switch {
+	case kty == 2 && alg == AlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return 0, nil, ErrUnsupportedKey
+		}
+		// ecdh validates that the point is on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return 0, nil, ErrUnsupportedKey
+		}
+		return alg, &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case kty == 1 && alg == AlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return 0, nil, ErrUnsupportedKey
+		}
+		return alg, ed25519.PublicKey(x), nil
+	case kty == 3 && alg == AlgRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return 0, nil, ErrUnsupportedKey
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < 2048 || key.E < 3 {
+			return 0, nil, ErrUnsupportedKey
+		}
+		return alg, key, nil
+	}
+	return 0, nil, fmt.Errorf("%w (kty %d, alg %d)", ErrUnsupportedKey, kty, alg)
+}
+
+func verifySignature(alg int64, key crypto.PublicKey, signed, sig []byte) bool {
+	switch alg {
+	case AlgES256:
+		digest := sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], sig)
+	case AlgEdDSA:
+		return ed25519.Verify(key.(ed25519.PublicKey), signed, sig)
+	case AlgRS256:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
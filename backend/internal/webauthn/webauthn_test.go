@@ -0,0 +1,242 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+)
+
+var testRP = RelyingParty{ID: "pets.example.com", Origin: "https://pets.example.com"}
+
+// encodeCBOR is a minimal encoder for building test fixtures: int, []byte, string, and map[interface{}]interface{}.
+func encodeCBOR(v interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 256:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 65536:
+			return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+		}
+		b := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		return b
+	}
+	switch x := v.(type) {
+	case int:
+		if x < 0 {
+			return head(1, uint64(-1-x))
+		}
+		return head(0, uint64(x))
+	case []byte:
+		return append(head(2, uint64(len(x))), x...)
+	case string:
+		return append(head(3, uint64(len(x))), x...)
+	case map[interface{}]interface{}:
+		keys := make([][]byte, 0, len(x))
+		byKey := map[string]interface{}{}
+		for k, val := range x {
+			kb := encodeCBOR(k)
+			keys = append(keys, kb)
+			byKey[string(kb)] = val
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+		out := head(5, uint64(len(x)))
+		for _, kb := range keys {
+			out = append(append(out, kb...), encodeCBOR(byKey[string(kb)])...)
+		}
+		return out
+	}
+	panic("unsupported fixture type")
+}
+
+type authenticator struct {
+	alg    int
+	signer crypto.Signer
+	credID []byte
+	count  uint32
+}
+
+func newAuthenticator(t *testing.T, alg int) *authenticator {
+	t.Helper()
+	var s crypto.Signer
+	var err error
+	switch alg {
+	case AlgES256:
+		s, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, s, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		s, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authenticator{alg: alg, signer: s, credID: []byte("test-credential")}
+}
+
+func (a *authenticator) coseKey() []byte {
+	switch pub := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		return encodeCBOR(map[interface{}]interface{}{1: 2, 3: AlgES256, -1: 1, -2: pub.X.FillBytes(make([]byte, 32)), -3: pub.Y.FillBytes(make([]byte, 32))})
+	case ed25519.PublicKey:
+		return encodeCBOR(map[interface{}]interface{}{1: 1, 3: AlgEdDSA, -1: 6, -2: []byte(pub)})
+	case *rsa.PublicKey:
+		e := make([]byte, 4)
+		binary.BigEndian.PutUint32(e, uint32(pub.E))
+		return encodeCBOR(map[interface{}]interface{}{1: 3, 3: AlgRS256, -1: pub.N.Bytes(), -2: bytes.TrimLeft(e, "\x00")})
+	}
+	return nil
+}
+
+func (a *authenticator) authData(rpID string, flags byte, attested bool) []byte {
+	h := sha256.Sum256([]byte(rpID))
+	b := append(h[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b[33:], a.count)
+	if attested {
+		b = append(b, make([]byte, 16)...)
+		b = append(b, byte(len(a.credID)>>8), byte(len(a.credID)))
+		b = append(b, a.credID...)
+		b = append(b, a.coseKey()...)
+	}
+	return b
+}
+
+func clientDataJSON(typ string, challenge []byte, origin string) []byte {
+	b, _ := json.Marshal(map[string]interface{}{"type": typ, "challenge": EncodeID(challenge), "origin": origin})
+	return b
+}
+
+func (a *authenticator) register(challenge []byte) (clientData, attestation []byte) {
+	ad := a.authData(testRP.ID, flagUserPresent|flagUserVerified|flagBackupEligible|flagAttested, true)
+	obj := encodeCBOR(map[interface{}]interface{}{"fmt": "none", "attStmt": map[interface{}]interface{}{}, "authData": ad})
+	return clientDataJSON("webauthn.create", challenge, testRP.Origin), obj
+}
+
+func (a *authenticator) assert(challenge []byte, origin string) (clientData, authData, sig []byte) {
+	clientData = clientDataJSON("webauthn.get", challenge, origin)
+	authData = a.authData(testRP.ID, flagUserPresent|flagUserVerified, false)
+	h := sha256.Sum256(clientData)
+	signed := append(append([]byte(nil), authData...), h[:]...)
+	var err error
+	if a.alg == AlgEdDSA {
+		sig, err = a.signer.Sign(rand.Reader, signed, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(signed)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return clientData, authData, sig
+}
+
+func TestRegisterAndAssert(t *testing.T) {
+	for _, alg := range []int{AlgES256, AlgEdDSA, AlgRS256} {
+		a := newAuthenticator(t, alg)
+		challenge, _ := NewChallenge()
+		cd, att := a.register(challenge)
+		cred, err := testRP.VerifyRegistration(challenge, cd, att)
+		if err != nil {
+			t.Fatalf("alg %d: register: %v", alg, err)
+		}
+		if !bytes.Equal(cred.ID, a.credID) || !cred.BackupEligible {
+			t.Errorf("alg %d: credential = %+v", alg, cred)
+		}
+
+		a.count = 5
+		challenge, _ = NewChallenge()
+		cd, ad, sig := a.assert(challenge, testRP.Origin)
+		count, err := testRP.VerifyAssertion(challenge, cd, ad, sig, cred.PublicKey, 0)
+		if err != nil || count != 5 {
+			t.Fatalf("alg %d: assert = %d, %v", alg, count, err)
+		}
+		if _, err := testRP.VerifyAssertion(challenge, cd, ad, sig, cred.PublicKey, 5); !errors.Is(err, ErrSignCount) {
+			t.Errorf("alg %d: replayed counter: %v", alg, err)
+		}
+		sig[len(sig)-1] ^= 1
+		if _, err := testRP.VerifyAssertion(challenge, cd, ad, sig, cred.PublicKey, 0); err == nil {
+			t.Errorf("alg %d: tampered signature accepted", alg)
+		}
+	}
+}
+
+func TestAssert_ZeroCounterAccepted(t *testing.T) {
+	a := newAuthenticator(t, AlgES256)
+	challenge, _ := NewChallenge()
+	cd, ad, sig := a.assert(challenge, testRP.Origin)
+	if _, err := testRP.VerifyAssertion(challenge, cd, ad, sig, a.coseKey(), 0); err != nil {
+		t.Errorf("zero counter: %v", err)
+	}
+}
+
+func TestRejections(t *testing.T) {
+	a := newAuthenticator(t, AlgES256)
+	challenge, _ := NewChallenge()
+	other, _ := NewChallenge()
+	key := a.coseKey()
+
+	cd, ad, sig := a.assert(challenge, testRP.Origin)
+	if _, err := testRP.VerifyAssertion(other, cd, ad, sig, key, 0); !errors.Is(err, ErrClientData) {
+		t.Errorf("wrong challenge: %v", err)
+	}
+	cd, ad, sig = a.assert(challenge, "https://evil.example.com")
+	if _, err := testRP.VerifyAssertion(challenge, cd, ad, sig, key, 0); !errors.Is(err, ErrClientData) {
+		t.Errorf("wrong origin: %v", err)
+	}
+	cd, att := a.register(challenge)
+	if _, err := testRP.VerifyAssertion(challenge, cd, ad, sig, key, 0); !errors.Is(err, ErrClientData) {
+		t.Errorf("create client data used for get: %v", err)
+	}
+	otherRP := RelyingParty{ID: "example.com", Origin: testRP.Origin}
+	if _, err := otherRP.VerifyRegistration(challenge, cd, att); !errors.Is(err, ErrRelyingParty) {
+		t.Errorf("wrong rp id: %v", err)
+	}
+	noUV := a.authData(testRP.ID, flagUserPresent|flagAttested, true)
+	att = encodeCBOR(map[interface{}]interface{}{"fmt": "none", "attStmt": map[interface{}]interface{}{}, "authData": noUV})
+	if _, err := testRP.VerifyRegistration(challenge, cd, att); !errors.Is(err, ErrRelyingParty) {
+		t.Errorf("missing user verification: %v", err)
+	}
+	if _, err := testRP.VerifyRegistration(challenge, cd, att[:len(att)-3]); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated attestation: %v", err)
+	}
+	p384 := encodeCBOR(map[interface{}]interface{}{1: 2, 3: -35, -1: 2, -2: make([]byte, 48), -3: make([]byte, 48)})
+	if _, _, err := parsePublicKey(p384); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("ES384 key: %v", err)
+	}
+	offCurve := encodeCBOR(map[interface{}]interface{}{1: 2, 3: AlgES256, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32)})
+	if _, _, err := parsePublicKey(offCurve); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("point not on curve: %v", err)
+	}
+}
+
+func TestDecodeCBOR_Malformed(t *testing.T) {
+	for _, in := range [][]byte{
+		{},
+		{0x5f},                         // indefinite byte string
+		{0x44, 1, 2},                   // byte string shorter than its length
+		{0xa1, 0x01},                   // map missing its value
+		{0xa2, 0x01, 0x01, 0x01, 0x02}, // duplicate key
+		{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, // absurd array length
+		{0xc0, 0x00}, // tag
+	} {
+		if _, _, err := decodeCBOR(in); err == nil {
+			t.Errorf("% x decoded without error", in)
+		}
+	}
+	v, rest, err := decodeCBOR([]byte{0x38, 0x18, 0xff})
+	if err != nil || v != int64(-25) || !bytes.Equal(rest, []byte{0xff}) {
+		t.Errorf("negative int = %v, % x, %v", v, rest, err)
+	}
+}
@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// metadataAddrs are cloud instance-metadata endpoints outside the link-local range, which is always refused.
+var metadataAddrs = []net.IP{
+	net.ParseIP("fd00:ec2::254"),   // AWS IPv6
+	net.ParseIP("100.100.100.200"), // Alibaba Cloud
+}
+
+// sharedAddrSpace is carrier-grade NAT (RFC 6598), treated as private.
+var sharedAddrSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// newClient returns the delivery client. It does not follow redirects, so a target cannot bounce the request to
+// another host, and it checks every address it connects to (after DNS resolution, so rebinding does not help):
+// link-local and cloud metadata addresses are always refused, loopback and private ranges unless allowPrivate.
+func newClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowedTarget(ip, allowPrivate) {
+				return fmt.Errorf("webhook target %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would make the dialed address the proxy's, not the target's
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   deliveryTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// allowedTarget reports whether a webhook may be delivered to ip. Private targets (loopback, RFC 1918, IPv6 unique
+// local, carrier-grade NAT) are allowed only with allowPrivate, for home-automation servers on the LAN.
+func allowedTarget(ip net.IP, allowPrivate bool) bool {
+	if ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, m := range metadataAddrs {
+		if ip.Equal(m) {
+			return false
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || sharedAddrSpace.Contains(ip) {
+		return allowPrivate
+	}
+	return true
+}
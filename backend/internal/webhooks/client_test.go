@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestAllowedTarget(t *testing.T) {
+	tests := []struct {
+		ip          string
+		strict, lan bool // allowed without / with allowPrivate
+	}{
+		{"93.184.216.34", true, true},
+		{"2606:2800:220:1::1", true, true},
+		{"127.0.0.1", false, true},
+		{"::1", false, true},
+		{"10.1.2.3", false, true},
+		{"172.16.0.10", false, true},
+		{"192.168.1.20", false, true},
+		{"fd12:3456::1", false, true},
+		{"100.64.0.1", false, true},
+		{"169.254.169.254", false, false},
+		{"fe80::1", false, false},
+		{"fd00:ec2::254", false, false},
+		{"100.100.100.200", false, false},
+		{"0.0.0.0", false, false},
+		{"224.0.0.1", false, false},
+	}
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.ip)
+		if got := allowedTarget(ip, false); got != tt.strict {
+			t.Errorf("allowedTarget(%s, false) = %v, want %v", tt.ip, got, tt.strict)
+		}
+		if got := allowedTarget(ip, true); got != tt.lan {
+			t.Errorf("allowedTarget(%s, true) = %v, want %v", tt.ip, got, tt.lan)
+		}
+	}
+}
+
+func TestNewClient_RefusesPrivateTarget(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+	d := &Dispatcher{Client: newClient(false)}
+	code, err := d.send(models.WebhookSubscription{URL: srv.URL}, &models.WebhookDelivery{Payload: "{}"})
+	if err == nil || code != 0 || hit || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("send = %d, %v (hit=%v); want refused before connecting", code, err, hit)
+	}
+}
+
+func TestNewClient_DoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/elsewhere" {
+			followed = true
+			return
+		}
+		http.Redirect(w, r, "/elsewhere", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+	d := &Dispatcher{Client: newClient(true)}
+	code, err := d.send(models.WebhookSubscription{URL: srv.URL}, &models.WebhookDelivery{Payload: "{}"})
+	if err == nil || code != http.StatusTemporaryRedirect || followed {
+		t.Errorf("send = %d, %v (followed=%v); want the redirect reported as a failure", code, err, followed)
+	}
+}
@@ -0,0 +1,346 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event types a subscription can listen for.
+const (
+	EventPetCreated         = "pet.created"
+	EventPetUpdated         = "pet.updated"
+	EventPetDeleted         = "pet.deleted"
+	EventVaccinationCreated = "vaccination.created"
+	EventVaccinationUpdated = "vaccination.updated"
+	EventVaccinationDeleted = "vaccination.deleted"
+	EventVaccinationDue     = "vaccination.due"
+	EventDocumentUploaded   = "document.uploaded"
+	EventWeightRecorded     = "weight.recorded"
+	EventTest               = "webhook.test" // sent only by the "send test event" endpoint
+)
+
+// Events lists the subscribable event types.
+var Events = []string{
+	EventPetCreated, EventPetUpdated, EventPetDeleted,
+	EventVaccinationCreated, EventVaccinationUpdated, EventVaccinationDeleted, EventVaccinationDue,
+	EventDocumentUploaded, EventWeightRecorded,
+}
+
+// ValidEvent reports whether e is a subscribable event type.
+func ValidEvent(e string) bool {
+	for _, x := range Events {
+		if x == e {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	maxAttempts      = 8
+	baseRetryDelay   = 30 * time.Second
+	maxRetryDelay    = 6 * time.Hour
+	pollInterval     = 15 * time.Second
+	dueCheckInterval = time.Hour
+	dueLookbackDays  = 7 // vaccination.due fires for due dates up to this many days ago, so a downtime does not skip them
+	batchSize        = 20
+	maxResponseBytes = 4096
+	deliveryTimeout  = 10 * time.Second
+	signatureHeader  = "X-PetMedical-Signature"
+	timestampHeader  = "X-PetMedical-Timestamp"
+	eventHeader      = "X-PetMedical-Event"
+	deliveryHeader   = "X-PetMedical-Delivery"
+)
+
+// Payload is the JSON body of every webhook request.
+type Payload struct {
+	ID        uuid.UUID   `json:"id"` // delivery id; stable across retries
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher queues webhook deliveries in the database and sends them from a background worker with exponential
+// retry. A nil *Dispatcher is valid and drops all events, so handlers work without webhooks configured.
+type Dispatcher struct {
+	DB     *gorm.DB
+	Client *http.Client
+	nudge  chan struct{}
+}
+
+// NewDispatcher returns a dispatcher whose client refuses link-local and metadata targets, and loopback and private
+// ones unless allowPrivate (see newClient). Subscription URLs come from any account and delivery results are shown
+// back to it, so an unrestricted client would let users probe the server's network.
+func NewDispatcher(db *gorm.DB, allowPrivate bool) *Dispatcher {
+	return &Dispatcher{
+		DB:     db,
+		Client: newClient(allowPrivate),
+		nudge:  make(chan struct{}, 1),
+	}
+}
+
+// Emit queues event for each of userID's active subscriptions that listen for it. Errors are logged, not returned,
+// so a webhook problem never fails the write that triggered it.
+func (d *Dispatcher) Emit(userID uuid.UUID, event string, data interface{}) {
+	if d == nil {
+		return
+	}
+	var subs []models.WebhookSubscription
+	if err := d.DB.Where("user_id = ? AND active = ?", userID, true).Find(&subs).Error; err != nil {
+		log.Printf("[WEBHOOKS] list subscriptions user_id=%s: %v", userID, err)
+		return
+	}
+	queued := false
+	for _, s := range subs {
+		if !Subscribes(s, event) {
+			continue
+		}
+		if _, err := d.Enqueue(s, event, data); err != nil {
+			log.Printf("[WEBHOOKS] enqueue %s subscription_id=%s: %v", event, s.ID, err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		d.wake()
+	}
+}
+
+// Enqueue stores a pending delivery of event to sub regardless of its event filter (used for test events).
+func (d *Dispatcher) Enqueue(sub models.WebhookSubscription, event string, data interface{}) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	del := models.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		Event:          event,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+	}
+	body, err := json.Marshal(Payload{ID: del.ID, Event: event, CreatedAt: now.UTC(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	del.Payload = string(body)
+	if err := d.DB.Create(&del).Error; err != nil {
+		return nil, err
+	}
+	d.wake()
+	return &del, nil
+}
+
+// Subscribes reports whether sub listens for event.
+func Subscribes(sub models.WebhookSubscription, event string) bool {
+	for _, e := range strings.Split(sub.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) wake() {
+	if d.nudge == nil {
+		return
+	}
+	select {
+	case d.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the delivery worker and the hourly vaccination.due check until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+		due := time.NewTicker(dueCheckInterval)
+		defer due.Stop()
+		d.emitDueVaccinations(time.Now())
+		for {
+			d.deliverPending(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-poll.C:
+			case <-d.nudge:
+			case <-due.C:
+				d.emitDueVaccinations(time.Now())
+			}
+		}
+	}()
+}
+
+// deliverPending sends deliveries whose next attempt is due, in batches until none are left.
+func (d *Dispatcher) deliverPending(now time.Time) {
+	for {
+		var batch []models.WebhookDelivery
+		err := d.DB.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").Limit(batchSize).Find(&batch).Error
+		if err != nil {
+			log.Printf("[WEBHOOKS] list pending deliveries: %v", err)
+			return
+		}
+		for i := range batch {
+			d.attempt(&batch[i], now)
+		}
+		if len(batch) < batchSize {
+			return
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome, scheduling a retry on failure.
+func (d *Dispatcher) attempt(del *models.WebhookDelivery, now time.Time) {
+	var sub models.WebhookSubscription
+	if err := d.DB.Where("id = ?", del.SubscriptionID).First(&sub).Error; err != nil || !sub.Active {
+		msg := "subscription deleted or inactive"
+		d.DB.Model(del).Updates(map[string]interface{}{"status": models.WebhookDeliveryFailed, "last_error": msg, "updated_at": now})
+		return
+	}
+	code, err := d.send(sub, del)
+	attempts := del.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts, "updated_at": time.Now()}
+	if code != 0 {
+		updates["last_status_code"] = code
+	}
+	switch {
+	case err == nil:
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = time.Now()
+		updates["last_error"] = nil
+	case attempts >= maxAttempts:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = err.Error()
+	default:
+		updates["next_attempt_at"] = now.Add(RetryDelay(attempts))
+		updates["last_error"] = err.Error()
+	}
+	if err := d.DB.Model(&models.WebhookDelivery{}).Where("id = ?", del.ID).Updates(updates).Error; err != nil {
+		log.Printf("[WEBHOOKS] record delivery %s: %v", del.ID, err)
+	}
+}
+
+// send POSTs the payload. Any 2xx response is success; the status code is returned when a response was received.
+func (d *Dispatcher) send(sub models.WebhookSubscription, del *models.WebhookDelivery) (int, error) {
+	body := []byte(del.Payload)
+	ts := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PetMedical-Webhooks/1")
+	req.Header.Set(eventHeader, del.Event)
+	req.Header.Set(deliveryHeader, del.ID.String())
+	req.Header.Set(timestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(signatureHeader, "sha256="+Sign(sub.Secret, ts, body))
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" with secret. Receivers recompute it from the
+// X-PetMedical-Timestamp header and raw body, compare in constant time, and reject old timestamps to prevent replay.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay is the wait after the given number of failed attempts: 30s, 1m, 2m, 4m, ... capped at 6h.
+func RetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := baseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+// emitDueVaccinations fires vaccination.due once per vaccination and due date, for the latest dose of each vaccine
+// whose next_due is today or within the last dueLookbackDays. Emitted events are recorded in sent_notifications.
+func (d *Dispatcher) emitDueVaccinations(now time.Time) {
+	var subs []models.WebhookSubscription
+	if err := d.DB.Where("active = ?", true).Find(&subs).Error; err != nil {
+		log.Printf("[WEBHOOKS] list subscriptions: %v", err)
+		return
+	}
+	users := make(map[uuid.UUID]bool)
+	for _, s := range subs {
+		if Subscribes(s, EventVaccinationDue) {
+			users[s.UserID] = true
+		}
+	}
+	today := now.Format("2006-01-02")
+	from := now.AddDate(0, 0, -dueLookbackDays).Format("2006-01-02")
+	for userID := range users {
+		type row struct {
+			models.Vaccination
+			PetName string
+		}
+		var rows []row
+		err := d.DB.Table("vaccinations").
+			Select("vaccinations.*, pets.name AS pet_name").
+			Joins("JOIN pets ON pets.id = vaccinations.pet_id").
+			Where("pets.user_id = ?", userID).
+			Order("vaccinations.administered_at DESC, vaccinations.created_at DESC").
+			Scan(&rows).Error
+		if err != nil {
+			log.Printf("[WEBHOOKS] list vaccinations user_id=%s: %v", userID, err)
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, r := range rows {
+			key := r.PetID.String() + "|" + strings.ToLower(strings.Join(strings.Fields(r.Name), " "))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if r.NextDue == nil || *r.NextDue > today || *r.NextDue < from {
+				continue
+			}
+			mark := models.SentNotification{
+				UserID: userID, Kind: models.NotificationWebhookVaccinationDue, SubjectID: r.ID, DueDate: *r.NextDue, SentAt: now,
+			}
+			res := d.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&mark)
+			if res.Error != nil || res.RowsAffected == 0 {
+				continue // already emitted (or failed to record; retry next hour)
+			}
+			d.Emit(userID, EventVaccinationDue, map[string]interface{}{
+				"vaccination": r.Vaccination,
+				"pet_name":    r.PetName,
+			})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pet-medical/api/internal/models"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"pet.created"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := hex.EncodeToString(mac.Sum(nil))
+	if got := Sign("secret", 1700000000, body); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("other", 1700000000, body) == want {
+		t.Error("different secret should give a different signature")
+	}
+	if Sign("secret", 1700000001, body) == want {
+		t.Error("different timestamp should give a different signature")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := RetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribes(t *testing.T) {
+	sub := models.WebhookSubscription{Events: "pet.created, weight.recorded"}
+	if !Subscribes(sub, EventPetCreated) || !Subscribes(sub, EventWeightRecorded) {
+		t.Error("expected listed events to match")
+	}
+	if Subscribes(sub, EventPetDeleted) || Subscribes(sub, "pet") {
+		t.Error("unlisted event matched")
+	}
+}
+
+func TestValidEvent(t *testing.T) {
+	if !ValidEvent(EventVaccinationDue) {
+		t.Error("vaccination.due should be valid")
+	}
+	if ValidEvent(EventTest) {
+		t.Error("webhook.test is not subscribable")
+	}
+}
+
+func TestNilDispatcherEmit(t *testing.T) {
+	var d *Dispatcher
+	d.Emit(uuid.New(), EventPetCreated, nil) // must not panic
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	var gotSig, gotTS, gotEvent, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		gotSig = r.Header.Get(signatureHeader)
+		gotTS = r.Header.Get(timestampHeader)
+		gotEvent = r.Header.Get(eventHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := &Dispatcher{Client: srv.Client()}
+	sub := models.WebhookSubscription{ID: uuid.New(), URL: srv.URL, Secret: "s3cret-s3cret-s3cret"}
+	del := &models.WebhookDelivery{ID: uuid.New(), Event: EventPetCreated, Payload: `{"event":"pet.created"}`}
+	code, err := d.send(sub, del)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("send = %d, %v", code, err)
+	}
+	if gotBody != del.Payload || gotEvent != EventPetCreated {
+		t.Errorf("body %q event %q", gotBody, gotEvent)
+	}
+	ts, err := strconv.ParseInt(gotTS, 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp %q: %v", gotTS, err)
+	}
+	if want := "sha256=" + Sign(sub.Secret, ts, []byte(gotBody)); gotSig != want {
+		t.Errorf("signature %q, want %q", gotSig, want)
+	}
+}
+
+func TestSendNon2xxIsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	d := &Dispatcher{Client: srv.Client()}
+	code, err := d.send(models.WebhookSubscription{URL: srv.URL}, &models.WebhookDelivery{Payload: "{}"})
+	if err == nil || code != http.StatusBadGateway {
+		t.Errorf("send = %d, %v; want 502 and error", code, err)
+	}
+}